@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDateBucketUnsupported = errors.New("dialect does not support date buckets")
+	ErrStringAggUnsupported  = errors.New("dialect does not support string aggregation")
+	ErrStringAggTruncated    = errors.New("aggregated values exceed the string aggregation limit")
+)
+
+// aggSeparator joins aggregated values. The ASCII unit separator is not
+// expected to appear in the values themselves.
+const aggSeparator = "\x1f"
+
+// Bucket is the length of the periods CountByDateBucket groups rows by.
+type Bucket int
+
+const (
+	BucketDay Bucket = iota
+	BucketWeek
+	BucketMonth
+)
+
+func (b Bucket) String() string {
+	switch b {
+	case BucketWeek:
+		return "week"
+	case BucketMonth:
+		return "month"
+	default:
+		return "day"
+	}
+}
+
+// SumGroupedBy sums sumColumn per distinct value of groupColumn and returns the
+// totals keyed by the group value. The total of the rows whose group value is
+// NULL is returned separately in nulls, which is nil when there is no such
+// group. Only groups whose total satisfies every having condition are
+// returned; a having condition applies to SUM(sumColumn) and its Column may
+// be left empty.
+func (r *entityRepository[E, ID]) SumGroupedBy(groupColumn, sumColumn string, having ...Condition) (totals map[string]float64, nulls *float64, err error) {
+	validColumns := r.validColumns()
+	for _, column := range []string{groupColumn, sumColumn} {
+		if !validColumns[column] {
+			return nil, nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+
+	sumExpr := fmt.Sprintf("SUM(%s)", sumColumn)
+	havingClause := &whereBuilder{dialect: r.options.dialect}
+	for _, c := range having {
+		if c.Column != "" && c.Column != sumColumn {
+			return nil, nil, fmt.Errorf("having condition on %q does not match summed column %q", c.Column, sumColumn)
+		}
+		if err := addComparison(havingClause, sumExpr, c); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tableName := r.table()
+
+	where := r.where()
+	query := fmt.Sprintf("SELECT %s AS group_value, COALESCE(%s, 0) AS total FROM %s%s GROUP BY %s%s",
+		groupColumn, sumExpr, tableName, where, groupColumn, havingClause.clause("HAVING"))
+
+	var rows []struct {
+		Group sql.NullString `db:"group_value"`
+		Total float64        `db:"total"`
+	}
+	err = r.selectAll(&rows, query, append(where.args, havingClause.args...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totals = make(map[string]float64, len(rows))
+	for _, row := range rows {
+		if !row.Group.Valid {
+			nulls = &row.Total
+			continue
+		}
+		totals[row.Group.String] = row.Total
+	}
+	return totals, nulls, nil
+}
+
+// ListGroupedBy collects the non-NULL values of valueColumn per distinct value
+// of groupColumn, with GROUP_CONCAT on MySQL and STRING_AGG on
+// StandardDialect, and returns them in ascending order keyed by the group
+// value. The values of the rows whose group value is NULL are returned
+// separately in nulls. Groups without values are absent. On MySQL
+// a group's values are cut off once they exceed group_concat_max_len bytes,
+// 1024 by default; ListGroupedBy then fails with ErrStringAggTruncated rather
+// than return partial lists, and large groups need the session variable
+// raised, e.g. with SessionVarsConnector.
+func (r *entityRepository[E, ID]) ListGroupedBy(groupColumn, valueColumn string) (lists map[string][]string, nulls []string, err error) {
+	aggregator, ok := r.options.dialect.(StringAggregator)
+	if !ok {
+		return nil, nil, ErrStringAggUnsupported
+	}
+	validColumns := r.validColumns()
+	for _, column := range []string{groupColumn, valueColumn} {
+		if !validColumns[column] {
+			return nil, nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+
+	// With a limit, also select the length the values would have untruncated.
+	var length string
+	if limiter, ok := r.options.dialect.(StringAggLimiter); ok {
+		length = fmt.Sprintf(", SUM(LENGTH(%s)) + (COUNT(%s) - 1) * %d AS agg_length, %s AS agg_limit",
+			valueColumn, valueColumn, len(aggSeparator), limiter.StringAggLimit())
+	}
+	where := r.where()
+	where.add(fmt.Sprintf("%s IS NOT NULL", valueColumn))
+	query := fmt.Sprintf("SELECT %s AS group_value, %s AS agg_values%s FROM %s%s GROUP BY %s",
+		groupColumn, aggregator.StringAgg(valueColumn, aggSeparator), length, r.table(), where, groupColumn)
+
+	var rows []struct {
+		Group  sql.NullString `db:"group_value"`
+		Values string         `db:"agg_values"`
+		Length int64          `db:"agg_length"`
+		Limit  int64          `db:"agg_limit"`
+	}
+	if err := r.selectAll(&rows, query, where.args...); err != nil {
+		return nil, nil, err
+	}
+
+	lists = make(map[string][]string, len(rows))
+	for _, row := range rows {
+		if length != "" && row.Length > row.Limit {
+			return nil, nil, fmt.Errorf("%w: group %q needs %d bytes, the limit is %d", ErrStringAggTruncated, row.Group.String, row.Length, row.Limit)
+		}
+		values := strings.Split(row.Values, aggSeparator)
+		if !row.Group.Valid {
+			nulls = values
+			continue
+		}
+		lists[row.Group.String] = values
+	}
+	return lists, nulls, nil
+}
+
+// CountGroupedBy counts the rows per distinct value of column and returns the
+// counts keyed by the group value. Rows whose value is NULL are counted
+// separately and returned as nulls. Use CountGroupedByTyped to keep the group
+// values' type.
+func (r *entityRepository[E, ID]) CountGroupedBy(column string) (counts map[string]int64, nulls int64, err error) {
+	return CountGroupedByTyped[string](r, column)
+}
+
+// CountGroupedByTyped counts the rows of repo per distinct value of column,
+// scanning each group value into K, which may be any type the driver can scan
+// the column into, such as an integer status code or a string enum. Rows whose
+// value is NULL are counted separately and returned as nulls.
+func CountGroupedByTyped[K comparable, E Entity[ID], ID comparable](repo Repository[E, ID], column string) (counts map[K]int64, nulls int64, err error) {
+	r, ok := repo.(*entityRepository[E, ID])
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported repository implementation %T", repo)
+	}
+	if !r.validColumns()[column] {
+		return nil, 0, fmt.Errorf("unknown column %q", column)
+	}
+
+	where := r.where()
+	query := fmt.Sprintf("SELECT %s AS group_value, COUNT(*) AS total FROM %s%s GROUP BY %s",
+		column, r.table(), where, column)
+	rows, err := r.queryRows(query, where.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	counts = make(map[K]int64)
+	for rows.Next() {
+		var group sql.Null[K]
+		var total int64
+		if err := rows.Scan(&group, &total); err != nil {
+			return nil, 0, err
+		}
+		if !group.Valid {
+			nulls += total
+			continue
+		}
+		counts[group.V] += total
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return counts, nulls, nil
+}
+
+// DistinctValues returns the distinct non-NULL values of column among the
+// rows of repo matching conditions, in ascending order, scanned into T, which
+// may be any type the driver can scan the column into, e.g. to fill the
+// options of a filter dropdown.
+func DistinctValues[T any, E Entity[ID], ID comparable](repo Repository[E, ID], column string, conditions map[string]any) ([]T, error) {
+	r, ok := repo.(*entityRepository[E, ID])
+	if !ok {
+		return nil, fmt.Errorf("unsupported repository implementation %T", repo)
+	}
+	if !r.validColumns()[column] {
+		return nil, fmt.Errorf("unknown column %q", column)
+	}
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+	where.add(fmt.Sprintf("%s IS NOT NULL", column))
+	query := fmt.Sprintf("SELECT DISTINCT %s FROM %s%s ORDER BY %s", column, r.table(), where, column)
+	var values []T
+	if err := r.selectAll(&values, query, where.args...); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// CountByDateBucket counts the rows per day, week or month of column, which
+// must be mapped to a time.Time, *time.Time or sql.NullTime field, and returns
+// the counts keyed by the first day of each bucket at midnight. Weeks start on
+// Monday. Rows whose column is NULL are not counted, and buckets without rows
+// are absent rather than zero. The dialect must implement DateTruncator,
+// otherwise ErrDateBucketUnsupported is returned.
+func (r *entityRepository[E, ID]) CountByDateBucket(column string, bucket Bucket) (map[time.Time]int64, error) {
+	if bucket < BucketDay || bucket > BucketMonth {
+		return nil, fmt.Errorf("unsupported bucket %d", bucket)
+	}
+	truncator, ok := r.options.dialect.(DateTruncator)
+	if !ok {
+		return nil, ErrDateBucketUnsupported
+	}
+	c, ok := r.column(column)
+	if !ok {
+		return nil, fmt.Errorf("unknown column %q", column)
+	}
+	var emptyEntity E
+	if !isTimeType(reflect.TypeOf(emptyEntity).FieldByIndex(c.Index).Type) {
+		return nil, fmt.Errorf("column %q is not a date or time column", column)
+	}
+
+	where := r.where()
+	where.add(fmt.Sprintf("%s IS NOT NULL", column))
+	query := fmt.Sprintf("SELECT %s AS bucket, COUNT(*) AS total FROM %s%s GROUP BY 1",
+		truncator.TruncateDate(column, bucket), r.table(), where)
+	rows, err := r.queryRows(query, where.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[time.Time]int64)
+	for rows.Next() {
+		var value any
+		var total int64
+		if err := rows.Scan(&value, &total); err != nil {
+			return nil, err
+		}
+		start, err := bucketStart(value)
+		if err != nil {
+			return nil, err
+		}
+		counts[start] += total
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// isTimeType reports whether fields of type t hold a time.
+func isTimeType(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t == timeType || t == reflect.TypeOf(sql.NullTime{})
+}
+
+// bucketStart converts a scanned DATE to a time. The driver returns a
+// time.Time with parseTime=true in the DSN and the text of the date otherwise,
+// which is read as UTC.
+func bucketStart(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return time.Parse(time.DateOnly, string(v))
+	case string:
+		return time.Parse(time.DateOnly, v)
+	}
+	return time.Time{}, fmt.Errorf("unexpected date bucket value %T", value)
+}
+
+// MaxID returns the greatest id among the rows visible through the
+// repository, or the zero ID when there are none. MAX(id) is answered from the
+// primary key index without scanning the table unless the repository adds
+// conditions, such as a tenant or soft delete scope. Ids that are not numbers
+// are compared as the database orders them, e.g. by collation for strings.
+func (r *entityRepository[E, ID]) MaxID() (ID, error) {
+	where := r.where()
+	query := fmt.Sprintf("SELECT MAX(id) FROM %s%s", r.table(), where)
+	var max sql.Null[ID]
+	if err := r.getOne(&max, query, where.args...); err != nil {
+		var zero ID
+		return zero, err
+	}
+	return max.V, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxID_Query(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	_, err = repo.MaxID()
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT MAX(id) FROM sample_entities", query)
+	require.Empty(t, args)
+}
+
+func TestDistinctValues(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[OrderEntity](db, WithQueryCapture())
+	_, err = DistinctValues[string](repo, "customer", map[string]any{"amount": 10})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT DISTINCT customer FROM order_entities WHERE amount = ? AND customer IS NOT NULL ORDER BY customer", query)
+	require.Equal(t, []any{10}, args)
+
+	_, err = DistinctValues[string](repo, "customer; --", nil)
+	require.ErrorContains(t, err, "unknown column")
+	_, err = DistinctValues[string](repo, "customer", map[string]any{"unknown": 1})
+	require.Error(t, err)
+}
+
+func TestSumGroupedBy_NullGroup(t *testing.T) {
+	connector := &recordingConnector{
+		columns: []string{"group_value", "total"},
+		rows: [][]driver.Value{
+			{[]byte(""), float64(5)},
+			{nil, float64(7)},
+			{[]byte("alice"), float64(3)},
+		},
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	totals, nulls, err := NewEntityRepository[OrderEntity](db).SumGroupedBy("customer", "amount")
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"": 5, "alice": 3}, totals)
+	require.NotNil(t, nulls)
+	require.Equal(t, float64(7), *nulls)
+
+	connector.rows = connector.rows[:1]
+	_, nulls, err = NewEntityRepository[OrderEntity](db).SumGroupedBy("customer", "amount")
+	require.NoError(t, err)
+	require.Nil(t, nulls)
+}
+
+func TestCountGroupedBy_NullGroup(t *testing.T) {
+	connector := &recordingConnector{
+		columns: []string{"group_value", "total"},
+		rows: [][]driver.Value{
+			{[]byte(""), int64(5)},
+			{nil, int64(7)},
+			{[]byte("alice"), int64(3)},
+		},
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	counts, nulls, err := NewEntityRepository[OrderEntity](db).CountGroupedBy("customer")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"": 5, "alice": 3}, counts)
+	require.Equal(t, int64(7), nulls)
+}
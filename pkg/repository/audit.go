@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+)
+
+// WriteAuditor receives every data-modifying statement a repository runs:
+// the exact SQL with its table and column names, the bound arguments as a
+// slice of their own that may be redacted freely, and the error the
+// statement failed with, if any. ctx is the repository's context.
+type WriteAuditor func(ctx context.Context, query string, args []any, err error)
+
+// WithWriteAudit calls auditor after every INSERT, UPDATE and DELETE the
+// repository runs, once per statement and after any retries, including
+// statements that fail and statements run inside transactions that are later
+// rolled back. Reads are never reported. The auditor runs synchronously on the
+// writing goroutine, so it should be fast.
+func WithWriteAudit(auditor WriteAuditor) Option {
+	return func(o *options) {
+		o.writeAuditor = auditor
+	}
+}
+
+// audit reports a write to the configured auditor.
+func (r *entityRepository[E, ID]) audit(query string, args []any, err error) {
+	if r.options.writeAuditor == nil {
+		return
+	}
+	r.options.writeAuditor(r.ctx, query, append([]any(nil), args...), err)
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAudit(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	var queries []string
+	var audited [][]any
+	repo := NewEntityRepository[SampleEntity](db, WithWriteAudit(func(ctx context.Context, query string, args []any, err error) {
+		require.Error(t, err)
+		queries = append(queries, query)
+		audited = append(audited, args)
+	}))
+
+	require.Error(t, repo.SaveAll([]*SampleEntity{{Name: "a"}, {Name: "b"}}))
+	require.Error(t, repo.DeleteByIDs([]int64{1}))
+	_, err = repo.FindAll()
+	require.Error(t, err)
+
+	require.Equal(t, []string{
+		"INSERT INTO sample_entities (name) VALUES (?),(?)",
+		"DELETE FROM sample_entities WHERE id IN (?)",
+	}, queries)
+	require.Equal(t, [][]any{{"a", "b"}, {int64(1)}}, audited)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrAutoIncrementIDs = errors.New("auto-increment ids of a multi-row insert cannot be derived; insert the entities one at a time")
+
+// WithAutoIncrementCheck makes multi-row inserts verify that the ids SaveAll
+// writes back are really the ones MySQL assigned. SaveAll assumes a batch got
+// consecutive ids starting at LastInsertId, which only holds when
+// innodb_autoinc_lock_mode is 0 or 1 and auto_increment_increment is 1, and
+// when every row was inserted.
+//
+// With the check enabled the server settings are read once per repository
+// before the first multi-row insert, and such inserts fail with
+// ErrAutoIncrementIDs when the settings do not give consecutive ids. After
+// every multi-row insert the number of inserted rows is compared with the
+// number of entities, also failing with ErrAutoIncrementIDs on a mismatch;
+// the rows are written by then, so run the insert in a transaction to be able
+// to roll it back.
+func WithAutoIncrementCheck() Option {
+	return func(o *options) {
+		o.autoIncrementCheck = true
+	}
+}
+
+// autoIncrementCheck caches whether the server assigns consecutive ids to
+// multi-row inserts. It is shared by all views of a repository.
+type autoIncrementCheck struct {
+	mu      sync.Mutex
+	checked bool
+	err     error
+}
+
+// checkAutoIncrement returns ErrAutoIncrementIDs when the server settings do
+// not guarantee consecutive ids within a multi-row insert. The lock is not held
+// while the settings are read, so that a slow or blocked query, e.g. on a
+// connection held by a transaction, does not stall the inserts of every other
+// view; concurrent first inserts may then read the settings more than once.
+func (r *entityRepository[E, ID]) checkAutoIncrement() error {
+	r.autoIncrement.mu.Lock()
+	checked, err := r.autoIncrement.checked, r.autoIncrement.err
+	r.autoIncrement.mu.Unlock()
+	if checked {
+		return err
+	}
+
+	var settings struct {
+		LockMode  int `db:"lock_mode"`
+		Increment int `db:"increment"`
+	}
+	query := "SELECT @@innodb_autoinc_lock_mode AS lock_mode, @@auto_increment_increment AS increment"
+	if err := r.getOne(&settings, query); err != nil {
+		return err
+	}
+	if settings.LockMode == 2 || settings.Increment != 1 {
+		err = fmt.Errorf("%w: innodb_autoinc_lock_mode is %d and auto_increment_increment is %d",
+			ErrAutoIncrementIDs, settings.LockMode, settings.Increment)
+	}
+
+	r.autoIncrement.mu.Lock()
+	defer r.autoIncrement.mu.Unlock()
+	r.autoIncrement.checked = true
+	r.autoIncrement.err = err
+	return err
+}
+
+// checkInsertedRows returns ErrAutoIncrementIDs when a multi-row insert
+// inserted a different number of rows than it had entities.
+func checkInsertedRows(affected int64, entities int) error {
+	if affected != int64(entities) {
+		return fmt.Errorf("%w: inserted %d rows for %d entities", ErrAutoIncrementIDs, affected, entities)
+	}
+	return nil
+}
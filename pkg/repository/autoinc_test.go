@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckInsertedRows(t *testing.T) {
+	require.NoError(t, checkInsertedRows(3, 3))
+	require.ErrorIs(t, checkInsertedRows(2, 3), ErrAutoIncrementIDs)
+}
+
+func TestCheckAutoIncrement(t *testing.T) {
+	connector := &recordingConnector{
+		columns: []string{"lock_mode", "increment"},
+		rows:    [][]driver.Value{{int64(2), int64(1)}},
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	repo := NewEntityRepository[SampleEntity](db, WithAutoIncrementCheck()).(*entityRepository[SampleEntity, int64])
+
+	require.ErrorIs(t, repo.checkAutoIncrement(), ErrAutoIncrementIDs)
+	require.ErrorIs(t, repo.checkAutoIncrement(), ErrAutoIncrementIDs)
+	require.Len(t, connector.executed, 1)
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// bloomFilter is a fixed-size Bloom filter over the string form of keys.
+type bloomFilter struct {
+	bits   []uint64
+	m      uint64
+	hashes uint64
+}
+
+func newBloomFilter(expectedItems int, falsePositiveRate float64) *bloomFilter {
+	n := math.Max(float64(expectedItems), 1)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(math.Round(m/n*math.Ln2), 1)
+	return &bloomFilter{
+		bits:   make([]uint64, (uint64(m)+63)/64),
+		m:      uint64(m),
+		hashes: uint64(k),
+	}
+}
+
+// positions derives the filter's bit positions for key by double hashing.
+func (f *bloomFilter) positions(key any) []uint64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, key)
+	sum := h.Sum64()
+	h1, h2 := sum&math.MaxUint32, sum>>32|1
+
+	positions := make([]uint64, f.hashes)
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % f.m
+	}
+	return positions
+}
+
+func (f *bloomFilter) add(key any) {
+	for _, p := range f.positions(key) {
+		f.bits[p/64] |= 1 << (p % 64)
+	}
+}
+
+func (f *bloomFilter) mayContain(key any) bool {
+	for _, p := range f.positions(key) {
+		if f.bits[p/64]&(1<<(p%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// existenceFilter lazily builds a Bloom filter of a table's ids and keeps it
+// up to date with the ids inserted through the repository. It is shared by
+// all views of a repository and safe for concurrent use.
+type existenceFilter struct {
+	mu                sync.RWMutex
+	filter            *bloomFilter
+	expectedItems     int
+	falsePositiveRate float64
+}
+
+// load returns the filter, building it with fill on first use.
+func (e *existenceFilter) load(fill func(f *bloomFilter) error) (*bloomFilter, error) {
+	e.mu.RLock()
+	filter := e.filter
+	e.mu.RUnlock()
+	if filter != nil {
+		return filter, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.filter != nil {
+		return e.filter, nil
+	}
+	filter = newBloomFilter(e.expectedItems, e.falsePositiveRate)
+	if err := fill(filter); err != nil {
+		return nil, err
+	}
+	e.filter = filter
+	return filter, nil
+}
+
+func (e *existenceFilter) mayContain(filter *bloomFilter, key any) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return filter.mayContain(key)
+}
+
+func (e *existenceFilter) add(keys ...any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.filter == nil {
+		return
+	}
+	for _, key := range keys {
+		e.filter.add(key)
+	}
+}
+
+func (e *existenceFilter) invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.filter = nil
+}
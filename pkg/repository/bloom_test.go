@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBloomFilter(t *testing.T) {
+	filter := newBloomFilter(1000, 0.01)
+	for i := int64(0); i < 1000; i++ {
+		filter.add(i)
+	}
+	for i := int64(0); i < 1000; i++ {
+		require.True(t, filter.mayContain(i))
+	}
+
+	falsePositives := 0
+	for i := int64(1000); i < 11000; i++ {
+		if filter.mayContain(i) {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 500)
+}
+
+func TestExistenceFilter(t *testing.T) {
+	e := &existenceFilter{expectedItems: 10, falsePositiveRate: 0.01}
+	e.add(int64(1))
+
+	loads := 0
+	fill := func(f *bloomFilter) error {
+		loads++
+		f.add(int64(2))
+		return nil
+	}
+	filter, err := e.load(fill)
+	require.NoError(t, err)
+	require.True(t, e.mayContain(filter, int64(2)))
+
+	e.add(int64(3))
+	filter, err = e.load(fill)
+	require.NoError(t, err)
+	require.True(t, e.mayContain(filter, int64(3)))
+	require.Equal(t, 1, loads)
+
+	e.invalidate()
+	_, err = e.load(fill)
+	require.NoError(t, err)
+	require.Equal(t, 2, loads)
+}
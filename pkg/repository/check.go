@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlErrCheckViolated is reported when a row violates a CHECK constraint.
+const mysqlErrCheckViolated = 3819
+
+var ErrCheckViolation = errors.New("check constraint violated")
+
+// Check is a predicate an entity must satisfy to be written, named after the
+// database CHECK constraint it mirrors.
+type Check struct {
+	Name string
+	OK   bool
+}
+
+// CheckedEntity is implemented by entities that mirror the CHECK constraints
+// of their table in Go, e.g.
+//
+//	func (u User) Checks() []Check {
+//		return []Check{{Name: "users_age_chk", OK: u.Age >= 0}}
+//	}
+//
+// The checks are evaluated before every insert, upsert and Update of the
+// entity, so a violation is reported without a round trip to the database.
+type CheckedEntity interface {
+	Checks() []Check
+}
+
+// CheckViolationError reports the check a write violated. Violations found by
+// CheckedEntity and CHECK constraints rejected by MySQL are both reported
+// with it, so callers handle them alike; FromDatabase tells them apart. It
+// matches ErrCheckViolation with errors.Is.
+type CheckViolationError struct {
+	Check        string
+	FromDatabase bool
+	Err          error
+}
+
+func (e *CheckViolationError) Error() string {
+	return fmt.Sprintf("check %s violated", e.Check)
+}
+
+func (e *CheckViolationError) Is(target error) bool {
+	return target == ErrCheckViolation
+}
+
+func (e *CheckViolationError) Unwrap() error {
+	return e.Err
+}
+
+// verifyChecks returns a *CheckViolationError for the first failing check of
+// entity.
+func verifyChecks[E any](entity *E) error {
+	checked, ok := any(entity).(CheckedEntity)
+	if !ok {
+		return nil
+	}
+	for _, check := range checked.Checks() {
+		if !check.OK {
+			return &CheckViolationError{Check: check.Name}
+		}
+	}
+	return nil
+}
+
+var checkViolatedPattern = regexp.MustCompile("^Check constraint '([^']+)' is violated")
+
+// checkError translates MySQL CHECK constraint violations into a
+// *CheckViolationError.
+func checkError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != mysqlErrCheckViolated {
+		return err
+	}
+	name := ""
+	if match := checkViolatedPattern.FindStringSubmatch(mysqlErr.Message); match != nil {
+		name = match[1]
+	}
+	return &CheckViolationError{Check: name, FromDatabase: true, Err: err}
+}
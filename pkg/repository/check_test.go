@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChecks(t *testing.T) {
+	require.NoError(t, verifyChecks(&InventoryEntity{Quantity: 1}))
+	require.NoError(t, verifyChecks(&SampleEntity{}))
+
+	err := verifyChecks(&InventoryEntity{Quantity: -1})
+	require.ErrorIs(t, err, ErrCheckViolation)
+	var violation *CheckViolationError
+	require.True(t, errors.As(err, &violation))
+	require.Equal(t, "inventory_quantity_chk", violation.Check)
+	require.False(t, violation.FromDatabase)
+}
+
+func TestCheckError(t *testing.T) {
+	mysqlErr := &mysql.MySQLError{Number: 3819, Message: "Check constraint 'inventory_quantity_chk' is violated."}
+	err := checkError(mysqlErr)
+	var violation *CheckViolationError
+	require.True(t, errors.As(err, &violation))
+	require.Equal(t, "inventory_quantity_chk", violation.Check)
+	require.True(t, violation.FromDatabase)
+	require.ErrorIs(t, err, mysqlErr)
+
+	other := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	require.Equal(t, other, checkError(other))
+	require.NoError(t, checkError(nil))
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// column describes a struct field mapped to a table column. Index is the
+// index sequence of the field for reflect.Value.FieldByIndex; it is nil for
+// the document column, which no field backs.
+type column struct {
+	Name    string
+	Index   []int
+	Options []string
+}
+
+func (c column) has(option string) bool {
+	return slices.Contains(c.Options, option)
+}
+
+// entityColumns returns the mapped fields of t in declaration order. A field
+// is named by its db tag or, when the tag leaves the name empty, by applying
+// mapper to the field name. The fields of an embedded struct without a db tag
+// name are mapped in its place, as sqlx maps them when scanning; embedded
+// pointers are not. Fields tagged db:"-" and unexported fields are skipped.
+func entityColumns(t reflect.Type, mapper func(string) string) []column {
+	return appendColumns(nil, t, nil, mapper)
+}
+
+// appendColumns appends the mapped fields of t to columns, prefixing their
+// indexes with index, the index sequence of t in the entity.
+func appendColumns(columns []column, t reflect.Type, index []int, mapper func(string) string) []column {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tagParts := strings.Split(field.Tag.Get("db"), ",")
+		for j, tagPart := range tagParts {
+			tagParts[j] = strings.TrimSpace(tagPart)
+		}
+		name := tagParts[0]
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(slices.Clip(index), i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && name == "" {
+			columns = appendColumns(columns, field.Type, fieldIndex, mapper)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = mapper(field.Name)
+		}
+		columns = append(columns, column{
+			Name:    name,
+			Index:   fieldIndex,
+			Options: tagParts[1:],
+		})
+	}
+	return columns
+}
+
+// columns returns the mapped fields of E, followed by the document column if E
+// is a DocumentEntity.
+func (r *entityRepository[E, ID]) columns() []column {
+	var emptyEntity E
+	columns := entityColumns(reflect.TypeOf(emptyEntity), r.options.nameMapper)
+	if document, ok := r.documentColumn(); ok {
+		columns = append(columns, document)
+	}
+	return columns
+}
+
+// Field is a struct field of an entity stored in a table column.
+type Field struct {
+	Column string
+	// Index is the index sequence of the field in the entity struct, for
+	// reflect.Value.FieldByIndex.
+	Index []int
+}
+
+// StoredFields returns the fields of the entity of repo stored in table
+// columns, in declaration order: every mapped field except readonly ones and
+// the document column. It lets packages building on repositories, such as
+// export, map entities to columns the way the repository does.
+func StoredFields[E Entity[ID], ID comparable](repo Repository[E, ID]) ([]Field, error) {
+	r, ok := repo.(*entityRepository[E, ID])
+	if !ok {
+		return nil, fmt.Errorf("unsupported repository implementation %T", repo)
+	}
+	var fields []Field
+	for _, c := range r.columns() {
+		if c.has("readonly") || c.has("document") {
+			continue
+		}
+		fields = append(fields, Field{Column: c.Name, Index: c.Index})
+	}
+	return fields, nil
+}
+
+// SnakeCase maps a Go field name to a snake_case column name, keeping
+// acronyms together: UserID becomes user_id and HTTPStatus http_status. It is
+// the default name mapper for fields without a db tag.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && runes[i-1] != '_' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
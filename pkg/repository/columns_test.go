@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"Id":         "id",
+		"Name":       "name",
+		"CreatedAt":  "created_at",
+		"UserID":     "user_id",
+		"HTTPStatus": "http_status",
+		"Address2":   "address2",
+	}
+	for name, expected := range cases {
+		require.Equal(t, expected, SnakeCase(name), name)
+	}
+}
+
+func TestEntityColumns_NameMapper(t *testing.T) {
+	type entity struct {
+		Id        int64 `db:"id,autoincrement"`
+		FirstName string
+		LastName  string `db:"surname"`
+		Ignored   string `db:"-"`
+		internal  string
+	}
+
+	columns := entityColumns(reflect.TypeOf(entity{}), SnakeCase)
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	require.Equal(t, []string{"id", "first_name", "surname"}, names)
+	require.True(t, columns[0].has("autoincrement"))
+
+	columns = entityColumns(reflect.TypeOf(entity{}), strings.ToUpper)
+	require.Equal(t, "FIRSTNAME", columns[1].Name)
+
+	repo := NewEntityRepository[SampleEntity](nil, WithNameMapper(nil)).(*entityRepository[SampleEntity, int64])
+	require.Equal(t, "created_at", repo.options.nameMapper("CreatedAt"))
+}
+
+func TestEntityColumns_Embedded(t *testing.T) {
+	type Timestamps struct {
+		CreatedAt string
+		UpdatedAt string `db:"modified_at"`
+	}
+	type audit struct {
+		Author string
+	}
+	type entity struct {
+		Id int64 `db:"id"`
+		Timestamps
+		audit
+		Named Timestamps `db:"named"`
+	}
+
+	columns := entityColumns(reflect.TypeOf(entity{}), SnakeCase)
+	require.Equal(t, []column{
+		{Name: "id", Index: []int{0}, Options: []string{}},
+		{Name: "created_at", Index: []int{1, 0}, Options: []string{}},
+		{Name: "modified_at", Index: []int{1, 1}, Options: []string{}},
+		{Name: "author", Index: []int{2, 0}, Options: []string{}},
+		{Name: "named", Index: []int{3}, Options: []string{}},
+	}, columns)
+
+	e := entity{Timestamps: Timestamps{UpdatedAt: "now"}}
+	require.Equal(t, "now", reflect.ValueOf(e).FieldByIndex(columns[2].Index).Interface())
+}
+
+func TestStoredFields(t *testing.T) {
+	fields, err := StoredFields(NewEntityRepository[PlayerEntity](nil))
+	require.NoError(t, err)
+	require.Equal(t, []Field{{"id", []int{0}}, {"name", []int{1}}, {"score", []int{2}}}, fields)
+}
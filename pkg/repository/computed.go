@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"fmt"
+)
+
+// SelectExpr is a SQL expression selected under an alias alongside an
+// entity's columns.
+type SelectExpr struct {
+	Expr string
+	As   string
+}
+
+// FindAllComputed returns the entities of repo matching conditions together
+// with the values of exprs, scanned into R, a struct that embeds E and has a
+// field mapped to every alias. For example, with
+//
+//	type OrderTotal struct {
+//		Order
+//		Total float64 `db:"total"`
+//	}
+//
+// FindAllComputed[OrderTotal](repo, []SelectExpr{{Expr: "price * quantity", As: "total"}}, nil)
+// returns every order with its total.
+//
+// Condition columns and aliases are validated, but expressions are spliced
+// into the query verbatim and must never contain user input.
+func FindAllComputed[R any, E Entity[ID], ID comparable](repo Repository[E, ID], exprs []SelectExpr, conditions map[string]any) ([]*R, error) {
+	r, ok := repo.(*entityRepository[E, ID])
+	if !ok {
+		return nil, fmt.Errorf("unsupported repository implementation %T", repo)
+	}
+
+	validColumns := r.validColumns()
+	selectList := "*"
+	for _, expr := range exprs {
+		if !identifierPattern.MatchString(expr.As) {
+			return nil, fmt.Errorf("invalid alias %q", expr.As)
+		}
+		if validColumns[expr.As] {
+			return nil, fmt.Errorf("alias %q shadows a column", expr.As)
+		}
+		selectList += fmt.Sprintf(", (%s) AS %s", expr.Expr, expr.As)
+	}
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+
+	var results []*R
+	query := fmt.Sprintf("SELECT %s FROM %s%s", selectList, r.table(), where)
+	if err := r.selectAll(&results, query, where.args...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+)
+
+// WithContext returns a view of the repository whose queries run under ctx,
+// so they are cancelled when ctx is and give up waiting between retries once
+// ctx's deadline passes. Transactions started from the view are bound to ctx
+// as well.
+func (r *entityRepository[E, ID]) WithContext(ctx context.Context) Repository[E, ID] {
+	view := *r
+	view.ctx = ctx
+	return &view
+}
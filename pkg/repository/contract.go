@@ -1,5 +1,12 @@
 package repository
 
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
 type Entity[ID comparable] interface {
 	GetID() ID
 	GetTableName() string
@@ -8,17 +15,93 @@ type Entity[ID comparable] interface {
 
 type Repository[E Entity[ID], ID comparable] interface {
 	FindAll() ([]*E, error)
+	Stream(fn func(*E) error) error
+	QueryRows(conditions map[string]any) (*sqlx.Rows, error)
 	FindAllByID(ids []ID) ([]*E, error)
+	FindAllByIDStrict(ids []ID) ([]*E, error)
+	FindMapByIDs(ids []ID) (map[ID]*E, error)
+	FindAllByIDAligned(ids []ID) ([]*E, error)
+	FindAllByIDWhere(ids []ID, conditions map[string]any) ([]*E, error)
 	FindByID(id ID) (*E, error)
 	Save(*E) error
 	SaveAll(entities []*E) error
+	SaveAllSummary(entities []*E) (*BulkResult[ID], error)
+	SaveAllWithDeadLetter(entities []*E, maxRetries int, deadLetter func(entity *E, err error)) error
+	IngestStream(ctx context.Context, src <-chan *E, batchSize int) error
+	Update(entity *E) error
+	Edit(id ID, mutate func(*E) error) error
+	Touch(id ID) (int64, error)
+	DiffUpdate(entity *E) (map[string]ColumnChange, error)
+	Untrack(id ID)
+	UpsertAll(entities []*E) error
+	Upsert(entities []*E, strategy MergeStrategy) error
+	UpsertAllSummary(entities []*E) (*BulkResult[ID], error)
+	UpsertWithCounts(entities []*E) (inserted, updated int64, err error)
 	DeleteByID(ID) error
 	DeleteByIDs([]ID) error
-	DeleteAll() error
+	DeleteAll(opts ...DeleteOption) error
+	DeleteAllUnguarded() error
+	DeleteReturning(conditions map[string]any) ([]*E, error)
+	DeleteByIDsReturning(ids []ID) ([]*E, error)
+	DeleteBy(conditions map[string]any, limit int) (int64, error)
+	UpdateWhere(conditions, values map[string]any, limit int) (int64, error)
+	UpdateWhereReturning(conditions, values map[string]any) ([]ID, error)
+	Duplicate(id ID, overrides map[string]any) (*E, error)
 	DeleteEntities(entities []*E) error
 	DeleteEntity(entity *E) error
+	DeleteEntitiesStrict(entities []*E) error
 	ExistsByID(id ID) error
+	ExistsByIDs(ids []ID) (map[ID]bool, error)
+	ExistsByColumnValues(column string, values []any) (map[any]bool, error)
+	RefreshExistenceFilter() error
+	InvalidateExistenceFilter()
 	FindAllPaginated(pagination Pagination) (*PaginatedResult[E], error)
+	FindAllPaginatedColumns(pagination Pagination, columns []string) (*PaginatedResult[E], error)
+	FindRanked(rankColumn string, order OrderClause, conditions map[string]any) ([]*E, error)
+	FindWithWindows(windows []WindowExpr, conditions map[string]any, order ...OrderClause) ([]*E, error)
+	FindBySpec(spec *QuerySpec) ([]*E, error)
+	Explain(spec *QuerySpec) (string, error)
+	Find(opts FindOptions) (*PaginatedResult[E], error)
+	FindByExample(example *E, includeZero ...string) ([]*E, error)
+	FindAllGroupedBy(column string, conditions map[string]any, order ...OrderClause) (groups map[string][]*E, nulls []*E, err error)
+	FindByColumnNotIn(column string, values []any) ([]*E, error)
+	FindByTuples(columns []string, tuples [][]any) ([]*E, error)
+	Search(query string, columns []string) ([]*E, error)
+	QueryWithCTE(cte string, cteArgs []any, where string, whereArgs []any) ([]*E, error)
+	FindAllWithList(list ListJoin, conditions map[string]any) ([]*E, error)
+	SampleRandom(n int) ([]*E, error)
+	SampleRandomApprox(n int) ([]*E, error)
+	SumGroupedBy(groupColumn, sumColumn string, having ...Condition) (totals map[string]float64, nulls *float64, err error)
+	CountGroupedBy(column string) (counts map[string]int64, nulls int64, err error)
+	ListGroupedBy(groupColumn, valueColumn string) (lists map[string][]string, nulls []string, err error)
+	CountByDateBucket(column string, bucket Bucket) (map[time.Time]int64, error)
+	MaxID() (ID, error)
+	ReserveIDs(n int) ([]ID, error)
+	EstimatedCount() (int64, error)
+	FindPage(token string, limit int, desc bool) (*PaginatedResult[E], string, error)
+	FindPageBy(order KeysetOrder, token string, limit int) (*PaginatedResult[E], string, error)
+	FindCursor(cursor string, limit int) (*CursorResult[E], error)
+	FindCursorBy(opts FindOptions) (*CursorResult[E], error)
+	RunInTx(fn func(tx TxRepository[E, ID]) error) error
+	WithDeferredConstraints(fn func(tx TxRepository[E, ID]) error) error
+	ReadSnapshot(fn func(snapshot Repository[E, ID]) error) error
+	VerifySchema() error
+	LastQuery() (string, []any)
+	InPartitions(partitions ...string) (Repository[E, ID], error)
+	WithContext(ctx context.Context) Repository[E, ID]
+	OnlyTrashed() Repository[E, ID]
+	WithoutGlobalScopes() Repository[E, ID]
+	WithTrashed() Repository[E, ID]
+	WithoutTrashed() Repository[E, ID]
+	Restore(id ID) error
+	RestoreBy(conditions map[string]any, opts ...RestoreOption) (int64, error)
+}
+
+type TxRepository[E Entity[ID], ID comparable] interface {
+	Repository[E, ID]
+	FindByIDForUpdate(id ID, opts ...LockOption) (*E, error)
+	FindByIDForUpdateNoWait(id ID) (*E, error)
+	FindOldestForUpdate(n int, orderColumn string) ([]*E, error)
 }
 
 type Pagination struct {
@@ -26,6 +109,13 @@ type Pagination struct {
 	Offset int `json:"offset"`
 }
 
+// CursorPagination requests the page of at most Limit rows a cursor points
+// to; an empty Cursor requests the first page.
+type CursorPagination struct {
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+}
+
 type PaginatedResult[E any] struct {
 	Pagination Pagination `json:"pagination"`
 	TotalCount int        `json:"total_count"`
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryWithCTE returns the entities matching where, a condition that may refer
+// to the common table expressions of cte, e.g.
+//
+//	repo.QueryWithCTE(
+//		"big_spenders AS (SELECT customer FROM orders GROUP BY customer HAVING SUM(amount) > ?)",
+//		[]any{1000},
+//		"customer IN (SELECT customer FROM big_spenders)", nil)
+//
+// cte is everything following WITH, so it can start with RECURSIVE and define
+// several expressions. The query is SELECT * FROM the entity's table, within
+// the repository's scopes, and its ? placeholders are bound to cteArgs and
+// then whereArgs, which must be empty when where is. Both cte and where are
+// spliced into the query verbatim and must never contain user input.
+func (r *entityRepository[E, ID]) QueryWithCTE(cte string, cteArgs []any, where string, whereArgs []any) ([]*E, error) {
+	if strings.TrimSpace(cte) == "" {
+		return nil, fmt.Errorf("empty common table expression")
+	}
+
+	w := r.where()
+	if strings.TrimSpace(where) != "" {
+		w.add("("+where+")", whereArgs...)
+	} else if len(whereArgs) > 0 {
+		return nil, fmt.Errorf("%d where arguments without a where condition", len(whereArgs))
+	}
+	query := fmt.Sprintf("WITH %s SELECT * FROM %s%s", cte, r.table(), w)
+
+	var entities []*E
+	if err := r.selectAll(&entities, query, append(append([]any{}, cteArgs...), w.args...)...); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryWithCTE(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[SoftDeleteEntity](db, WithQueryCapture())
+	_, err = repo.QueryWithCTE("picked AS (SELECT ? AS name)", []any{"a"}, "name IN (SELECT name FROM picked) OR id = ?", []any{3})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "WITH picked AS (SELECT ? AS name) SELECT * FROM soft_delete_entities WHERE deleted_at IS NULL AND (name IN (SELECT name FROM picked) OR id = ?)", query)
+	require.Equal(t, []any{"a", 3}, args)
+
+	_, err = repo.QueryWithCTE(" ", nil, "", nil)
+	require.ErrorContains(t, err, "empty common table expression")
+	_, err = repo.QueryWithCTE("picked AS (SELECT 1)", nil, " ", []any{3})
+	require.EqualError(t, err, "1 where arguments without a where condition")
+}
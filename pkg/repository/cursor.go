@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CursorResult is a page of a keyset pagination together with the opaque
+// cursors leading to the pages after and before it. A cursor is empty when
+// there is no such page.
+type CursorResult[E any] struct {
+	Results    []*E   `json:"results"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// FindCursor returns the page of at most limit entities, ordered by id, that
+// cursor points to; an empty cursor requests the first page. Cursors are signed
+// with the secret set by WithPageTokenSecret, which is required.
+func (r *entityRepository[E, ID]) FindCursor(cursor string, limit int) (*CursorResult[E], error) {
+	entities, direction, hasMore, err := r.findByIDKey("*", r.where(), cursor, cursorTokenKind, limit, false)
+	if err != nil {
+		return nil, err
+	}
+	if direction == sortDescending {
+		slices.Reverse(entities)
+	}
+	result := &CursorResult[E]{Results: entities}
+	result.NextCursor, result.PrevCursor, err = r.cursors(cursorTokenKind, cursor, entities, direction, hasMore)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindCursorBy returns the page of the entities matching opts that
+// opts.Cursor points to, ordered by id, as FindCursor does. opts.Cursor is
+// required, OrderBy, Pagination and Count cannot be set, and Columns must
+// include id. Its cursors are not interchangeable with those of FindCursor.
+func (r *entityRepository[E, ID]) FindCursorBy(opts FindOptions) (*CursorResult[E], error) {
+	if opts.Cursor == nil {
+		return nil, fmt.Errorf("cursor pagination needs a cursor")
+	}
+	if len(opts.OrderBy) > 0 || opts.Pagination != nil || opts.Count {
+		return nil, fmt.Errorf("cursor pagination cannot be combined with OrderBy, Pagination or Count")
+	}
+	if len(opts.Columns) > 0 && !slices.Contains(opts.Columns, "id") {
+		return nil, fmt.Errorf("cursor pagination needs the id column")
+	}
+	validColumns := r.validColumns()
+	selectList, err := selectColumns(validColumns, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+	where, err := r.findWhere(validColumns, opts.Where)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := opts.Cursor.Cursor
+	entities, direction, hasMore, err := r.findByIDKey(selectList, where, cursor, findTokenKind, opts.Cursor.Limit, false)
+	if err != nil {
+		return nil, err
+	}
+	if direction == sortDescending {
+		slices.Reverse(entities)
+	}
+	result := &CursorResult[E]{Results: entities}
+	result.NextCursor, result.PrevCursor, err = r.cursors(findTokenKind, cursor, entities, direction, hasMore)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findByIDKey reads selectList of the page of at most limit rows matching
+// where, ordered by id, that token of the given kind points to. A token
+// paging in descending order reads the rows before its id, in descending
+// order. An empty token requests the first page, in descending order when
+// desc is set. hasMore reports whether rows follow the page in the direction
+// it was read. where is left unchanged.
+func (r *entityRepository[E, ID]) findByIDKey(selectList string, where *whereBuilder, token, kind string, limit int, desc bool) (entities []*E, direction string, hasMore bool, err error) {
+	if len(r.options.pageTokenSecret) == 0 {
+		return nil, "", false, fmt.Errorf("page token secret is not configured")
+	}
+	if limit <= 0 {
+		return nil, "", false, fmt.Errorf("limit must be positive")
+	}
+
+	direction = sortAscending
+	if desc {
+		direction = sortDescending
+	}
+	keyed := *where
+	keyed.conditions = slices.Clip(where.conditions)
+	keyed.args = slices.Clip(where.args)
+	if token != "" {
+		pt, err := decodePageToken(r.options.pageTokenSecret, token, kind)
+		if err != nil {
+			return nil, "", false, err
+		}
+		var key ID
+		if err := json.Unmarshal(pt.Key, &key); err != nil {
+			return nil, "", false, ErrInvalidPageToken
+		}
+		direction = pt.Direction
+		if direction == sortDescending {
+			keyed.add("id < ?", key)
+		} else {
+			keyed.add("id > ?", key)
+		}
+	}
+
+	query, args := r.limit(fmt.Sprintf("SELECT %s FROM %s%s ORDER BY id %s", selectList, r.table(), &keyed, strings.ToUpper(direction)), keyed.args, limit+1, 0)
+	if err := r.selectAll(&entities, query, args...); err != nil {
+		return nil, "", false, err
+	}
+	hasMore = len(entities) > limit
+	if hasMore {
+		entities = entities[:limit]
+	}
+	return entities, direction, hasMore, nil
+}
+
+// cursors returns the tokens of the given kind leading to the pages after and
+// before entities, the page that token, read in direction, points to, in
+// ascending order.
+func (r *entityRepository[E, ID]) cursors(kind, token string, entities []*E, direction string, hasMore bool) (next, prev string, err error) {
+	if len(entities) == 0 {
+		return "", "", nil
+	}
+	// Paging backward from a token means rows follow this page, and paging
+	// forward from one means rows precede it.
+	hasNext := hasMore || (token != "" && direction == sortDescending)
+	hasPrev := (hasMore && direction == sortDescending) || (token != "" && direction == sortAscending)
+
+	if hasNext {
+		next, err = r.idToken(kind, entities[len(entities)-1], sortAscending)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if hasPrev {
+		prev, err = r.idToken(kind, entities[0], sortDescending)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return next, prev, nil
+}
+
+// idToken returns the token of the given kind for the page after entity or,
+// when direction is descending, before it.
+func (r *entityRepository[E, ID]) idToken(kind string, entity *E, direction string) (string, error) {
+	key, err := json.Marshal((*entity).GetID())
+	if err != nil {
+		return "", err
+	}
+	return encodePageToken(r.options.pageTokenSecret, pageToken{Kind: kind, Key: key, Direction: direction})
+}
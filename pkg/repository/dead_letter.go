@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"errors"
+)
+
+// SaveAllWithDeadLetter saves entities like SaveAll, but a bad entity does
+// not fail the others: when the batch insert fails, every entity is saved on
+// its own, retried up to maxRetries times, and the ones still failing are
+// passed to deadLetter with their last error instead of being saved. It is
+// meant for ingesting streams that may carry poison messages. A negative
+// maxRetries is rejected before anything is saved.
+//
+// Errors that are not the entity's fault are returned instead of being dead
+// lettered: the repository's context being done, or the connection to the
+// database failing. The entities saved before are kept, so the caller can
+// retry the batch once the database is back, provided the entities have
+// auto-incremented ids or their inserts are otherwise idempotent.
+func (r *entityRepository[E, ID]) SaveAllWithDeadLetter(entities []*E, maxRetries int, deadLetter func(entity *E, err error)) error {
+	if deadLetter == nil {
+		return errors.New("dead letter callback is required")
+	}
+	if maxRetries < 0 {
+		return errors.New("max retries must not be negative")
+	}
+	if err := r.SaveAll(entities); err == nil || !r.isEntityError(err) {
+		return err
+	}
+
+	for _, entity := range entities {
+		var err error
+		for attempt := 0; attempt <= maxRetries; attempt++ {
+			if err = r.Save(entity); err == nil || !r.isEntityError(err) {
+				break
+			}
+		}
+		if err == nil {
+			continue
+		}
+		if !r.isEntityError(err) {
+			return err
+		}
+		deadLetter(entity, err)
+	}
+	return nil
+}
+
+// isEntityError reports whether err may be caused by the data of the saved
+// entities rather than by the context or the connection.
+func (r *entityRepository[E, ID]) isEntityError(err error) bool {
+	return r.ctx.Err() == nil && !isConnectError(err) && !isTransientConnError(err)
+}
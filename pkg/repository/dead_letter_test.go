@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// poisonConnector opens connections whose statements fail when one of their
+// arguments is "poison".
+type poisonConnector struct {
+	execs int
+}
+
+func (c *poisonConnector) Connect(context.Context) (driver.Conn, error) {
+	return poisonConn{c}, nil
+}
+
+func (c *poisonConnector) Driver() driver.Driver { return rowsDriver{} }
+
+type poisonConn struct{ c *poisonConnector }
+
+func (conn poisonConn) Prepare(string) (driver.Stmt, error) { return poisonStmt(conn), nil }
+func (poisonConn) Close() error                             { return nil }
+func (poisonConn) Begin() (driver.Tx, error)                { return nil, driver.ErrSkip }
+
+type poisonStmt struct{ c *poisonConnector }
+
+func (poisonStmt) Close() error  { return nil }
+func (poisonStmt) NumInput() int { return -1 }
+func (s poisonStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.execs++
+	for _, arg := range args {
+		if arg == "poison" {
+			return nil, errors.New("Data too long for column 'name'")
+		}
+	}
+	return insertResult(len(args)), nil
+}
+func (poisonStmt) Query([]driver.Value) (driver.Rows, error) { return &sampleRows{}, nil }
+
+func TestSaveAllWithDeadLetter(t *testing.T) {
+	connector := &poisonConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	repo := NewEntityRepository[SampleEntity](db)
+
+	entities := []*SampleEntity{{Name: "a"}, {Name: "poison"}, {Name: "b"}}
+	var dead []string
+	err := repo.SaveAllWithDeadLetter(entities, 2, func(entity *SampleEntity, err error) {
+		require.Error(t, err)
+		dead = append(dead, entity.Name)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"poison"}, dead)
+	// The batch, then a, poison three times, and b.
+	require.Equal(t, 6, connector.execs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = repo.WithContext(ctx).SaveAllWithDeadLetter(entities, 2, func(*SampleEntity, error) {
+		t.Fatal("entity dead lettered after the context was done")
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	connector.execs = 0
+	err = repo.SaveAllWithDeadLetter(entities, -1, func(*SampleEntity, error) {})
+	require.EqualError(t, err, "max retries must not be negative")
+	require.Zero(t, connector.execs)
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"sync"
+)
+
+// WithQueryCapture makes the repository remember the last query it sent and
+// its arguments, exposed by LastQuery, so tests can assert on the generated
+// SQL. Queries are captured before they run, so this works even when they
+// fail. Without this option nothing is retained.
+func WithQueryCapture() Option {
+	return func(o *options) {
+		o.captureQueries = true
+	}
+}
+
+// queryRecorder holds the last query sent by a repository and all its views.
+type queryRecorder struct {
+	mu    sync.Mutex
+	query string
+	args  []any
+}
+
+func (r *entityRepository[E, ID]) record(query string, args []any) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	r.recorder.query = query
+	r.recorder.args = append([]any(nil), args...)
+}
+
+// LastQuery returns the last query the repository sent and its arguments. It
+// returns an empty query unless the repository was created with
+// WithQueryCapture.
+func (r *entityRepository[E, ID]) LastQuery() (string, []any) {
+	if r.recorder == nil {
+		return "", nil
+	}
+	r.recorder.mu.Lock()
+	defer r.recorder.mu.Unlock()
+	return r.recorder.query, r.recorder.args
+}
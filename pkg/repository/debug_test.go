@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastQuery(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	_, err = repo.FindAllByIDWhere([]int64{1, 2}, map[string]any{"name": "test"})
+	require.Error(t, err)
+
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE id IN (?,?) AND name = ?", query)
+	require.Equal(t, []any{int64(1), int64(2), "test"}, args)
+
+	query, args = NewEntityRepository[SampleEntity](db).LastQuery()
+	require.Empty(t, query)
+	require.Nil(t, args)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultsApplier is implemented by entities that fill in default values
+// before they are inserted.
+type DefaultsApplier interface {
+	ApplyDefaults()
+}
+
+// applyDefaults sets zero-valued fields with a default tag option, e.g.
+// db:"status,default=active", and then calls ApplyDefaults if the entity
+// implements DefaultsApplier. Default literals cannot contain commas.
+func (r *entityRepository[E, ID]) applyDefaults(entity *E) error {
+	entityValue := reflect.ValueOf(entity).Elem()
+	for _, c := range r.columns() {
+		literal, ok := c.option("default")
+		if !ok {
+			continue
+		}
+		field := entityValue.FieldByIndex(c.Index)
+		if !field.IsZero() {
+			continue
+		}
+		if err := setFromLiteral(field, literal); err != nil {
+			return fmt.Errorf("invalid default for column %s: %w", c.Name, err)
+		}
+	}
+
+	if applier, ok := any(entity).(DefaultsApplier); ok {
+		applier.ApplyDefaults()
+	}
+	return nil
+}
+
+// setFromLiteral parses literal into the type of field and stores it.
+func setFromLiteral(field reflect.Value, literal string) error {
+	if field.Kind() == reflect.Pointer {
+		value := reflect.New(field.Type().Elem())
+		if err := setFromLiteral(value.Elem(), literal); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(literal)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(literal, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(literal, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(literal, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// option returns the value of a key=value tag option.
+func (c column) option(key string) (string, bool) {
+	for _, option := range c.Options {
+		if value, ok := strings.CutPrefix(option, key+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFromLiteral(t *testing.T) {
+	var s string
+	require.NoError(t, setFromLiteral(reflect.ValueOf(&s).Elem(), "active"))
+	require.Equal(t, "active", s)
+
+	var i int32
+	require.NoError(t, setFromLiteral(reflect.ValueOf(&i).Elem(), "42"))
+	require.Equal(t, int32(42), i)
+
+	var b *bool
+	require.NoError(t, setFromLiteral(reflect.ValueOf(&b).Elem(), "true"))
+	require.True(t, *b)
+
+	var f float64
+	require.NoError(t, setFromLiteral(reflect.ValueOf(&f).Elem(), "1.5"))
+	require.Equal(t, 1.5, f)
+
+	var u uint8
+	require.Error(t, setFromLiteral(reflect.ValueOf(&u).Elem(), "300"))
+}
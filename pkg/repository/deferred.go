@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"errors"
+)
+
+var ErrDeferredConstraintsUnsupported = errors.New("dialect does not support deferred constraints")
+
+// WithDeferredConstraints runs fn in a transaction whose deferrable
+// constraints, such as foreign keys declared DEFERRABLE, are only checked when
+// it commits, so related rows can be inserted in any order. Called on a
+// transaction-bound repository it joins that transaction and switches the
+// constraints back to immediate checking once fn returns, which checks
+// everything fn wrote. They are switched back even when fn fails, so that the
+// rest of the joined transaction does not run with deferred constraints.
+//
+// MySQL cannot defer constraints, so with MySQLDialect, the default, it
+// returns ErrDeferredConstraintsUnsupported without running fn.
+func (r *entityRepository[E, ID]) WithDeferredConstraints(fn func(tx TxRepository[E, ID]) error) error {
+	deferrer, ok := r.options.dialect.(ConstraintDeferrer)
+	if !ok {
+		return ErrDeferredConstraintsUnsupported
+	}
+
+	joined := r.tx != nil
+	return r.inTx(func(txRepo *entityRepository[E, ID]) (err error) {
+		if _, err := txRepo.exec(deferrer.ConstraintMode(true)); err != nil {
+			return err
+		}
+		if joined {
+			defer func() {
+				if _, restoreErr := txRepo.exec(deferrer.ConstraintMode(false)); err == nil {
+					err = restoreErr
+				}
+			}()
+		}
+		return fn(txRepo)
+	})
+}
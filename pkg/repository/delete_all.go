@@ -0,0 +1,19 @@
+package repository
+
+import "errors"
+
+var ErrDeleteAllUnconfirmed = errors.New("DeleteAll requires ConfirmFullDelete")
+
+type DeleteOption func(*deleteOptions)
+
+type deleteOptions struct {
+	confirmed bool
+}
+
+// ConfirmFullDelete confirms that a DeleteAll call is meant to delete every
+// row.
+func ConfirmFullDelete() DeleteOption {
+	return func(o *deleteOptions) {
+		o.confirmed = true
+	}
+}
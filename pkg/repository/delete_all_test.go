@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAll_Confirmation(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	require.ErrorIs(t, repo.DeleteAll(), ErrDeleteAllUnconfirmed)
+	query, _ := repo.LastQuery()
+	require.Empty(t, query)
+
+	require.Error(t, repo.DeleteAll(ConfirmFullDelete()))
+	query, _ = repo.LastQuery()
+	require.Equal(t, "DELETE FROM sample_entities", query)
+}
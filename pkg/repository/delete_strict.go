@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrEntitiesMissing = errors.New("entities no longer exist")
+
+// DeleteEntitiesStrict deletes entities like DeleteEntities but only if all of
+// them still exist. The matching rows are locked and counted in the same
+// transaction as the delete; if any entity was already deleted, nothing is
+// deleted and an error wrapping ErrEntitiesMissing is returned.
+func (r *entityRepository[E, ID]) DeleteEntitiesStrict(entities []*E) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	ids := entityIDs[E, ID](entities)
+	distinct := make(map[ID]bool, len(ids))
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		if !distinct[id] {
+			distinct[id] = true
+			args = append(args, id)
+		}
+	}
+
+	tableName := r.table()
+	return r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		where := txRepo.where()
+		where.add(fmt.Sprintf("id IN (%s)", placeholders(len(args))), args...)
+
+		var existing int
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s FOR UPDATE", tableName, where)
+		if err := txRepo.getOne(&existing, query, where.args...); err != nil {
+			return err
+		}
+		if existing < len(args) {
+			return fmt.Errorf("%w: %d of %d", ErrEntitiesMissing, len(args)-existing, len(args))
+		}
+		return txRepo.DeleteByIDs(ids)
+	})
+}
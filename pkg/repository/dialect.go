@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect generates the parts of a query whose syntax differs between
+// databases. The repository otherwise still generates MySQL syntax, so a
+// dialect other than MySQLDialect only helps with servers that accept MySQL
+// syntax everywhere else.
+type Dialect interface {
+	// LimitClause returns the clause, starting with a space, that skips
+	// offset rows and returns at most limit rows, and its arguments.
+	LimitClause(limit, offset int) (string, []any)
+}
+
+// MySQLDialect generates LIMIT ? OFFSET ?. It is the default.
+type MySQLDialect struct{}
+
+func (MySQLDialect) LimitClause(limit, offset int) (string, []any) {
+	if offset == 0 {
+		return " LIMIT ?", []any{limit}
+	}
+	return " LIMIT ? OFFSET ?", []any{limit, offset}
+}
+
+// StandardDialect generates the SQL:2008 OFFSET ? ROWS FETCH FIRST ? ROWS ONLY.
+type StandardDialect struct{}
+
+func (StandardDialect) LimitClause(limit, offset int) (string, []any) {
+	if offset == 0 {
+		return " FETCH FIRST ? ROWS ONLY", []any{limit}
+	}
+	return " OFFSET ? ROWS FETCH FIRST ? ROWS ONLY", []any{offset, limit}
+}
+
+// WithDialect sets the dialect used to generate dialect-specific SQL.
+func WithDialect(dialect Dialect) Option {
+	return func(o *options) {
+		o.dialect = dialect
+	}
+}
+
+// limit appends the dialect's limit clause to query and its arguments to args.
+func (r *entityRepository[E, ID]) limit(query string, args []any, limit, offset int) (string, []any) {
+	clause, limitArgs := r.options.dialect.LimitClause(limit, offset)
+	return query + clause, append(args, limitArgs...)
+}
+
+// Collator is implemented by dialects whose databases compare and sort
+// strings under a collation named in the query.
+type Collator interface {
+	// Collate returns expr compared or sorted under collation.
+	Collate(expr, collation string) string
+}
+
+// Collate returns expr COLLATE collation.
+func (MySQLDialect) Collate(expr, collation string) string {
+	return fmt.Sprintf("%s COLLATE %s", expr, collation)
+}
+
+// ConstraintDeferrer is implemented by dialects whose databases can defer
+// constraint checks to the end of a transaction.
+type ConstraintDeferrer interface {
+	// ConstraintMode returns the statement switching the constraints of the
+	// current transaction to deferred or back to immediate checking.
+	ConstraintMode(deferred bool) string
+}
+
+// ConstraintMode returns SET CONSTRAINTS ALL DEFERRED or IMMEDIATE.
+func (StandardDialect) ConstraintMode(deferred bool) string {
+	if deferred {
+		return "SET CONSTRAINTS ALL DEFERRED"
+	}
+	return "SET CONSTRAINTS ALL IMMEDIATE"
+}
+
+// DateTruncator is implemented by dialects that can truncate a date or time to
+// the start of its day, week or month.
+type DateTruncator interface {
+	// TruncateDate returns an expression of type DATE holding the first day
+	// of the bucket that expr falls in.
+	TruncateDate(expr string, bucket Bucket) string
+}
+
+// TruncateDate truncates with DATE and DATE_FORMAT. Weeks start on Monday.
+func (MySQLDialect) TruncateDate(expr string, bucket Bucket) string {
+	switch bucket {
+	case BucketWeek:
+		return fmt.Sprintf("DATE(%s) - INTERVAL WEEKDAY(%s) DAY", expr, expr)
+	case BucketMonth:
+		return fmt.Sprintf("DATE(DATE_FORMAT(%s, '%%Y-%%m-01'))", expr)
+	default:
+		return fmt.Sprintf("DATE(%s)", expr)
+	}
+}
+
+// TruncateDate truncates with DATE_TRUNC. Weeks start on Monday.
+func (StandardDialect) TruncateDate(expr string, bucket Bucket) string {
+	return fmt.Sprintf("CAST(DATE_TRUNC('%s', %s) AS DATE)", bucket, expr)
+}
+
+// StringAggregator is implemented by dialects that can concatenate the values
+// of a group into one string.
+type StringAggregator interface {
+	// StringAgg returns an aggregate expression joining the non-NULL values
+	// of expr in ascending order with separator, a trusted string literal.
+	StringAgg(expr, separator string) string
+}
+
+// StringAgg aggregates with GROUP_CONCAT, whose result MySQL truncates to
+// group_concat_max_len bytes, 1024 by default.
+func (MySQLDialect) StringAgg(expr, separator string) string {
+	return fmt.Sprintf("GROUP_CONCAT(%s ORDER BY %s SEPARATOR '%s')", expr, expr, separator)
+}
+
+// StringAggLimiter is implemented by dialects whose string aggregation cuts
+// its results off at a length limit.
+type StringAggLimiter interface {
+	// StringAggLimit returns an expression evaluating to the limit, in bytes.
+	StringAggLimit() string
+}
+
+// StringAggLimit returns the session's group_concat_max_len.
+func (MySQLDialect) StringAggLimit() string {
+	return "@@group_concat_max_len"
+}
+
+// StringAgg aggregates with STRING_AGG.
+func (StandardDialect) StringAgg(expr, separator string) string {
+	return fmt.Sprintf("STRING_AGG(CAST(%s AS VARCHAR), '%s' ORDER BY %s)", expr, separator, expr)
+}
+
+// IdentifierQuoter is implemented by dialects that can quote identifiers, so
+// that names which are reserved words, such as rank, can be used as aliases.
+type IdentifierQuoter interface {
+	QuoteIdentifier(name string) string
+}
+
+// QuoteIdentifier quotes name with backticks.
+func (MySQLDialect) QuoteIdentifier(name string) string {
+	return "`" + name + "`"
+}
+
+// QuoteIdentifier quotes name with double quotes.
+func (StandardDialect) QuoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
+
+// quoteIdentifier quotes name when the dialect supports it. Names must
+// already be validated identifiers.
+func (r *entityRepository[E, ID]) quoteIdentifier(name string) string {
+	if quoter, ok := r.options.dialect.(IdentifierQuoter); ok {
+		return quoter.QuoteIdentifier(name)
+	}
+	return name
+}
+
+// NullsOrderer is implemented by dialects that can place NULLs explicitly in
+// an ORDER BY clause. Dialects that do not implement it sort by expr IS NULL
+// first, which works wherever booleans sort false before true.
+type NullsOrderer interface {
+	// OrderNulls returns the ORDER BY items sorting expr in the given
+	// direction with NULLs first or last.
+	OrderNulls(expr string, desc bool, nulls NullsOrder) string
+}
+
+// OrderNulls sorts by expr IS NULL before expr itself, since MySQL has no
+// NULLS FIRST or NULLS LAST.
+func (MySQLDialect) OrderNulls(expr string, desc bool, nulls NullsOrder) string {
+	return orderNullsFirst(expr, desc, nulls)
+}
+
+// OrderNulls uses NULLS FIRST or NULLS LAST.
+func (StandardDialect) OrderNulls(expr string, desc bool, nulls NullsOrder) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	if nulls == NullsLast {
+		return fmt.Sprintf("%s %s NULLS LAST", expr, direction)
+	}
+	return fmt.Sprintf("%s %s NULLS FIRST", expr, direction)
+}
+
+// orderNullsFirst returns ORDER BY items placing NULLs by sorting on
+// expr IS NULL first.
+func orderNullsFirst(expr string, desc bool, nulls NullsOrder) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+	nullsDirection := "DESC"
+	if nulls == NullsLast {
+		nullsDirection = "ASC"
+	}
+	return fmt.Sprintf("%s IS NULL %s, %s %s", expr, nullsDirection, expr, direction)
+}
+
+// orderNulls returns the dialect's ORDER BY items sorting expr with NULLs
+// placed according to nulls.
+func (r *entityRepository[E, ID]) orderNulls(expr string, desc bool, nulls NullsOrder) string {
+	if orderer, ok := r.options.dialect.(NullsOrderer); ok {
+		return orderer.OrderNulls(expr, desc, nulls)
+	}
+	return orderNullsFirst(expr, desc, nulls)
+}
+
+// WriteLimiter is implemented by dialects that can limit the rows an UPDATE
+// or DELETE changes directly. Other dialects restrict the statement to the ids
+// selected by a limited subquery.
+type WriteLimiter interface {
+	// LimitWrite returns the clause, starting with a space, that follows the
+	// WHERE clause of an UPDATE or DELETE to change at most limit rows in id
+	// order, and its arguments.
+	LimitWrite(limit int) (string, []any)
+}
+
+// LimitWrite returns ORDER BY id LIMIT ?.
+func (MySQLDialect) LimitWrite(limit int) (string, []any) {
+	return " ORDER BY id LIMIT ?", []any{limit}
+}
+
+// limitWrite returns the WHERE clause of an UPDATE or DELETE of the rows
+// matching where, changing at most limit rows in id order when limit is
+// positive, and its arguments.
+func (r *entityRepository[E, ID]) limitWrite(where *whereBuilder, limit int) (string, []any) {
+	if limit <= 0 {
+		return where.String(), where.args
+	}
+	if limiter, ok := r.options.dialect.(WriteLimiter); ok {
+		clause, args := limiter.LimitWrite(limit)
+		return where.String() + clause, append(where.args, args...)
+	}
+	subquery, args := r.limit(fmt.Sprintf("SELECT id FROM %s%s ORDER BY id", r.table(), where), where.args, limit, 0)
+	return fmt.Sprintf(" WHERE id IN (%s)", subquery), args
+}
+
+// RowEstimator is implemented by dialects whose databases keep an estimate of
+// the number of rows of a table in their catalog.
+type RowEstimator interface {
+	// EstimateRows returns a query selecting the estimated number of rows of
+	// table, and its arguments.
+	EstimateRows(table string) (string, []any)
+}
+
+// EstimateRows reads TABLE_ROWS from information_schema.tables.
+func (MySQLDialect) EstimateRows(table string) (string, []any) {
+	return "SELECT TABLE_ROWS FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", []any{table}
+}
+
+// Returner is implemented by dialects whose UPDATE and DELETE statements can
+// return columns of the rows they change.
+type Returner interface {
+	// Returning returns the clause, starting with a space, that makes a
+	// statement return columns.
+	Returning(columns string) string
+}
+
+// Returning returns RETURNING columns.
+func (StandardDialect) Returning(columns string) string {
+	return " RETURNING " + columns
+}
+
+// FullTextSearcher is implemented by dialects with full-text search.
+type FullTextSearcher interface {
+	// MatchAgainst returns an expression scoring the relevance of the columns
+	// to the search query bound to its single placeholder, and true for rows
+	// matching it.
+	MatchAgainst(columns []string) string
+}
+
+// MatchAgainst uses MATCH ... AGAINST in natural language mode.
+func (MySQLDialect) MatchAgainst(columns []string) string {
+	return fmt.Sprintf("MATCH(%s) AGAINST (? IN NATURAL LANGUAGE MODE)", strings.Join(columns, ", "))
+}
+
+// Explainer is implemented by dialects that can report a query plan in a
+// structured format. Other dialects use a plain EXPLAIN.
+type Explainer interface {
+	// Explain returns the statement reporting the plan of query.
+	Explain(query string) string
+}
+
+// Explain uses EXPLAIN FORMAT=JSON.
+func (MySQLDialect) Explain(query string) string {
+	return "EXPLAIN FORMAT=JSON " + query
+}
+
+// Explain uses EXPLAIN (FORMAT JSON).
+func (StandardDialect) Explain(query string) string {
+	return "EXPLAIN (FORMAT JSON) " + query
+}
+
+// SequenceTable is the table MySQLDialect keeps id counters in for
+// ReserveIDs, one row per entity table:
+//
+//	CREATE TABLE sqlrepo_sequences (
+//		name VARCHAR(64) NOT NULL PRIMARY KEY,
+//		last_id BIGINT NOT NULL
+//	)
+const SequenceTable = "sqlrepo_sequences"
+
+// IDBlockReserver is implemented by dialects that reserve ids as a block of
+// consecutive values with a single statement.
+type IDBlockReserver interface {
+	// ReserveIDBlock returns the statement reserving the next n consecutive
+	// ids of table, whose result's LastInsertId is the last of them, and its
+	// arguments.
+	ReserveIDBlock(table string, n int) (string, []any)
+}
+
+// ReserveIDBlock advances the counter of table in SequenceTable by n, seeding
+// it with the table's highest id on first use, and passes the new value
+// through LAST_INSERT_ID(expr) so it comes back in the result.
+func (MySQLDialect) ReserveIDBlock(table string, n int) (string, []any) {
+	query := fmt.Sprintf("INSERT INTO %s (name, last_id) VALUES (?, LAST_INSERT_ID((SELECT COALESCE(MAX(id), 0) FROM %s) + ?)) "+
+		"ON DUPLICATE KEY UPDATE last_id = LAST_INSERT_ID(last_id + ?)", SequenceTable, table)
+	return query, []any{table, n, n}
+}
+
+// IDSequencer is implemented by dialects that reserve ids by drawing them
+// from the sequence behind the id column.
+type IDSequencer interface {
+	// NextIDs returns a query selecting the next n ids of table, one per
+	// row, and its arguments.
+	NextIDs(table string, n int) (string, []any)
+}
+
+// NextIDs calls nextval on the sequence owned by the id column n times.
+func (StandardDialect) NextIDs(table string, n int) (string, []any) {
+	return "SELECT nextval(pg_get_serial_sequence(?, 'id')) FROM generate_series(1, ?)", []any{table, n}
+}
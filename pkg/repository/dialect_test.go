@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMySQLDialect_LimitClause(t *testing.T) {
+	clause, args := MySQLDialect{}.LimitClause(10, 0)
+	require.Equal(t, " LIMIT ?", clause)
+	require.Equal(t, []any{10}, args)
+
+	clause, args = MySQLDialect{}.LimitClause(10, 20)
+	require.Equal(t, " LIMIT ? OFFSET ?", clause)
+	require.Equal(t, []any{10, 20}, args)
+}
+
+func TestStandardDialect_LimitClause(t *testing.T) {
+	clause, args := StandardDialect{}.LimitClause(10, 0)
+	require.Equal(t, " FETCH FIRST ? ROWS ONLY", clause)
+	require.Equal(t, []any{10}, args)
+
+	clause, args = StandardDialect{}.LimitClause(10, 20)
+	require.Equal(t, " OFFSET ? ROWS FETCH FIRST ? ROWS ONLY", clause)
+	require.Equal(t, []any{20, 10}, args)
+}
+
+func TestDialect_PaginationQueries(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	cases := map[string]struct {
+		dialect Dialect
+		query   string
+		args    []any
+	}{
+		"mysql": {
+			dialect: MySQLDialect{},
+			query:   "SELECT * FROM sample_entities WHERE name = ? ORDER BY id LIMIT ? OFFSET ?",
+			args:    []any{"test", 5, 10},
+		},
+		"standard": {
+			dialect: StandardDialect{},
+			query:   "SELECT * FROM sample_entities WHERE name = ? ORDER BY id OFFSET ? ROWS FETCH FIRST ? ROWS ONLY",
+			args:    []any{"test", 10, 5},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := NewEntityRepository[SampleEntity](db, WithDialect(c.dialect), WithQueryCapture())
+			_, err := repo.FindBySpec(&QuerySpec{
+				Where:      []Condition{Eq("name", "test")},
+				OrderBy:    []OrderClause{{Column: "id"}},
+				Pagination: &Pagination{Limit: 5, Offset: 10},
+			})
+			require.Error(t, err)
+
+			query, args := repo.LastQuery()
+			require.Equal(t, c.query, query)
+			require.Equal(t, c.args, args)
+		})
+	}
+}
+
+func TestWithDeferredConstraints(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	called := false
+	err = NewEntityRepository[SampleEntity](db).WithDeferredConstraints(func(TxRepository[SampleEntity, int64]) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, ErrDeferredConstraintsUnsupported)
+	require.False(t, called)
+
+	require.Equal(t, "SET CONSTRAINTS ALL DEFERRED", StandardDialect{}.ConstraintMode(true))
+	require.Equal(t, "SET CONSTRAINTS ALL IMMEDIATE", StandardDialect{}.ConstraintMode(false))
+
+	connector := &recordingConnector{}
+	repo := NewEntityRepository[SampleEntity](sql.OpenDB(connector), WithDialect(StandardDialect{}))
+	failure := errors.New("failure")
+	err = repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		err := tx.WithDeferredConstraints(func(TxRepository[SampleEntity, int64]) error {
+			return failure
+		})
+		require.ErrorIs(t, err, failure)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"SET CONSTRAINTS ALL DEFERRED", "SET CONSTRAINTS ALL IMMEDIATE"}, connector.executed)
+}
+
+func TestDialect_CountByDateBucket(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[TimestampEntity](db, WithQueryCapture())
+	_, err = repo.CountByDateBucket("created_at", BucketMonth)
+	require.Error(t, err)
+	query, _ := repo.LastQuery()
+	require.Equal(t, "SELECT DATE(DATE_FORMAT(created_at, '%Y-%m-01')) AS bucket, COUNT(*) AS total FROM timestamp_entities WHERE created_at IS NOT NULL GROUP BY 1", query)
+
+	_, err = repo.CountByDateBucket("id", BucketDay)
+	require.ErrorContains(t, err, "not a date or time column")
+
+	require.Equal(t, "DATE(created_at) - INTERVAL WEEKDAY(created_at) DAY", MySQLDialect{}.TruncateDate("created_at", BucketWeek))
+	require.Equal(t, "CAST(DATE_TRUNC('week', created_at) AS DATE)", StandardDialect{}.TruncateDate("created_at", BucketWeek))
+}
+
+func TestDialect_StringAgg(t *testing.T) {
+	require.Equal(t, "GROUP_CONCAT(name ORDER BY name SEPARATOR ',')", MySQLDialect{}.StringAgg("name", ","))
+	require.Equal(t, "STRING_AGG(CAST(name AS VARCHAR), ',' ORDER BY name)", StandardDialect{}.StringAgg("name", ","))
+
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[OrderEntity](db, WithQueryCapture())
+	_, _, err = repo.ListGroupedBy("customer", "amount")
+	require.Error(t, err)
+	query, _ := repo.LastQuery()
+	require.Equal(t, "SELECT customer AS group_value, GROUP_CONCAT(amount ORDER BY amount SEPARATOR '\x1f') AS agg_values, SUM(LENGTH(amount)) + (COUNT(amount) - 1) * 1 AS agg_length, @@group_concat_max_len AS agg_limit FROM order_entities WHERE amount IS NOT NULL GROUP BY customer", query)
+
+	_, _, err = repo.ListGroupedBy("customer", "unknown")
+	require.ErrorContains(t, err, `unknown column "unknown"`)
+
+	repo = NewEntityRepository[OrderEntity](db, WithDialect(StandardDialect{}), WithQueryCapture())
+	_, _, _ = repo.ListGroupedBy("customer", "amount")
+	query, _ = repo.LastQuery()
+	require.Equal(t, "SELECT customer AS group_value, STRING_AGG(CAST(amount AS VARCHAR), '\x1f' ORDER BY amount) AS agg_values FROM order_entities WHERE amount IS NOT NULL GROUP BY customer", query)
+}
+
+func TestListGroupedBy_Truncated(t *testing.T) {
+	connector := &recordingConnector{
+		columns: []string{"group_value", "agg_values", "agg_length", "agg_limit"},
+		rows: [][]driver.Value{
+			{[]byte("alice"), []byte("10\x1f20"), int64(5), int64(5)},
+			{[]byte("bob"), []byte("12345"), int64(11), int64(5)},
+		},
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	_, _, err := NewEntityRepository[OrderEntity](db).ListGroupedBy("customer", "amount")
+	require.ErrorIs(t, err, ErrStringAggTruncated)
+	require.EqualError(t, err, `aggregated values exceed the string aggregation limit: group "bob" needs 11 bytes, the limit is 5`)
+
+	connector.rows = [][]driver.Value{connector.rows[0], {nil, []byte("7"), int64(1), int64(5)}}
+	lists, nulls, err := NewEntityRepository[OrderEntity](db).ListGroupedBy("customer", "amount")
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"alice": {"10", "20"}}, lists)
+	require.Equal(t, []string{"7"}, nulls)
+}
+
+func TestDialect_OrderNulls(t *testing.T) {
+	require.Equal(t, "score IS NULL DESC, score ASC", MySQLDialect{}.OrderNulls("score", false, NullsFirst))
+	require.Equal(t, "score IS NULL ASC, score DESC", MySQLDialect{}.OrderNulls("score", true, NullsLast))
+	require.Equal(t, "score ASC NULLS LAST", StandardDialect{}.OrderNulls("score", false, NullsLast))
+	require.Equal(t, "score DESC NULLS FIRST", StandardDialect{}.OrderNulls("score", true, NullsFirst))
+
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[ScoredEntity](db, WithDialect(StandardDialect{}), WithPageTokenSecret([]byte("secret")), WithQueryCapture())
+	_, _, err = repo.FindPageBy(KeysetOrder{Column: "score", Desc: true, Nulls: NullsLast}, "", 2)
+	require.Error(t, err)
+	query, _ := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM scored_entities ORDER BY score DESC NULLS LAST, id DESC FETCH FIRST ? ROWS ONLY", query)
+}
@@ -0,0 +1,35 @@
+package repository
+
+import "reflect"
+
+// ColumnChange is the stored and the new value of a column.
+type ColumnChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// DiffUpdate returns the columns Update would change in the row of entity,
+// keyed by column name, without writing anything. Only columns Update may
+// write are compared, see UpdatableEntity, and values are compared as they
+// are bound, so pointers are dereferenced and NULLs are nil. The stored row is
+// read without touching the snapshot kept by change tracking. It returns
+// ErrNotFound if the row does not exist.
+func (r *entityRepository[E, ID]) DiffUpdate(entity *E) (map[string]ColumnChange, error) {
+	stored, err := r.FindAllByID([]ID{(*entity).GetID()})
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) == 0 {
+		return nil, ErrNotFound
+	}
+
+	previous := r.snapshot(stored[0])
+	current := r.snapshot(entity)
+	changes := make(map[string]ColumnChange)
+	for _, c := range r.updatableColumns() {
+		if !reflect.DeepEqual(previous[c.Name], current[c.Name]) {
+			changes[c.Name] = ColumnChange{Old: previous[c.Name], New: current[c.Name]}
+		}
+	}
+	return changes, nil
+}
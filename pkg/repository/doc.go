@@ -0,0 +1,17 @@
+// Package repository provides a generic, reflection based repository for
+// entities stored in MySQL.
+//
+// Time values are bound with microsecond precision, so TIMESTAMP(6) and
+// DATETIME(6) columns round-trip exactly. Reading them back into time.Time
+// fields requires the DSN to set parseTime=true, and loc should match the
+// server time_zone so TIMESTAMP values are not shifted, e.g.
+//
+//	user:password@tcp(host:3306)/db?parseTime=true&loc=UTC
+//
+// A repository is safe for concurrent use by multiple goroutines, and so are
+// the views returned by methods such as WithContext or WithTrashed: the state
+// they share, such as the statement cache, the entity cache, the change
+// tracker or the captured query, is guarded by locks. Repositories bound to a
+// transaction are the exception, as the transaction itself must not be used
+// concurrently.
+package repository
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// DocumentEntity is implemented by entities stored as a single document, e.g.
+// event-sourced aggregates kept as JSON: instead of mapping every field to a
+// column, the repository stores what MarshalDocument returns in the document
+// column and restores the entity from it with UnmarshalDocument. Fields with
+// db tags, such as the id and the tenant column, are still mapped as usual,
+// so the document usually holds every other field, tagged db:"-".
+//
+// UnmarshalDocument must be implemented on the pointer receiver. It is called
+// after the tagged fields are scanned.
+type DocumentEntity interface {
+	MarshalDocument() ([]byte, error)
+	UnmarshalDocument(data []byte) error
+}
+
+// defaultDocumentColumn is the column storing documents unless
+// WithDocumentColumn names another one.
+const defaultDocumentColumn = "document"
+
+// WithDocumentColumn stores the documents of a DocumentEntity in column
+// instead of the default "document" column.
+func WithDocumentColumn(column string) Option {
+	return func(o *options) {
+		o.documentColumn = column
+	}
+}
+
+// documentColumn returns the document column of E, or false when E is not a
+// DocumentEntity.
+func (r *entityRepository[E, ID]) documentColumn() (column, bool) {
+	if _, ok := any(new(E)).(DocumentEntity); !ok {
+		return column{}, false
+	}
+	name := r.options.documentColumn
+	if name == "" {
+		name = defaultDocumentColumn
+	}
+	// The document column is not backed by a field of the entity, so it has
+	// no index.
+	return column{Name: name, Options: []string{"document"}}, true
+}
+
+// scansManually reports whether entities must be scanned by scanEntity rather
+// than by sqlx.
+func (r *entityRepository[E, ID]) scansManually() bool {
+	_, document := r.documentColumn()
+	return document || len(r.options.transformers) > 0
+}
+
+// marshalDocument returns the document of the entity entityValue addresses.
+func marshalDocument(entityValue reflect.Value) ([]byte, error) {
+	data, err := entityValue.Addr().Interface().(DocumentEntity).MarshalDocument()
+	if err != nil {
+		return nil, fmt.Errorf("marshal document: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NamedDocumentEntity keeps its document in the name column of
+// sample_entities, which the sqlrepo_rows driver fills in.
+type NamedDocumentEntity struct {
+	Id       int64  `db:"id,autoincrement"`
+	Document string `db:"-"`
+}
+
+func (e NamedDocumentEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e NamedDocumentEntity) GetTableName() string {
+	return "sample_entities"
+}
+
+func (e NamedDocumentEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e *NamedDocumentEntity) MarshalDocument() ([]byte, error) {
+	return []byte(e.Document), nil
+}
+
+func (e *NamedDocumentEntity) UnmarshalDocument(data []byte) error {
+	e.Document = string(data)
+	return nil
+}
+
+func TestDocumentEntity_Insert(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[EventEntity](db).(*entityRepository[EventEntity, int64])
+	insert, err := repo.buildInsert([]*EventEntity{{Kind: "created", Payload: "x"}}, false)
+	require.NoError(t, err)
+	require.Equal(t, "INSERT INTO event_entities (document) VALUES (?)", insert.query)
+	require.Equal(t, []any{[]byte(`{"kind":"created","payload":"x"}`)}, insert.args)
+
+	require.False(t, repo.validColumns()["document"])
+}
+
+func TestDocumentEntity_Scan(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "2")
+	require.NoError(t, err)
+	defer db.Close()
+
+	entities, err := NewEntityRepository[NamedDocumentEntity](db, WithDocumentColumn("name")).FindAll()
+	require.NoError(t, err)
+	require.Equal(t, []*NamedDocumentEntity{{Id: 1, Document: "entity 1"}, {Id: 2, Document: "entity 2"}}, entities)
+}
+
+func TestDocumentEntity_ColumnLookups(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[EventEntity](db, WithPageTokenSecret([]byte("secret")))
+
+	_, err = repo.CountByDateBucket("document", BucketDay)
+	require.EqualError(t, err, `unknown column "document"`)
+
+	_, err = repo.Duplicate(1, map[string]any{"document": "{}"})
+	require.EqualError(t, err, `unknown column "document"`)
+
+	_, _, err = repo.FindPageBy(KeysetOrder{Column: "document"}, "", 10)
+	require.EqualError(t, err, `unknown column "document"`)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// Duplicate inserts a copy of the entity with the given id and returns it. The
+// copy takes the value of overrides for every column in it, where a nil value
+// stores the zero value of the field. An auto-incremented id is cleared and the
+// copy gets a newly assigned one, which is set on the returned entity; any
+// other id must be given as overrides["id"], since the copy cannot reuse the
+// original's key.
+func (r *entityRepository[E, ID]) Duplicate(id ID, overrides map[string]any) (*E, error) {
+	columns := make(map[string]column)
+	for _, c := range r.columns() {
+		if c.Index != nil {
+			columns[c.Name] = c
+		}
+	}
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	idColumn := columns["id"]
+	_, newID := overrides["id"]
+	if idColumn.has("autoincrement") && newID {
+		return nil, fmt.Errorf("column id is auto-incremented and cannot be overridden")
+	}
+	if !idColumn.has("autoincrement") && !newID {
+		return nil, fmt.Errorf("duplicating needs a new id in the overrides")
+	}
+
+	entity, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	entityValue := reflect.ValueOf(entity).Elem()
+	if idColumn.has("autoincrement") {
+		entityValue.FieldByIndex(idColumn.Index).SetZero()
+	}
+	for _, name := range names {
+		if err := setFieldValue(entityValue.FieldByIndex(columns[name].Index), overrides[name]); err != nil {
+			return nil, fmt.Errorf("invalid override for column %s: %w", name, err)
+		}
+	}
+
+	if err := r.Save(entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// setFieldValue stores value in field. A nil value stores the zero value, a
+// value of the pointed-to type is stored in a new pointer, and numbers are
+// converted between numeric types as long as the field can hold them exactly,
+// so that 300 is not stored as 44 in an int8 nor 1.5 as 1 in an int.
+func setFieldValue(field reflect.Value, value any) error {
+	if value == nil {
+		field.SetZero()
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case field.Kind() == reflect.Pointer && v.Type().AssignableTo(field.Type().Elem()):
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(v)
+		field.Set(ptr)
+	case isNumericKind(v.Kind()) && isNumericKind(field.Kind()):
+		if !fitsNumber(field, v) {
+			return fmt.Errorf("%v does not fit in a field of type %s", value, field.Type())
+		}
+		field.Set(v.Convert(field.Type()))
+	default:
+		return fmt.Errorf("cannot store %T in a field of type %s", value, field.Type())
+	}
+	return nil
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	return (kind >= reflect.Int && kind <= reflect.Uint64) || kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// fitsNumber reports whether the number v converts to the type of field
+// without overflowing or dropping a fraction. Integers converted to floats may
+// still lose precision beyond the float's mantissa.
+func fitsNumber(field, v reflect.Value) bool {
+	switch {
+	case field.CanInt():
+		switch {
+		case v.CanInt():
+			return !field.OverflowInt(v.Int())
+		case v.CanUint():
+			return v.Uint() <= math.MaxInt64 && !field.OverflowInt(int64(v.Uint()))
+		default:
+			f := v.Float()
+			return f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 && !field.OverflowInt(int64(f))
+		}
+	case field.CanUint():
+		switch {
+		case v.CanInt():
+			return v.Int() >= 0 && !field.OverflowUint(uint64(v.Int()))
+		case v.CanUint():
+			return !field.OverflowUint(v.Uint())
+		default:
+			f := v.Float()
+			return f == math.Trunc(f) && f >= 0 && f < math.MaxUint64 && !field.OverflowUint(uint64(f))
+		}
+	default:
+		return !v.CanFloat() || !field.OverflowFloat(v.Float())
+	}
+}
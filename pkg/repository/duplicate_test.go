@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFieldValue(t *testing.T) {
+	var entity ScoredEntity
+	value := reflect.ValueOf(&entity).Elem()
+
+	require.NoError(t, setFieldValue(value.Field(0), 7))
+	require.Equal(t, int64(7), entity.Id)
+	require.NoError(t, setFieldValue(value.Field(1), int64(3)))
+	require.Equal(t, int64(3), *entity.Score)
+	require.NoError(t, setFieldValue(value.Field(1), nil))
+	require.Nil(t, entity.Score)
+	require.Error(t, setFieldValue(value.Field(0), "7"))
+
+	require.NoError(t, setFieldValue(value.Field(0), 2.0))
+	require.Equal(t, int64(2), entity.Id)
+	require.EqualError(t, setFieldValue(value.Field(0), 1.5), "1.5 does not fit in a field of type int64")
+	require.Error(t, setFieldValue(value.Field(0), uint64(math.MaxUint64)))
+	require.Error(t, setFieldValue(value.Field(0), math.Inf(1)))
+	require.Equal(t, int64(2), entity.Id)
+
+	var small struct {
+		Int8  int8
+		Uint  uint
+		Float float32
+	}
+	fields := reflect.ValueOf(&small).Elem()
+	require.EqualError(t, setFieldValue(fields.Field(0), 300), "300 does not fit in a field of type int8")
+	require.NoError(t, setFieldValue(fields.Field(0), -128))
+	require.Error(t, setFieldValue(fields.Field(1), -1))
+	require.NoError(t, setFieldValue(fields.Field(1), 3.0))
+	require.Error(t, setFieldValue(fields.Field(2), 1e300))
+	require.NoError(t, setFieldValue(fields.Field(2), 0.5))
+	require.Equal(t, struct {
+		Int8  int8
+		Uint  uint
+		Float float32
+	}{-128, 3, 0.5}, small)
+}
+
+func TestDuplicate_InvalidOverrides(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	repo := NewEntityRepository[SampleEntity](db)
+
+	_, err = repo.Duplicate(1, map[string]any{"unknown": 1})
+	require.ErrorContains(t, err, `unknown column "unknown"`)
+	_, err = repo.Duplicate(1, map[string]any{"id": 2})
+	require.ErrorContains(t, err, "cannot be overridden")
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+type CacheOption func(*cacheOptions)
+
+type cacheOptions struct {
+	stale      time.Duration
+	onError    func(error)
+	maxEntries int
+	// err is the first invalid argument given to a cache option.
+	err error
+}
+
+// defaultCacheEntries bounds an entity cache without MaxCachedEntities.
+const defaultCacheEntries = 10000
+
+// StaleWhileRevalidate keeps serving a cached entity for up to stale after
+// its TTL has passed, refreshing it from the database in the background, so
+// reads of hot entities do not wait for the database when their entry
+// expires. Concurrent reads of the same stale entry start a single refresh.
+func StaleWhileRevalidate(stale time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.stale = stale
+	}
+}
+
+// OnRevalidateError registers fn to be called with the error of a failed
+// background refresh. The stale entry is kept, and served until its stale
+// period ends, so a refresh failing does not fail any read.
+func OnRevalidateError(fn func(error)) CacheOption {
+	return func(o *cacheOptions) {
+		o.onError = fn
+	}
+}
+
+// MaxCachedEntities bounds the cache to n entities, evicting the least
+// recently read one to make room for another. The default is 10000. The
+// repository fails every query if n is not positive.
+func MaxCachedEntities(n int) CacheOption {
+	return func(o *cacheOptions) {
+		if n <= 0 {
+			if o.err == nil {
+				o.err = fmt.Errorf("max cached entities must be positive, got %d", n)
+			}
+			return
+		}
+		o.maxEntries = n
+	}
+}
+
+// WithEntityCache caches the entities read by FindByID for ttl, so repeated
+// reads of the same entity are served from memory. Entries are keyed by the
+// rows visible through the repository, so views of different tenants or
+// scopes never share them, and every write through the repository drops the
+// whole cache. Reads within a transaction bypass the cache, but a write
+// committed later still lets other readers see the old entity until the
+// transaction's writes drop it, and writes made by other processes are only
+// seen once entries expire.
+//
+// FindByID returns a deep copy of the cached entity, so callers may modify
+// it, including through its pointer, slice and map fields. Only the values of
+// unexported and interface fields are shared with the cache, and the fields
+// of the entity must not form cycles.
+func WithEntityCache(ttl time.Duration, opts ...CacheOption) Option {
+	return func(o *options) {
+		o.entityCacheTTL = ttl
+		o.entityCache = opts
+	}
+}
+
+type cacheEntry[E any] struct {
+	key        string
+	entity     *E
+	loadedAt   time.Time
+	refreshing bool
+}
+
+// entityCache is a read-through cache of entities keyed by query. It is safe
+// for concurrent use.
+type entityCache[E any] struct {
+	ttl     time.Duration
+	options cacheOptions
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// recent orders the entries from the most to the least recently read.
+	recent *list.List
+	// generation counts invalidations, so loads started before one do not
+	// store the entity they read.
+	generation uint64
+}
+
+func newEntityCache[E any](ttl time.Duration, opts []CacheOption) *entityCache[E] {
+	c := &entityCache[E]{ttl: ttl, now: time.Now, entries: make(map[string]*list.Element), recent: list.New()}
+	c.options.maxEntries = defaultCacheEntries
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+	return c
+}
+
+// get returns a copy of the entity cached under key, calling fetch to load it
+// when it is missing or expired. A stale entry is returned as is while a
+// single background fetch, run with ctx's values but not its cancellation,
+// refreshes it.
+func (c *entityCache[E]) get(ctx context.Context, key string, fetch func(ctx context.Context) (*E, error)) (*E, error) {
+	c.mu.Lock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry[E])
+		c.recent.MoveToFront(element)
+		age := c.now().Sub(entry.loadedAt)
+		switch {
+		case age < c.ttl:
+			c.mu.Unlock()
+			return copyEntity(entry.entity), nil
+		case age < c.ttl+c.options.stale:
+			if !entry.refreshing {
+				entry.refreshing = true
+				go c.refresh(context.WithoutCancel(ctx), key, c.generation, fetch)
+			}
+			c.mu.Unlock()
+			return copyEntity(entry.entity), nil
+		}
+		c.remove(key)
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	entity, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, generation, entity)
+	return copyEntity(entity), nil
+}
+
+// refresh reloads the entry under key in the background.
+func (c *entityCache[E]) refresh(ctx context.Context, key string, generation uint64, fetch func(ctx context.Context) (*E, error)) {
+	entity, err := func() (entity *E, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return fetch(ctx)
+	}()
+
+	switch {
+	case err == nil:
+		c.store(key, generation, entity)
+	case errors.Is(err, ErrNotFound):
+		c.mu.Lock()
+		if c.generation == generation {
+			c.remove(key)
+		}
+		c.mu.Unlock()
+	default:
+		if c.options.onError != nil {
+			c.options.onError(fmt.Errorf("revalidate cached entity: %w", err))
+		}
+		c.mu.Lock()
+		if element, ok := c.entries[key]; ok {
+			element.Value.(*cacheEntry[E]).refreshing = false
+		}
+		c.mu.Unlock()
+	}
+}
+
+// store caches entity under key unless the cache was invalidated since
+// generation, evicting the least recently read entries beyond the bound.
+func (c *entityCache[E]) store(key string, generation uint64, entity *E) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+	c.remove(key)
+	entry := &cacheEntry[E]{key: key, entity: copyEntity(entity), loadedAt: c.now()}
+	c.entries[key] = c.recent.PushFront(entry)
+	for len(c.entries) > c.options.maxEntries {
+		c.remove(c.recent.Back().Value.(*cacheEntry[E]).key)
+	}
+}
+
+// remove drops the entry under key, if any. c.mu must be held.
+func (c *entityCache[E]) remove(key string) {
+	if element, ok := c.entries[key]; ok {
+		c.recent.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// invalidate drops every entry.
+func (c *entityCache[E]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	clear(c.entries)
+	c.recent.Init()
+}
+
+// copyEntity returns a deep copy of entity; see WithEntityCache.
+func copyEntity[E any](entity *E) *E {
+	copied := new(E)
+	reflect.ValueOf(copied).Elem().Set(deepCopy(reflect.ValueOf(entity).Elem()))
+	return copied
+}
+
+// deepCopy returns a copy of v sharing no pointers, slices or maps reachable
+// through exported fields with it.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(deepCopy(v.Elem()))
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			copied.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		copied.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				copied.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return copied
+	}
+	return v
+}
+
+// cacheKey identifies the entity with id among the rows visible through the
+// repository.
+func (r *entityRepository[E, ID]) cacheKey(id ID) string {
+	where := r.where()
+	return fmt.Sprintf("%s%s %v %v", r.table(), where, where.args, id)
+}
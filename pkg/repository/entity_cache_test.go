@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityCache_StaleWhileRevalidate(t *testing.T) {
+	var errs atomic.Int32
+	cache := newEntityCache[SampleEntity](time.Minute, []CacheOption{
+		StaleWhileRevalidate(time.Minute),
+		OnRevalidateError(func(error) { errs.Add(1) }),
+	})
+	start := time.Now()
+	var elapsed atomic.Int64
+	cache.now = func() time.Time { return start.Add(time.Duration(elapsed.Load())) }
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	var fail atomic.Bool
+	fetch := func(context.Context) (*SampleEntity, error) {
+		n := fetches.Add(1)
+		if n > 1 {
+			<-release
+		}
+		if fail.Load() {
+			return nil, errors.New("connection refused")
+		}
+		return &SampleEntity{Id: 1, Name: "v" + string(rune('0'+n))}, nil
+	}
+
+	entity, err := cache.get(context.Background(), "k", fetch)
+	require.NoError(t, err)
+	require.Equal(t, "v1", entity.Name)
+	entity.Name = "modified"
+
+	entity, err = cache.get(context.Background(), "k", fetch)
+	require.NoError(t, err)
+	require.Equal(t, "v1", entity.Name)
+	require.EqualValues(t, 1, fetches.Load())
+
+	// Stale reads are served at once and share a single refresh.
+	elapsed.Add(int64(90 * time.Second))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entity, err := cache.get(context.Background(), "k", fetch)
+			assert.NoError(t, err)
+			assert.Equal(t, "v1", entity.Name)
+		}()
+	}
+	wg.Wait()
+	close(release)
+	require.Eventually(t, func() bool {
+		entity, _ := cache.get(context.Background(), "k", fetch)
+		return entity.Name == "v2"
+	}, time.Second, time.Millisecond)
+	require.EqualValues(t, 2, fetches.Load())
+
+	// A failed refresh keeps the stale entry and is reported.
+	elapsed.Add(int64(90 * time.Second))
+	fail.Store(true)
+	entity, err = cache.get(context.Background(), "k", fetch)
+	require.NoError(t, err)
+	require.Equal(t, "v2", entity.Name)
+	require.Eventually(t, func() bool {
+		cache.mu.Lock()
+		defer cache.mu.Unlock()
+		return !cache.entries["k"].Value.(*cacheEntry[SampleEntity]).refreshing
+	}, time.Second, time.Millisecond)
+	require.EqualValues(t, 1, errs.Load())
+
+	// Past the stale period the entry is loaded again.
+	elapsed.Add(int64(2 * time.Minute))
+	_, err = cache.get(context.Background(), "k", fetch)
+	require.Error(t, err)
+}
+
+func TestEntityCache_Invalidate(t *testing.T) {
+	cache := newEntityCache[SampleEntity](time.Minute, nil)
+	fetches := 0
+	fetch := func(context.Context) (*SampleEntity, error) {
+		fetches++
+		return &SampleEntity{Id: 1}, nil
+	}
+	_, err := cache.get(context.Background(), "k", fetch)
+	require.NoError(t, err)
+	cache.invalidate()
+	_, err = cache.get(context.Background(), "k", fetch)
+	require.NoError(t, err)
+	require.Equal(t, 2, fetches)
+}
+
+func TestEntityCache_MaxCachedEntities(t *testing.T) {
+	cache := newEntityCache[SampleEntity](time.Minute, []CacheOption{MaxCachedEntities(2)})
+	fetches := make(map[string]int)
+	get := func(key string) {
+		_, err := cache.get(context.Background(), key, func(context.Context) (*SampleEntity, error) {
+			fetches[key]++
+			return &SampleEntity{}, nil
+		})
+		require.NoError(t, err)
+	}
+
+	get("a")
+	get("b")
+	get("a")
+	get("c")
+	require.Len(t, cache.entries, 2)
+	get("a")
+	get("b")
+	require.Equal(t, map[string]int{"a": 1, "b": 2, "c": 1}, fetches)
+
+	require.Error(t, newEntityCache[SampleEntity](time.Minute, []CacheOption{MaxCachedEntities(0)}).options.err)
+}
+
+func TestCopyEntity(t *testing.T) {
+	type nested struct {
+		Tags  []string
+		Attrs map[string]*int
+	}
+	type entity struct {
+		Name   *string
+		Data   []byte
+		Nested nested
+		Items  [1]*nested
+		At     time.Time
+	}
+	name, n := "a", 1
+	original := &entity{
+		Name:   &name,
+		Data:   []byte("data"),
+		Nested: nested{Tags: []string{"x"}, Attrs: map[string]*int{"n": &n}},
+		Items:  [1]*nested{{Tags: []string{"y"}}},
+		At:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	copied := copyEntity(original)
+	require.Equal(t, original, copied)
+	*copied.Name = "b"
+	copied.Data[0] = 'D'
+	copied.Nested.Tags[0] = "changed"
+	*copied.Nested.Attrs["n"] = 2
+	copied.Items[0].Tags[0] = "changed"
+	require.Equal(t, "a", name)
+	require.Equal(t, "data", string(original.Data))
+	require.Equal(t, "x", original.Nested.Tags[0])
+	require.Equal(t, 1, n)
+	require.Equal(t, "y", original.Items[0].Tags[0])
+}
+
+func TestFindByID_EntityCache(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "1")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithEntityCache(time.Minute), WithQueryCapture())
+	entity, err := repo.FindByID(1)
+	require.NoError(t, err)
+	require.Equal(t, "entity 1", entity.Name)
+
+	_, err = repo.FindAll()
+	require.NoError(t, err)
+	lastQuery, _ := repo.LastQuery()
+
+	entity, err = repo.FindByID(1)
+	require.NoError(t, err)
+	require.Equal(t, "entity 1", entity.Name)
+	query, _ := repo.LastQuery()
+	require.Equal(t, lastQuery, query)
+}
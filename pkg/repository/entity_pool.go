@@ -0,0 +1,67 @@
+package repository
+
+import "sync"
+
+// Resetter is implemented by entities that clear themselves for reuse, for
+// example truncating slices to keep their backing arrays. Pooled entities
+// that do not implement it are reset to their zero value.
+type Resetter interface {
+	Reset()
+}
+
+// WithEntityPool makes Stream lend entities from a sync.Pool instead of
+// allocating one per row, which saves an allocation per row and, for entities
+// implementing Resetter, the buffers they keep.
+//
+// An entity passed to the Stream callback is only valid until the callback
+// returns: it is then reset and reused for a later row, possibly by another
+// goroutine. Callbacks must copy whatever they keep, such as the entity value
+// itself or the contents of its slices, and must not hand the pointer to other
+// goroutines. Methods returning entities to the caller never use the pool.
+func WithEntityPool() Option {
+	return func(o *options) {
+		o.entityPool = true
+	}
+}
+
+// entityPool holds reusable entities of type E. It is shared by all views of
+// a repository.
+type entityPool[E any] struct {
+	pool sync.Pool
+}
+
+func newEntityPool[E any]() *entityPool[E] {
+	return &entityPool[E]{pool: sync.Pool{New: func() any { return new(E) }}}
+}
+
+func (p *entityPool[E]) get() *E {
+	return p.pool.Get().(*E)
+}
+
+// put resets entity and returns it to the pool.
+func (p *entityPool[E]) put(entity *E) {
+	if resetter, ok := any(entity).(Resetter); ok {
+		resetter.Reset()
+	} else {
+		var zero E
+		*entity = zero
+	}
+	p.pool.Put(entity)
+}
+
+// newEntity returns an entity to scan a row into, from the pool if the
+// repository has one.
+func (r *entityRepository[E, ID]) newEntity() *E {
+	if r.entities == nil {
+		return new(E)
+	}
+	return r.entities.get()
+}
+
+// releaseEntity returns an entity obtained from newEntity to the pool, if the
+// repository has one.
+func (r *entityRepository[E, ID]) releaseEntity(entity *E) {
+	if r.entities != nil {
+		r.entities.put(entity)
+	}
+}
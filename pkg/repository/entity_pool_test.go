@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rowsDriver answers every query with the rows of sample_entities numbered
+// 1 to n, where n is the data source name, and every other statement as if it
+// inserted a row with id 1.
+type rowsDriver struct{}
+
+func (rowsDriver) Open(name string) (driver.Conn, error) {
+	n, err := strconv.Atoi(name)
+	if err != nil {
+		return nil, err
+	}
+	return rowsConn(n), nil
+}
+
+type rowsConn int
+
+func (c rowsConn) Prepare(string) (driver.Stmt, error) { return rowsStmt(c), nil }
+func (rowsConn) Close() error                          { return nil }
+func (rowsConn) Begin() (driver.Tx, error)             { return nil, driver.ErrSkip }
+
+type rowsStmt int
+
+func (rowsStmt) Close() error                               { return nil }
+func (rowsStmt) NumInput() int                              { return -1 }
+func (rowsStmt) Exec([]driver.Value) (driver.Result, error) { return insertResult(1), nil }
+func (s rowsStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &sampleRows{n: int(s)}, nil
+}
+
+// insertResult reports inserting rows numbered from 1.
+type insertResult int64
+
+func (insertResult) LastInsertId() (int64, error)   { return 1, nil }
+func (r insertResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+type sampleRows struct {
+	n, i int
+}
+
+func (*sampleRows) Columns() []string { return []string{"id", "name"} }
+func (*sampleRows) Close() error      { return nil }
+func (r *sampleRows) Next(dest []driver.Value) error {
+	if r.i == r.n {
+		return io.EOF
+	}
+	r.i++
+	dest[0] = int64(r.i)
+	dest[1] = []byte("entity " + strconv.Itoa(r.i))
+	return nil
+}
+
+func init() {
+	sql.Register("sqlrepo_rows", rowsDriver{})
+}
+
+// ResettableEntity counts how often it is reset.
+type ResettableEntity struct {
+	SampleEntity
+	resets int
+}
+
+func (e *ResettableEntity) Reset() {
+	e.Id, e.Name = 0, ""
+	e.resets++
+}
+
+func TestEntityPool(t *testing.T) {
+	pool := newEntityPool[SampleEntity]()
+	entity := pool.get()
+	entity.Name = "used"
+	pool.put(entity)
+	require.Empty(t, entity.Name)
+
+	resettable := newEntityPool[ResettableEntity]()
+	e := resettable.get()
+	e.Name = "used"
+	resettable.put(e)
+	require.Empty(t, e.Name)
+	require.Equal(t, 1, e.resets)
+}
+
+func TestStream_EntityPool(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "3")
+	require.NoError(t, err)
+	defer db.Close()
+
+	var names []string
+	err = NewEntityRepository[SampleEntity](db, WithEntityPool()).Stream(func(entity *SampleEntity) error {
+		names = append(names, entity.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"entity 1", "entity 2", "entity 3"}, names)
+}
+
+func BenchmarkStream(b *testing.B) {
+	db, err := sql.Open("sqlrepo_rows", "1000")
+	require.NoError(b, err)
+	defer db.Close()
+
+	cases := map[string]Repository[SampleEntity, int64]{
+		"allocated": NewEntityRepository[SampleEntity](db),
+		"pooled":    NewEntityRepository[SampleEntity](db, WithEntityPool()),
+	}
+	for name, repo := range cases {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := repo.Stream(func(entity *SampleEntity) error {
+					return nil
+				})
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var ErrEstimateUnsupported = errors.New("dialect does not support row estimates")
+
+// EstimatedCount returns the number of rows of the entity's table as estimated
+// by the database catalog, without counting them, for totals that only need to
+// be roughly right, such as "about 2.3M results" in a pagination UI. It fails
+// with ErrEstimateUnsupported unless the dialect is a RowEstimator.
+//
+// On MySQL the estimate comes from InnoDB's sampled statistics and can be off
+// by 40% or more, especially right after bulk writes, until the statistics are
+// recalculated, e.g. by ANALYZE TABLE. It covers the whole table: the tenant,
+// soft delete and global scopes of the repository are ignored, so it may count
+// rows the repository never returns. Use an exact count for anything else.
+func (r *entityRepository[E, ID]) EstimatedCount() (int64, error) {
+	estimator, ok := r.options.dialect.(RowEstimator)
+	if !ok {
+		return 0, ErrEstimateUnsupported
+	}
+	var emptyEntity E
+	tableName := emptyEntity.GetTableName()
+
+	query, args := estimator.EstimateRows(tableName)
+	var rows sql.NullInt64
+	if err := r.getOne(&rows, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("table %s does not exist", tableName)
+		}
+		return 0, err
+	}
+	return rows.Int64, nil
+}
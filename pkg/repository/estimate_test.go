@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimatedCount_Query(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	_, err = repo.EstimatedCount()
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT TABLE_ROWS FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", query)
+	require.Equal(t, []any{"sample_entities"}, args)
+
+	_, err = NewEntityRepository[SampleEntity](db, WithDialect(StandardDialect{})).EstimatedCount()
+	require.ErrorIs(t, err, ErrEstimateUnsupported)
+}
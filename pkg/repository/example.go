@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FindByExample returns the entities whose columns equal the non-zero fields
+// of example, e.g. FindByExample(&Order{Customer: "alice"}). Zero fields are
+// ignored, since a zero might as well mean "unset", except for the columns
+// listed in includeZero, which are matched even when zero: a nil pointer then
+// matches NULL. Readonly columns and the document of a DocumentEntity are
+// never matched.
+func (r *entityRepository[E, ID]) FindByExample(example *E, includeZero ...string) ([]*E, error) {
+	validColumns := r.validColumns()
+	included := make(map[string]bool, len(includeZero))
+	for _, column := range includeZero {
+		if !validColumns[column] {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		included[column] = true
+	}
+
+	exampleValue := reflect.ValueOf(example).Elem()
+	var conditions []Condition
+	for _, c := range r.columns() {
+		if !validColumns[c.Name] {
+			continue
+		}
+		if exampleValue.FieldByIndex(c.Index).IsZero() && !included[c.Name] {
+			continue
+		}
+		// Conditions encode transformed columns themselves.
+		var value any
+		if field := exampleValue.FieldByIndex(c.Index); field.Kind() != reflect.Pointer || !field.IsNil() {
+			value = bindValue(field.Interface())
+		}
+		conditions = append(conditions, Eq(c.Name, value))
+	}
+
+	result, err := r.Find(FindOptions{Where: conditions})
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
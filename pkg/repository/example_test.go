@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindByExample_Query(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	orders := NewEntityRepository[OrderEntity](db, WithQueryCapture())
+	_, err = orders.FindByExample(&OrderEntity{Customer: "alice"})
+	require.Error(t, err)
+	query, args := orders.LastQuery()
+	require.Equal(t, "SELECT * FROM order_entities WHERE customer = ?", query)
+	require.Equal(t, []any{"alice"}, args)
+
+	_, err = orders.FindByExample(&OrderEntity{Customer: "alice"}, "amount")
+	require.Error(t, err)
+	query, args = orders.LastQuery()
+	require.Equal(t, "SELECT * FROM order_entities WHERE customer = ? AND amount = ?", query)
+	require.Equal(t, []any{"alice", int64(0)}, args)
+
+	_, err = orders.FindByExample(&OrderEntity{}, "unknown")
+	require.Error(t, err)
+
+	deleted := NewEntityRepository[SoftDeleteEntity](db, WithQueryCapture()).WithTrashed()
+	_, err = deleted.FindByExample(&SoftDeleteEntity{Name: "x"}, "deleted_at")
+	require.Error(t, err)
+	query, args = deleted.LastQuery()
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE name = ? AND deleted_at IS NULL", query)
+	require.Equal(t, []any{"x"}, args)
+}
+
+func TestFindByExample_Transformed(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[CompressedEntity](db, WithColumnTransformer("body", gzipTransformer{}), WithQueryCapture())
+	_, err = repo.FindByExample(&CompressedEntity{Body: "bob"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM compressed_entities WHERE body = ?", query)
+	bob, err := gzipTransformer{}.Encode("bob")
+	require.NoError(t, err)
+	require.Equal(t, []any{bob}, args)
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ext returns the transaction the repository is bound to, if any, or the
+// database otherwise.
+func (r *entityRepository[E, ID]) ext() sqlx.ExtContext {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.DB
+}
+
+// prepared returns the cached statement for query, bound to the repository's
+// transaction if any, or nil when statements are not cached. release must be
+// called once the statement has been used.
+func (r *entityRepository[E, ID]) prepared(query string) (stmt *sqlx.Stmt, release func(), err error) {
+	if r.stmts == nil {
+		return nil, func() {}, nil
+	}
+	stmt, release, err = r.stmts.get(r.DB, query)
+	if err != nil || r.tx == nil {
+		return stmt, release, err
+	}
+	return r.tx.StmtxContext(r.ctx, stmt), release, nil
+}
+
+func (r *entityRepository[E, ID]) selectAll(dest any, query string, args ...any) error {
+	r.record(query, args)
+	if err := r.ready(); err != nil {
+		return err
+	}
+	return r.withQueryTimeout(func(r *entityRepository[E, ID]) error {
+		if entities, ok := dest.(*[]*E); ok && r.scansManually() {
+			*entities = nil
+			return r.selectEntities(entities, query, args...)
+		}
+		return r.retry(r.options.readRetry, func() error {
+			// Select appends to dest, so discard rows scanned by a failed attempt.
+			destValue := reflect.ValueOf(dest).Elem()
+			destValue.Set(reflect.Zero(destValue.Type()))
+
+			stmt, release, err := r.prepared(query)
+			if err != nil {
+				return err
+			}
+			defer release()
+			if stmt != nil {
+				return stmt.SelectContext(r.ctx, dest, args...)
+			}
+			return sqlx.SelectContext(r.ctx, r.ext(), dest, query, args...)
+		})
+	})
+}
+
+func (r *entityRepository[E, ID]) queryRows(query string, args ...any) (*sqlx.Rows, error) {
+	r.record(query, args)
+	if err := r.ready(); err != nil {
+		return nil, err
+	}
+	var rows *sqlx.Rows
+	err := r.retry(r.options.readRetry, func() error {
+		stmt, release, err := r.prepared(query)
+		if err != nil {
+			return err
+		}
+		defer release()
+		if stmt != nil {
+			rows, err = stmt.QueryxContext(r.ctx, args...)
+			return err
+		}
+		rows, err = r.ext().QueryxContext(r.ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+func (r *entityRepository[E, ID]) getOne(dest any, query string, args ...any) error {
+	r.record(query, args)
+	if err := r.ready(); err != nil {
+		return err
+	}
+	return r.withQueryTimeout(func(r *entityRepository[E, ID]) error {
+		return r.retry(r.options.readRetry, func() error {
+			stmt, release, err := r.prepared(query)
+			if err != nil {
+				return err
+			}
+			defer release()
+			if stmt != nil {
+				return stmt.GetContext(r.ctx, dest, args...)
+			}
+			return sqlx.GetContext(r.ctx, r.ext(), dest, query, args...)
+		})
+	})
+}
+
+// wrote reports a write statement to the auditor and drops the entity cache,
+// which it may have made stale.
+func (r *entityRepository[E, ID]) wrote(query string, args []any, err error) {
+	r.audit(query, args, err)
+	if r.cache != nil {
+		r.cache.invalidate()
+	}
+}
+
+func (r *entityRepository[E, ID]) exec(query string, args ...any) (sql.Result, error) {
+	r.record(query, args)
+	if err := r.ready(); err != nil {
+		return nil, err
+	}
+	var result sql.Result
+	err := r.retry(r.options.writeRetry, func() error {
+		stmt, release, err := r.prepared(query)
+		if err != nil {
+			return err
+		}
+		defer release()
+		if stmt != nil {
+			result, err = stmt.ExecContext(r.ctx, args...)
+			return err
+		}
+		result, err = r.ext().ExecContext(r.ctx, query, args...)
+		return err
+	})
+	r.wrote(query, args, err)
+	return result, checkError(err)
+}
+
+// execReturning runs query, a write returning rows such as an UPDATE with a
+// dialect's RETURNING clause, and scans the rows into dest. Unlike reads it is
+// not retried, since the write may have been applied.
+func (r *entityRepository[E, ID]) execReturning(dest any, query string, args ...any) error {
+	r.record(query, args)
+	if err := r.ready(); err != nil {
+		return err
+	}
+	err := r.withQueryTimeout(func(r *entityRepository[E, ID]) error {
+		stmt, release, err := r.prepared(query)
+		if err != nil {
+			return err
+		}
+		defer release()
+		if stmt != nil {
+			return stmt.SelectContext(r.ctx, dest, args...)
+		}
+		return sqlx.SelectContext(r.ctx, r.ext(), dest, query, args...)
+	})
+	r.wrote(query, args, err)
+	return checkError(err)
+}
@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithExistencePrefilter makes ExistsByIDs consult an in-memory Bloom filter of
+// the table's ids before querying, sized for expectedItems ids at the given
+// false positive rate. Ids the filter rules out are reported missing without a
+// round trip; possible matches are always confirmed with a real query, so
+// false positives only cost a query.
+//
+// The filter is loaded with every id in the table on first use and then learns
+// the ids inserted through the repository. Rows inserted by anything else are
+// invisible to it, which would make ExistsByIDs report them as missing, until
+// RefreshExistenceFilter or InvalidateExistenceFilter is called.
+//
+// The repository fails every query unless expectedItems is positive and
+// falsePositiveRate is strictly between 0 and 1.
+func WithExistencePrefilter(expectedItems int, falsePositiveRate float64) Option {
+	return func(o *options) {
+		if expectedItems <= 0 {
+			o.invalid(fmt.Errorf("expected items must be positive, got %d", expectedItems))
+			return
+		}
+		if !(falsePositiveRate > 0 && falsePositiveRate < 1) {
+			o.invalid(fmt.Errorf("false positive rate must be between 0 and 1, got %v", falsePositiveRate))
+			return
+		}
+		o.existenceFilterItems = expectedItems
+		o.existenceFilterRate = falsePositiveRate
+	}
+}
+
+// ExistsByIDs reports for every id whether a matching row exists.
+func (r *entityRepository[E, ID]) ExistsByIDs(ids []ID) (map[ID]bool, error) {
+	exists := make(map[ID]bool, len(ids))
+	candidates := ids
+	if r.existence != nil {
+		filter, err := r.existence.load(r.fillExistenceFilter)
+		if err != nil {
+			return nil, err
+		}
+		candidates = nil
+		for _, id := range ids {
+			if r.existence.mayContain(filter, id) {
+				candidates = append(candidates, id)
+			}
+		}
+	}
+	for _, id := range ids {
+		exists[id] = false
+	}
+
+	tableName := r.table()
+	for _, idChunk := range chunk(candidates, maxInListSize) {
+		args := make([]interface{}, len(idChunk))
+		for i, id := range idChunk {
+			args[i] = id
+		}
+
+		where := r.where()
+		where.add(fmt.Sprintf("id IN (%s)", placeholders(len(idChunk))), args...)
+		var found []ID
+		query := fmt.Sprintf("SELECT id FROM %s%s", tableName, where)
+		if err := r.selectAll(&found, query, where.args...); err != nil {
+			return nil, err
+		}
+		for _, id := range found {
+			exists[id] = true
+		}
+	}
+	return exists, nil
+}
+
+// ExistsByColumnValues reports for every value whether a row with that value
+// in column exists, using one IN query per chunk of values. Values must be
+// comparable, since they key the result. Stored values are matched back to
+// the supplied ones by their text form, so a value only equal to a stored one
+// under a case-insensitive collation is reported missing.
+func (r *entityRepository[E, ID]) ExistsByColumnValues(column string, values []any) (map[any]bool, error) {
+	if !r.validColumns()[column] {
+		return nil, fmt.Errorf("unknown column %q", column)
+	}
+
+	exists := make(map[any]bool, len(values))
+	byKey := make(map[string][]any, len(values))
+	for _, value := range values {
+		exists[value] = false
+		key := valueKey(value)
+		byKey[key] = append(byKey[key], value)
+	}
+
+	tableName := r.table()
+	for _, valueChunk := range chunk(values, maxInListSize) {
+		args := make([]any, len(valueChunk))
+		for i, value := range valueChunk {
+			args[i] = bindValue(value)
+		}
+
+		where := r.where()
+		where.add(fmt.Sprintf("%s IN (%s)", column, placeholders(len(valueChunk))), args...)
+		var found []any
+		query := fmt.Sprintf("SELECT DISTINCT %s FROM %s%s", column, tableName, where)
+		if err := r.selectAll(&found, query, where.args...); err != nil {
+			return nil, err
+		}
+		for _, value := range found {
+			for _, supplied := range byKey[valueKey(value)] {
+				exists[supplied] = true
+			}
+		}
+	}
+	return exists, nil
+}
+
+// valueKey returns the text form used to match a stored column value to a
+// supplied one.
+func valueKey(v any) string {
+	switch t := bindValue(v).(type) {
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		if t != nil {
+			return t.UTC().Format(time.RFC3339Nano)
+		}
+	case bool:
+		if t {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprint(v)
+}
+
+// RefreshExistenceFilter rebuilds the existence prefilter from the table.
+func (r *entityRepository[E, ID]) RefreshExistenceFilter() error {
+	if r.existence == nil {
+		return fmt.Errorf("existence prefilter is not enabled")
+	}
+	r.existence.invalidate()
+	_, err := r.existence.load(r.fillExistenceFilter)
+	return err
+}
+
+// InvalidateExistenceFilter drops the existence prefilter; it is rebuilt on
+// the next ExistsByIDs call.
+func (r *entityRepository[E, ID]) InvalidateExistenceFilter() {
+	if r.existence != nil {
+		r.existence.invalidate()
+	}
+}
+
+func (r *entityRepository[E, ID]) fillExistenceFilter(filter *bloomFilter) error {
+	var emptyEntity E
+	query := fmt.Sprintf("SELECT id FROM %s", emptyEntity.GetTableName())
+	rows, err := r.queryRows(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id ID
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		filter.add(id)
+	}
+	return rows.Err()
+}
+
+// rememberIDs records inserted ids in the existence prefilter. Entities whose
+// id is still zero got an id the repository does not know, so the filter is
+// dropped rather than risk a false negative.
+func (r *entityRepository[E, ID]) rememberIDs(entities []*E) {
+	if r.existence == nil {
+		return
+	}
+	var zero ID
+	keys := make([]any, len(entities))
+	for i, entity := range entities {
+		id := (*entity).GetID()
+		if id == zero {
+			r.existence.invalidate()
+			return
+		}
+		keys[i] = id
+	}
+	r.existence.add(keys...)
+}
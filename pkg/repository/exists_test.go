@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExistencePrefilter_Validation(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	_, err = NewEntityRepository[SampleEntity](db, WithExistencePrefilter(100, 0.01)).FindAll()
+	require.EqualError(t, err, "sql: database is closed")
+	for _, rate := range []float64{0, 1, -0.1, 1.5, math.NaN()} {
+		_, err := NewEntityRepository[SampleEntity](db, WithExistencePrefilter(100, rate)).FindAll()
+		require.ErrorContains(t, err, "false positive rate must be between 0 and 1", rate)
+	}
+	_, err = NewEntityRepository[SampleEntity](db, WithExistencePrefilter(0, 0.01)).FindAll()
+	require.EqualError(t, err, "expected items must be positive, got 0")
+}
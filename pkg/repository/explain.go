@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Explain returns the plan the database chooses for the query FindBySpec runs
+// for spec, without running it, to check how generated queries use indexes.
+// The plan is JSON with an Explainer dialect. Otherwise it is the rows of a
+// plain EXPLAIN, one per line with their columns separated by tabs.
+func (r *entityRepository[E, ID]) Explain(spec *QuerySpec) (string, error) {
+	if spec == nil {
+		return "", errors.New("query spec is required")
+	}
+	query, args, _, err := r.findQuery(FindOptions{Where: spec.Where, OrderBy: spec.OrderBy, Pagination: spec.Pagination})
+	if err != nil {
+		return "", err
+	}
+	explain := "EXPLAIN " + query
+	if explainer, ok := r.options.dialect.(Explainer); ok {
+		explain = explainer.Explain(query)
+	}
+
+	rows, err := r.queryRows(explain, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		targets := make([]any, len(columns))
+		for i := range values {
+			targets[i] = &values[i]
+		}
+		if err := rows.Scan(targets...); err != nil {
+			return "", err
+		}
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = value.String
+		}
+		lines = append(lines, strings.Join(fields, "\t"))
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
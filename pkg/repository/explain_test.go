@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "2")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	plan, err := repo.Explain(&QuerySpec{Where: []Condition{Eq("name", "x")}})
+	require.NoError(t, err)
+	require.Equal(t, "1\tentity 1\n2\tentity 2", plan)
+	query, args := repo.LastQuery()
+	require.Equal(t, "EXPLAIN FORMAT=JSON SELECT * FROM sample_entities WHERE name = ?", query)
+	require.Equal(t, []any{"x"}, args)
+
+	_, err = repo.Explain(&QuerySpec{Where: []Condition{Eq("unknown", "x")}})
+	require.Error(t, err)
+
+	_, err = repo.Explain(nil)
+	require.EqualError(t, err, "query spec is required")
+}
@@ -0,0 +1,209 @@
+// Package export writes the entities of a repository to analytics file
+// formats. It is kept apart from package repository so that only programs
+// exporting data depend on the Parquet libraries.
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/xitongsys/parquet-go/writer"
+
+	"sqlrepo/pkg/repository"
+)
+
+// rowGroupSize bounds the bytes of rows buffered in memory before they are
+// written out as a Parquet row group.
+const rowGroupSize = 8 << 20
+
+// Parquet streams every entity visible through repo into w as a Parquet file,
+// with one column per stored field, named after it. Rows are read one at a
+// time with Stream and buffered only until a row group is full, so memory use
+// stays bounded whatever the table size. Views of repo narrow what is
+// exported, e.g. repo.OnlyTrashed().
+//
+// Fields map to Parquet types by kind: booleans to BOOLEAN, integers of up to
+// 32 bits to INT32 and larger ones to INT64, floats to FLOAT and DOUBLE,
+// strings to UTF8 BYTE_ARRAY, []byte to BYTE_ARRAY and time.Time to INT64
+// TIMESTAMP_MICROS. Pointers and sql.Null types make the column optional,
+// with NULLs written as nulls. Other field types are rejected before anything
+// is written.
+func Parquet[E repository.Entity[ID], ID comparable](repo repository.Repository[E, ID], w io.Writer) error {
+	fields, err := repository.StoredFields(repo)
+	if err != nil {
+		return err
+	}
+	return writeParquet(w, fields, repo.Stream)
+}
+
+// writeParquet writes the entities produced by stream into w.
+func writeParquet[E any](w io.Writer, fields []repository.Field, stream func(fn func(*E) error) error) error {
+	entityType := reflect.TypeOf((*E)(nil)).Elem()
+	columns := make([]parquetColumn, len(fields))
+	metadata := make([]string, len(fields))
+	for i, field := range fields {
+		column, err := newParquetColumn(entityType.FieldByIndex(field.Index).Type)
+		if err != nil {
+			return fmt.Errorf("column %s: %w", field.Column, err)
+		}
+		columns[i] = column
+		metadata[i] = fmt.Sprintf("name=%s, %s", field.Column, column.schema)
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(metadata, w, 1)
+	if err != nil {
+		return err
+	}
+	pw.RowGroupSize = rowGroupSize
+
+	err = stream(func(entity *E) error {
+		value := reflect.ValueOf(entity).Elem()
+		row := make([]any, len(fields))
+		for i, field := range fields {
+			v, err := columns[i].value(value.FieldByIndex(field.Index))
+			if err != nil {
+				return fmt.Errorf("column %s: %w", field.Column, err)
+			}
+			row[i] = v
+		}
+		return pw.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	return pw.WriteStop()
+}
+
+// parquetColumn describes how a field type is written.
+type parquetColumn struct {
+	// schema is the type part of the column's parquet-go metadata.
+	schema string
+	// value returns the Parquet value of a field, nil for NULL.
+	value func(reflect.Value) (any, error)
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	bytesType       = reflect.TypeOf([]byte(nil))
+	nullStringType  = reflect.TypeOf(sql.NullString{})
+	nullInt64Type   = reflect.TypeOf(sql.NullInt64{})
+	nullInt32Type   = reflect.TypeOf(sql.NullInt32{})
+	nullInt16Type   = reflect.TypeOf(sql.NullInt16{})
+	nullByteType    = reflect.TypeOf(sql.NullByte{})
+	nullFloat64Type = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType    = reflect.TypeOf(sql.NullBool{})
+	nullTimeType    = reflect.TypeOf(sql.NullTime{})
+)
+
+// nullTypes maps the sql.Null types to the type of their value.
+var nullTypes = map[reflect.Type]reflect.Type{
+	nullStringType:  reflect.TypeOf(""),
+	nullInt64Type:   reflect.TypeOf(int64(0)),
+	nullInt32Type:   reflect.TypeOf(int32(0)),
+	nullInt16Type:   reflect.TypeOf(int16(0)),
+	nullByteType:    reflect.TypeOf(byte(0)),
+	nullFloat64Type: reflect.TypeOf(float64(0)),
+	nullBoolType:    reflect.TypeOf(false),
+	nullTimeType:    timeType,
+}
+
+func newParquetColumn(t reflect.Type) (parquetColumn, error) {
+	if t.Kind() == reflect.Pointer {
+		column, err := requiredColumn(t.Elem())
+		if err != nil {
+			return parquetColumn{}, err
+		}
+		return optional(column, func(v reflect.Value) (reflect.Value, bool) {
+			return v.Elem(), !v.IsNil()
+		}), nil
+	}
+	if valueType, ok := nullTypes[t]; ok {
+		column, err := requiredColumn(valueType)
+		if err != nil {
+			return parquetColumn{}, err
+		}
+		// Every sql.Null type holds its value in its first field and
+		// validity in its second.
+		return optional(column, func(v reflect.Value) (reflect.Value, bool) {
+			return v.Field(0), v.Field(1).Bool()
+		}), nil
+	}
+	column, err := requiredColumn(t)
+	if err != nil {
+		return parquetColumn{}, err
+	}
+	column.schema += ", repetitiontype=REQUIRED"
+	return column, nil
+}
+
+// optional makes column nullable, reading the value of a field and whether it
+// is set with unwrap.
+func optional(column parquetColumn, unwrap func(reflect.Value) (reflect.Value, bool)) parquetColumn {
+	value := column.value
+	return parquetColumn{
+		schema: column.schema + ", repetitiontype=OPTIONAL",
+		value: func(v reflect.Value) (any, error) {
+			inner, ok := unwrap(v)
+			if !ok {
+				return nil, nil
+			}
+			return value(inner)
+		},
+	}
+}
+
+// requiredColumn returns the column for a field of type t that cannot be NULL.
+func requiredColumn(t reflect.Type) (parquetColumn, error) {
+	switch {
+	case t == timeType:
+		return parquetColumn{"type=INT64, convertedtype=TIMESTAMP_MICROS", func(v reflect.Value) (any, error) {
+			return v.Interface().(time.Time).UnixMicro(), nil
+		}}, nil
+	case t == bytesType:
+		return parquetColumn{"type=BYTE_ARRAY", func(v reflect.Value) (any, error) {
+			return string(v.Bytes()), nil
+		}}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return parquetColumn{"type=BOOLEAN", func(v reflect.Value) (any, error) {
+			return v.Bool(), nil
+		}}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return parquetColumn{"type=INT32", func(v reflect.Value) (any, error) {
+			return int32(v.Int()), nil
+		}}, nil
+	case reflect.Uint8, reflect.Uint16:
+		return parquetColumn{"type=INT32", func(v reflect.Value) (any, error) {
+			return int32(v.Uint()), nil
+		}}, nil
+	case reflect.Int, reflect.Int64:
+		return parquetColumn{"type=INT64", func(v reflect.Value) (any, error) {
+			return v.Int(), nil
+		}}, nil
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return parquetColumn{"type=INT64", func(v reflect.Value) (any, error) {
+			if v.Uint() > math.MaxInt64 {
+				return nil, fmt.Errorf("value %d overflows INT64", v.Uint())
+			}
+			return int64(v.Uint()), nil
+		}}, nil
+	case reflect.Float32:
+		return parquetColumn{"type=FLOAT", func(v reflect.Value) (any, error) {
+			return float32(v.Float()), nil
+		}}, nil
+	case reflect.Float64:
+		return parquetColumn{"type=DOUBLE", func(v reflect.Value) (any, error) {
+			return v.Float(), nil
+		}}, nil
+	case reflect.String:
+		return parquetColumn{"type=BYTE_ARRAY, convertedtype=UTF8", func(v reflect.Value) (any, error) {
+			return v.String(), nil
+		}}, nil
+	}
+	return parquetColumn{}, fmt.Errorf("unsupported field type %s", t)
+}
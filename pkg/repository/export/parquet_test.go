@@ -0,0 +1,69 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"sqlrepo/pkg/repository"
+)
+
+type exportEntity struct {
+	Id      int64
+	Name    string
+	Score   *float64
+	Level   int8
+	Note    sql.NullString
+	Created time.Time
+}
+
+func TestWriteParquet(t *testing.T) {
+	fields := []repository.Field{{Column: "id", Index: []int{0}}, {Column: "name", Index: []int{1}}, {Column: "score", Index: []int{2}}, {Column: "level", Index: []int{3}}, {Column: "note", Index: []int{4}}, {Column: "created_at", Index: []int{5}}}
+	score := 1.5
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	entities := []*exportEntity{
+		{Id: 1, Name: "a", Score: &score, Level: 3, Note: sql.NullString{String: "x", Valid: true}, Created: created},
+		{Id: 2, Name: "b"},
+	}
+	stream := func(fn func(*exportEntity) error) error {
+		for _, entity := range entities {
+			if err := fn(entity); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeParquet(&out, fields, stream))
+
+	pr, err := reader.NewParquetColumnReader(buffer.NewBufferFileFromBytes(out.Bytes()), 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+	require.Equal(t, int64(2), pr.GetNumRows())
+
+	read := func(column string) []any {
+		values, _, _, err := pr.ReadColumnByPath("parquet_go_root\x01"+column, 2)
+		require.NoError(t, err)
+		return values
+	}
+	require.Equal(t, []any{int64(1), int64(2)}, read("id"))
+	require.Equal(t, []any{"a", "b"}, read("name"))
+	require.Equal(t, []any{1.5, nil}, read("score"))
+	require.Equal(t, []any{int32(3), int32(0)}, read("level"))
+	require.Equal(t, []any{"x", nil}, read("note"))
+	require.Equal(t, []any{created.UnixMicro(), time.Time{}.UnixMicro()}, read("created_at"))
+}
+
+func TestWriteParquet_UnsupportedType(t *testing.T) {
+	type entity struct {
+		Tags []string
+	}
+	err := writeParquet(&bytes.Buffer{}, []repository.Field{{Column: "tags", Index: []int{0}}}, func(func(*entity) error) error { return nil })
+	require.ErrorContains(t, err, "unsupported field type")
+}
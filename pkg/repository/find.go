@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindOptions describes a read for Find. Every field is optional.
+type FindOptions struct {
+	// Where holds the conditions the rows must all match.
+	Where []Condition
+	// OrderBy orders the rows; without it their order is unspecified.
+	OrderBy []OrderClause
+	// Columns restricts the columns read; the other fields of the returned
+	// entities are left zero. Every column is read when it is empty.
+	Columns []string
+	// Pagination limits the rows read to one page. Every matching row is read
+	// when it is nil. Use FindCursorBy, FindPage or FindPageBy to page
+	// through large tables by cursor instead of offset.
+	Pagination *Pagination
+	// Cursor selects the page FindCursorBy reads. Find rejects it, since its
+	// offset results carry no cursors.
+	Cursor *CursorPagination
+	// Count makes Find count every matching row into TotalCount with a second
+	// query.
+	Count bool
+}
+
+// Find returns the entities matching opts. Columns, conditions and ordering
+// are validated against the entity's db tags as for FindBySpec, and the rows
+// are restricted to the repository's scopes. The result's TotalCount is only
+// set when opts.Count is true.
+func (r *entityRepository[E, ID]) Find(opts FindOptions) (*PaginatedResult[E], error) {
+	if opts.Cursor != nil {
+		return nil, fmt.Errorf("cursor pagination needs FindCursorBy")
+	}
+	query, args, where, err := r.findQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaginatedResult[E]{}
+	if opts.Pagination != nil {
+		result.Pagination = *opts.Pagination
+	}
+	if err := r.selectAll(&result.Results, query, args...); err != nil {
+		return nil, err
+	}
+
+	if opts.Count {
+		if err := r.countInto(&result.TotalCount, where); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// countInto counts the rows matching where into total.
+func (r *entityRepository[E, ID]) countInto(total *int, where *whereBuilder) error {
+	return r.getOne(total, fmt.Sprintf("SELECT COUNT(*) FROM %s%s", r.table(), where), where.args...)
+}
+
+// findQuery returns the query Find runs for opts and its arguments, and the
+// WHERE clause it filters by.
+func (r *entityRepository[E, ID]) findQuery(opts FindOptions) (string, []any, *whereBuilder, error) {
+	validColumns := r.validColumns()
+	selectList, err := selectColumns(validColumns, opts.Columns)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	where, err := r.findWhere(validColumns, opts.Where)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	order, err := orderBy(r.options.dialect, validColumns, opts.OrderBy)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s%s", selectList, r.table(), where, order)
+	args := where.args
+	if opts.Pagination != nil {
+		if opts.Pagination.Limit < 0 || opts.Pagination.Offset < 0 {
+			return "", nil, nil, fmt.Errorf("invalid pagination: limit %d, offset %d", opts.Pagination.Limit, opts.Pagination.Offset)
+		}
+		query, args = r.limit(query, args, opts.Pagination.Limit, opts.Pagination.Offset)
+	}
+	return query, args, where, nil
+}
+
+// selectColumns returns the select list reading columns, or every column when
+// it is empty.
+func selectColumns(validColumns map[string]bool, columns []string) (string, error) {
+	if len(columns) == 0 {
+		return "*", nil
+	}
+	for _, column := range columns {
+		if !validColumns[column] {
+			return "", fmt.Errorf("unknown column %q", column)
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// findWhere starts a WHERE clause restricted to the repository's scopes and
+// to conditions.
+func (r *entityRepository[E, ID]) findWhere(validColumns map[string]bool, conditions []Condition) (*whereBuilder, error) {
+	where := r.where()
+	for _, c := range conditions {
+		if err := addCondition(where, validColumns, c); err != nil {
+			return nil, err
+		}
+	}
+	return where, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MissingIDsError lists the requested ids FindAllByIDStrict found no row for,
+// in the order they were requested. It matches ErrNotFound with errors.Is.
+type MissingIDsError[ID comparable] struct {
+	IDs []ID
+}
+
+func (e *MissingIDsError[ID]) Error() string {
+	ids := make([]string, len(e.IDs))
+	for i, id := range e.IDs {
+		ids[i] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("%d entities not found: %s", len(e.IDs), strings.Join(ids, ", "))
+}
+
+func (e *MissingIDsError[ID]) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// FindAllByIDStrict is FindAllByID for callers that expect every id to exist.
+// If any id has no visible row it returns a *MissingIDsError listing them and
+// no entities. An id requested more than once is reported once.
+func (r *entityRepository[E, ID]) FindAllByIDStrict(ids []ID) ([]*E, error) {
+	entities, err := r.FindAllByID(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[ID]bool, len(entities))
+	for _, entity := range entities {
+		found[(*entity).GetID()] = true
+	}
+	var missing []ID
+	for _, id := range ids {
+		if !found[id] {
+			found[id] = true
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingIDsError[ID]{IDs: missing}
+	}
+	return entities, nil
+}
+
+// FindMapByIDs returns the entities with the given ids keyed by id. Ids with
+// no visible row are absent from the map. Long id lists are queried in chunks.
+func (r *entityRepository[E, ID]) FindMapByIDs(ids []ID) (map[ID]*E, error) {
+	entities, err := r.FindAllByIDWhere(ids, nil)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[ID]*E, len(entities))
+	for _, entity := range entities {
+		byID[(*entity).GetID()] = entity
+	}
+	return byID, nil
+}
+
+// FindAllByIDAligned returns one entry per id, in the order of ids, as batch
+// loaders such as GraphQL dataloaders expect: the entity with that id, or nil
+// when no visible row has it, so the result always has len(ids) entries and
+// missing ids are not an error. An id requested more than once yields the
+// same entity pointer at each of its positions.
+func (r *entityRepository[E, ID]) FindAllByIDAligned(ids []ID) ([]*E, error) {
+	byID, err := r.FindMapByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	aligned := make([]*E, len(ids))
+	for i, id := range ids {
+		aligned[i] = byID[id]
+	}
+	return aligned, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingIDsError(t *testing.T) {
+	err := fmt.Errorf("load: %w", &MissingIDsError[int64]{IDs: []int64{3, 7}})
+	require.EqualError(t, err, "load: 2 entities not found: 3, 7")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	var missing *MissingIDsError[int64]
+	require.True(t, errors.As(err, &missing))
+	require.Equal(t, []int64{3, 7}, missing.IDs)
+}
+
+func TestFindAllByIDAligned(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "3")
+	require.NoError(t, err)
+	defer db.Close()
+
+	entities, err := NewEntityRepository[SampleEntity](db).FindAllByIDAligned([]int64{3, 5, 1, 3})
+	require.NoError(t, err)
+	require.Len(t, entities, 4)
+	require.Equal(t, "entity 3", entities[0].Name)
+	require.Nil(t, entities[1])
+	require.Equal(t, "entity 1", entities[2].Name)
+	require.Same(t, entities[0], entities[3])
+
+	entities, err = NewEntityRepository[SampleEntity](db).FindAllByIDAligned(nil)
+	require.NoError(t, err)
+	require.Empty(t, entities)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind_Query(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[OrderEntity](db, WithQueryCapture())
+	_, err = repo.Find(FindOptions{
+		Where:      []Condition{Eq("customer", "alice"), Where("amount", ">", 10)},
+		OrderBy:    []OrderClause{{Column: "amount", Desc: true}},
+		Columns:    []string{"id", "amount"},
+		Pagination: &Pagination{Limit: 20, Offset: 40},
+		Count:      true,
+	})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT id, amount FROM order_entities WHERE customer = ? AND amount > ? ORDER BY amount DESC LIMIT ? OFFSET ?", query)
+	require.Equal(t, []any{"alice", 10, 20, 40}, args)
+
+	for _, opts := range []FindOptions{
+		{Columns: []string{"id", "secret"}},
+		{Where: []Condition{Eq("secret", 1)}},
+		{OrderBy: []OrderClause{{Column: "secret"}}},
+		{Pagination: &Pagination{Limit: -1}},
+	} {
+		_, err := repo.Find(opts)
+		require.Error(t, err)
+	}
+}
+
+func TestFind_Cursor(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "3")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithPageTokenSecret([]byte("secret")), WithQueryCapture())
+	first, err := repo.FindCursorBy(FindOptions{Where: []Condition{Eq("name", "x")}, Cursor: &CursorPagination{Limit: 2}})
+	require.NoError(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE name = ? ORDER BY id ASC LIMIT ?", query)
+	require.Equal(t, []any{"x", 3}, args)
+	require.Len(t, first.Results, 2)
+	require.NotEmpty(t, first.NextCursor)
+	require.Empty(t, first.PrevCursor)
+
+	second, err := repo.FindCursorBy(FindOptions{Where: []Condition{Eq("name", "x")}, Cursor: &CursorPagination{Cursor: first.NextCursor, Limit: 2}})
+	require.NoError(t, err)
+	query, args = repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE name = ? AND id > ? ORDER BY id ASC LIMIT ?", query)
+	require.Equal(t, []any{"x", int64(2), 3}, args)
+	require.NotEmpty(t, second.PrevCursor)
+
+	_, err = repo.FindCursor(first.NextCursor, 2)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	for _, opts := range []FindOptions{
+		{Cursor: &CursorPagination{Limit: 2}, OrderBy: []OrderClause{{Column: "name"}}},
+		{Cursor: &CursorPagination{Limit: 2}, Pagination: &Pagination{Limit: 2}},
+		{Cursor: &CursorPagination{Limit: 2}, Count: true},
+		{Cursor: &CursorPagination{Limit: 2}, Columns: []string{"name"}},
+		{Cursor: &CursorPagination{Limit: 0}},
+		{},
+	} {
+		_, err := repo.FindCursorBy(opts)
+		require.Error(t, err)
+	}
+
+	_, err = repo.Find(FindOptions{Cursor: &CursorPagination{Limit: 2}})
+	require.EqualError(t, err, "cursor pagination needs FindCursorBy")
+}
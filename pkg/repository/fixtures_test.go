@@ -0,0 +1,604 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type TimestampEntity struct {
+	Id        int64     `db:"id,autoincrement"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (e TimestampEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e TimestampEntity) GetTableName() string {
+	return "timestamp_entities"
+}
+
+func (e TimestampEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateTimestampEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS timestamp_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		created_at TIMESTAMP(6) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// ReorderedEntity declares its fields in a different order than the columns of
+// its table.
+type ReorderedEntity struct {
+	Email string `db:"email"`
+	Name  string `db:"name"`
+	Id    int64  `db:"id,autoincrement"`
+}
+
+func (e ReorderedEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e ReorderedEntity) GetTableName() string {
+	return "reordered_entities"
+}
+
+func (e ReorderedEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateReorderedEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS reordered_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		email VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+type SoftDeleteEntity struct {
+	Id        int64      `db:"id,autoincrement"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func (e SoftDeleteEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e SoftDeleteEntity) GetTableName() string {
+	return "soft_delete_entities"
+}
+
+func (e SoftDeleteEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateSoftDeleteEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS soft_delete_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		deleted_at DATETIME(6) NULL
+	)`)
+	require.NoError(t, err)
+}
+
+type OrderEntity struct {
+	Id       int64  `db:"id,autoincrement"`
+	Customer string `db:"customer"`
+	Amount   int64  `db:"amount"`
+}
+
+func (e OrderEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e OrderEntity) GetTableName() string {
+	return "order_entities"
+}
+
+func (e OrderEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateOrderEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS order_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		customer VARCHAR(255) NOT NULL,
+		amount BIGINT NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// UntaggedEntity relies on the name mapper for its untagged fields.
+type UntaggedEntity struct {
+	Id        int64 `db:"id,autoincrement"`
+	FirstName string
+	LastName  string
+}
+
+func (e UntaggedEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e UntaggedEntity) GetTableName() string {
+	return "untagged_entities"
+}
+
+func (e UntaggedEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateUntaggedEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS untagged_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		first_name VARCHAR(255) NOT NULL,
+		last_name VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+type DefaultsEntity struct {
+	Id       int64  `db:"id,autoincrement"`
+	Status   string `db:"status,default=active"`
+	Priority int    `db:"priority,default=3"`
+	Source   string `db:"source"`
+}
+
+func (e DefaultsEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e DefaultsEntity) GetTableName() string {
+	return "defaults_entities"
+}
+
+func (e DefaultsEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e *DefaultsEntity) ApplyDefaults() {
+	if e.Source == "" {
+		e.Source = "api"
+	}
+}
+
+func CreateDefaultsEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS defaults_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		status VARCHAR(255) NOT NULL,
+		priority INT NOT NULL,
+		source VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// CompressedEntity stores Body gzipped through a column transformer.
+type CompressedEntity struct {
+	Id   int64  `db:"id,autoincrement"`
+	Body string `db:"body"`
+}
+
+func (e CompressedEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e CompressedEntity) GetTableName() string {
+	return "compressed_entities"
+}
+
+func (e CompressedEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateCompressedEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS compressed_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		body BLOB NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// gzipTransformer compresses string fields.
+type gzipTransformer struct{}
+
+func (gzipTransformer) Encode(value any) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(w, value.(string)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipTransformer) Decode(data []byte) (any, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(decoded), nil
+}
+
+type tenantKey struct{}
+
+// TenantEntity belongs to a tenant.
+type TenantEntity struct {
+	Id       int64  `db:"id,autoincrement"`
+	TenantID int64  `db:"tenant_id"`
+	Name     string `db:"name"`
+}
+
+func (e TenantEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e TenantEntity) GetTableName() string {
+	return "tenant_entities"
+}
+
+func (e TenantEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+// ScopedTenantEntity is TenantEntity restricted by a read scope to the tenant
+// stored in the context, and to no rows without one.
+type ScopedTenantEntity struct {
+	Id       int64  `db:"id,autoincrement"`
+	TenantID int64  `db:"tenant_id"`
+	Name     string `db:"name"`
+}
+
+func (e ScopedTenantEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e ScopedTenantEntity) GetTableName() string {
+	return "tenant_entities"
+}
+
+func (e ScopedTenantEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e ScopedTenantEntity) ReadScopes(ctx context.Context) []Condition {
+	tenantID, ok := ctx.Value(tenantKey{}).(int64)
+	if !ok {
+		return []Condition{ExprCond("1 = 0")}
+	}
+	return []Condition{Eq("tenant_id", tenantID)}
+}
+
+func CreateTenantEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS tenant_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		tenant_id BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// AccountEntity protects Role with the immutable option and only lets updates
+// write Name.
+type AccountEntity struct {
+	Id    int64  `db:"id,autoincrement"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+	Role  string `db:"role,immutable"`
+}
+
+func (e AccountEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e AccountEntity) GetTableName() string {
+	return "account_entities"
+}
+
+func (e AccountEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e AccountEntity) UpdatableColumns() []string {
+	return []string{"name", "role"}
+}
+
+func CreateAccountEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS account_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		email VARCHAR(255) NOT NULL,
+		role VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// ScoredEntity has a nullable Score to paginate by.
+type ScoredEntity struct {
+	Id    int64  `db:"id,autoincrement"`
+	Score *int64 `db:"score"`
+}
+
+func (e ScoredEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e ScoredEntity) GetTableName() string {
+	return "scored_entities"
+}
+
+func (e ScoredEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateScoredEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS scored_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		score BIGINT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// PlayerEntity carries a readonly Rank that only FindRanked fills in.
+type PlayerEntity struct {
+	Id    int64  `db:"id,autoincrement"`
+	Name  string `db:"name"`
+	Score int64  `db:"score"`
+	Rank  int64  `db:"rank,readonly"`
+}
+
+func (e PlayerEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e PlayerEntity) GetTableName() string {
+	return "player_entities"
+}
+
+func (e PlayerEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreatePlayerEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS player_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		score BIGINT NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// ArticleEntity records when and how often it was updated.
+type ArticleEntity struct {
+	Id        int64     `db:"id,autoincrement"`
+	Title     string    `db:"title"`
+	UpdatedAt time.Time `db:"updated_at"`
+	Version   int64     `db:"version,version"`
+}
+
+func (e ArticleEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e ArticleEntity) GetTableName() string {
+	return "article_entities"
+}
+
+func (e ArticleEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateArticleEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS article_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		title VARCHAR(255) NOT NULL,
+		updated_at DATETIME NOT NULL,
+		version BIGINT NOT NULL DEFAULT 0
+	)`)
+	require.NoError(t, err)
+}
+
+// InventoryEntity mirrors the CHECK constraint of its table in Go.
+type InventoryEntity struct {
+	Id       int64 `db:"id,autoincrement"`
+	Quantity int64 `db:"quantity"`
+}
+
+func (e InventoryEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e InventoryEntity) GetTableName() string {
+	return "inventory_entities"
+}
+
+func (e InventoryEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e InventoryEntity) Checks() []Check {
+	return []Check{{Name: "inventory_quantity_chk", OK: e.Quantity >= 0}}
+}
+
+func CreateInventoryEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS inventory_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		quantity BIGINT NOT NULL,
+		CONSTRAINT inventory_quantity_chk CHECK (quantity >= 0)
+	)`)
+	require.NoError(t, err)
+}
+
+// PostEntity collects the names of its tags from post_tags.
+type PostEntity struct {
+	Id    int64    `db:"id,autoincrement"`
+	Title string   `db:"title"`
+	Tags  []string `db:"tags,readonly"`
+}
+
+func (e PostEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e PostEntity) GetTableName() string {
+	return "post_entities"
+}
+
+func (e PostEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreatePostEntityTables(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS post_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		title VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS post_tags (
+		post_id BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// EventEntity is stored as a JSON document next to its id.
+type EventEntity struct {
+	Id      int64  `db:"id,autoincrement" json:"-"`
+	Kind    string `db:"-" json:"kind"`
+	Payload string `db:"-" json:"payload"`
+}
+
+func (e EventEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e EventEntity) GetTableName() string {
+	return "event_entities"
+}
+
+func (e EventEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e *EventEntity) MarshalDocument() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (e *EventEntity) UnmarshalDocument(data []byte) error {
+	return json.Unmarshal(data, e)
+}
+
+func CreateEventEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS event_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		document JSON NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// DocEntity is searched through a FULLTEXT index on title and body.
+type DocEntity struct {
+	Id    int64   `db:"id,autoincrement"`
+	Title string  `db:"title"`
+	Body  string  `db:"body"`
+	Score float64 `db:"score,readonly,relevance"`
+}
+
+func (e DocEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e DocEntity) GetTableName() string {
+	return "doc_entities"
+}
+
+func (e DocEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateDocEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS doc_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		title VARCHAR(255) NOT NULL,
+		body TEXT NOT NULL,
+		FULLTEXT (title, body)
+	) ENGINE=InnoDB`)
+	require.NoError(t, err)
+}
+
+func CreateSequenceTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS sqlrepo_sequences (
+		name VARCHAR(64) NOT NULL PRIMARY KEY,
+		last_id BIGINT NOT NULL
+	)`)
+	require.NoError(t, err)
+}
+
+// OrderTotalsEntity reads order_entities with readonly totals that only
+// FindWithWindows fills in.
+type OrderTotalsEntity struct {
+	Id            int64  `db:"id,autoincrement"`
+	Customer      string `db:"customer"`
+	Amount        int64  `db:"amount"`
+	RunningTotal  int64  `db:"running_total,readonly"`
+	CustomerTotal int64  `db:"customer_total,readonly"`
+}
+
+func (e OrderTotalsEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e OrderTotalsEntity) GetTableName() string {
+	return "order_entities"
+}
+
+func (e OrderTotalsEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+// ChildEntity references a SampleEntity through ParentID.
+type ChildEntity struct {
+	Id        int64      `db:"id,autoincrement"`
+	ParentID  *int64     `db:"parent_id"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+func (e ChildEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e ChildEntity) GetTableName() string {
+	return "child_entities"
+}
+
+func (e ChildEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func CreateChildEntityTable(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS child_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		parent_id BIGINT NULL,
+		name VARCHAR(255) NOT NULL,
+		deleted_at DATETIME(6) NULL
+	)`)
+	require.NoError(t, err)
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// ScopedEntity is implemented by entities whose reads are always filtered,
+// for example to the current tenant or to rows that are not archived. The
+// conditions returned by ReadScopes are added to every query the repository
+// filters with a WHERE clause, reads as well as updates, unless the
+// repository is viewed through WithoutGlobalScopes. ctx is the repository's
+// context, see WithContext, so values such as the tenant can come from the
+// request.
+//
+// The conditions are entity code, not input: a condition on an unknown column
+// or with an unsupported operator is a programming error and makes every
+// query of the repository fail. ReadScopes should fail closed, e.g. return
+// ExprCond("1 = 0") when ctx lacks the value it filters by, since returning no
+// conditions lets the repository read every row.
+type ScopedEntity interface {
+	ReadScopes(ctx context.Context) []Condition
+}
+
+// globalScopes returns the read scopes E declares for the repository's
+// context.
+func (r *entityRepository[E, ID]) globalScopes() []Condition {
+	if r.unscoped {
+		return nil
+	}
+	scoped, ok := any(new(E)).(ScopedEntity)
+	if !ok {
+		return nil
+	}
+	return scoped.ReadScopes(r.ctx)
+}
+
+// addGlobalScopes adds the read scopes of E to w and fails if one of them is
+// invalid. Queries check the scopes before they run, see ready, so callers
+// building a WHERE clause may ignore the error.
+func (r *entityRepository[E, ID]) addGlobalScopes(w *whereBuilder) error {
+	scopes := r.globalScopes()
+	if len(scopes) == 0 {
+		return nil
+	}
+	validColumns := r.validColumns()
+	for _, c := range scopes {
+		if err := addCondition(w, validColumns, c); err != nil {
+			var emptyEntity E
+			return fmt.Errorf("invalid read scope of %T: %w", emptyEntity, err)
+		}
+	}
+	return nil
+}
+
+// WithoutGlobalScopes returns a view of the repository that ignores the read
+// scopes declared by the entity.
+func (r *entityRepository[E, ID]) WithoutGlobalScopes() Repository[E, ID] {
+	view := *r
+	view.unscoped = true
+	return &view
+}
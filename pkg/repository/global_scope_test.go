@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalScopes(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, int64(7))
+	repo := NewEntityRepository[ScopedTenantEntity](db, WithQueryCapture()).WithContext(ctx)
+
+	_, err = repo.FindAllByIDWhere([]int64{1}, map[string]any{"name": "test"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM tenant_entities WHERE tenant_id = ? AND id IN (?) AND name = ?", query)
+	require.Equal(t, []any{int64(7), int64(1), "test"}, args)
+
+	_, err = repo.WithoutGlobalScopes().FindAll()
+	require.Error(t, err)
+	query, _ = repo.LastQuery()
+	require.Equal(t, "SELECT * FROM tenant_entities", query)
+
+	noTenant := NewEntityRepository[ScopedTenantEntity](db, WithQueryCapture())
+	_, err = noTenant.FindAll()
+	require.Error(t, err)
+	query, _ = noTenant.LastQuery()
+	require.Equal(t, "SELECT * FROM tenant_entities WHERE (1 = 0)", query)
+}
+
+// InvalidScopeEntity declares a read scope on a column it does not map.
+type InvalidScopeEntity struct {
+	Id int64 `db:"id"`
+}
+
+func (e InvalidScopeEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e InvalidScopeEntity) GetTableName() string {
+	return "sample_entities"
+}
+
+func (e InvalidScopeEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func (e InvalidScopeEntity) ReadScopes(context.Context) []Condition {
+	return []Condition{Eq("unknown", 1)}
+}
+
+func TestGlobalScopes_Invalid(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "1")
+	require.NoError(t, err)
+	defer db.Close()
+
+	repo := NewEntityRepository[InvalidScopeEntity](db)
+	_, err = repo.FindAll()
+	require.EqualError(t, err, `invalid read scope of repository.InvalidScopeEntity: unknown column "unknown"`)
+	require.ErrorContains(t, repo.DeleteByIDs([]int64{1}), "invalid read scope")
+
+	require.NoError(t, repo.WithoutGlobalScopes().DeleteByIDs([]int64{1}))
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// FindAllGroupedBy returns the entities matching conditions grouped by the
+// value of column, read in a single query. Group values are formatted with
+// fmt.Sprint, those of a driver.Valuer field after converting them with
+// Value. Entities whose field is NULL, a nil pointer or a Valuer returning
+// nil, are returned in nulls. Each group keeps the order given by order; use
+// FindAllGroupedByTyped to keep the group values' type.
+func (r *entityRepository[E, ID]) FindAllGroupedBy(column string, conditions map[string]any, order ...OrderClause) (groups map[string][]*E, nulls []*E, err error) {
+	c, entities, err := r.findForGrouping(column, conditions, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups = make(map[string][]*E)
+	for _, entity := range entities {
+		value := reflect.ValueOf(entity).Elem().FieldByIndex(c.Index).Interface()
+		if isNullValue(value) {
+			nulls = append(nulls, entity)
+			continue
+		}
+		if valuer, ok := value.(driver.Valuer); ok {
+			if value, err = valuer.Value(); err != nil {
+				return nil, nil, err
+			}
+			if data, ok := value.([]byte); ok {
+				value = string(data)
+			}
+		}
+		if field := reflect.ValueOf(value); field.Kind() == reflect.Pointer {
+			value = field.Elem().Interface()
+		}
+		key := fmt.Sprint(value)
+		groups[key] = append(groups[key], entity)
+	}
+	return groups, nulls, nil
+}
+
+// FindAllGroupedByTyped is FindAllGroupedBy keeping the group values' type:
+// the field mapped to column must be of type K or *K. Entities whose field is
+// a nil pointer are returned in nulls.
+func FindAllGroupedByTyped[K comparable, E Entity[ID], ID comparable](repo Repository[E, ID], column string, conditions map[string]any, order ...OrderClause) (groups map[K][]*E, nulls []*E, err error) {
+	r, ok := repo.(*entityRepository[E, ID])
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported repository implementation %T", repo)
+	}
+	var emptyEntity E
+	var emptyKey K
+	keyType := reflect.TypeOf(&emptyKey).Elem()
+	if c, ok := r.column(column); ok && r.validColumns()[column] {
+		fieldType := reflect.TypeOf(emptyEntity).FieldByIndex(c.Index).Type
+		if fieldType != keyType && fieldType != reflect.PointerTo(keyType) {
+			return nil, nil, fmt.Errorf("column %q is mapped to %s, not %s", column, fieldType, keyType)
+		}
+	}
+
+	c, entities, err := r.findForGrouping(column, conditions, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups = make(map[K][]*E)
+	for _, entity := range entities {
+		field := reflect.ValueOf(entity).Elem().FieldByIndex(c.Index)
+		if field.Kind() == reflect.Pointer && field.Type() != keyType {
+			if field.IsNil() {
+				nulls = append(nulls, entity)
+				continue
+			}
+			field = field.Elem()
+		}
+		key := field.Interface().(K)
+		groups[key] = append(groups[key], entity)
+	}
+	return groups, nulls, nil
+}
+
+// findForGrouping validates column and returns it with the entities matching
+// conditions in order.
+func (r *entityRepository[E, ID]) findForGrouping(column string, conditions map[string]any, order []OrderClause) (column, []*E, error) {
+	c, ok := r.column(column)
+	if !ok || !r.validColumns()[column] {
+		return c, nil, fmt.Errorf("unknown column %q", column)
+	}
+
+	names := make([]string, 0, len(conditions))
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	where := make([]Condition, len(names))
+	for i, name := range names {
+		where[i] = Eq(name, conditions[name])
+	}
+
+	result, err := r.Find(FindOptions{Where: where, OrderBy: order})
+	if err != nil {
+		return c, nil, err
+	}
+	return c, result.Results, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAllGroupedBy(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "3")
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewEntityRepository[SampleEntity](db)
+
+	groups, nulls, err := repo.FindAllGroupedBy("name", nil)
+	require.NoError(t, err)
+	require.Len(t, groups, 3)
+	require.Empty(t, nulls)
+	require.Equal(t, int64(2), groups["entity 2"][0].Id)
+
+	typed, typedNulls, err := FindAllGroupedByTyped[int64](repo, "id", nil)
+	require.NoError(t, err)
+	require.Empty(t, typedNulls)
+	require.Equal(t, "entity 3", typed[3][0].Name)
+
+	_, _, err = FindAllGroupedByTyped[string](repo, "id", nil)
+	require.Error(t, err)
+	_, _, err = repo.FindAllGroupedBy("unknown", nil)
+	require.Error(t, err)
+}
+
+// NullableNameEntity reads the names of sample_entities into nullable fields.
+type NullableNameEntity struct {
+	Id   int64          `db:"id"`
+	Name sql.NullString `db:"name"`
+}
+
+func (e NullableNameEntity) GetID() int64 {
+	return e.Id
+}
+
+func (e NullableNameEntity) GetTableName() string {
+	return "sample_entities"
+}
+
+func (e NullableNameEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func TestFindAllGroupedBy_Nulls(t *testing.T) {
+	connector := &recordingConnector{
+		columns: []string{"id", "name"},
+		rows: [][]driver.Value{
+			{int64(1), []byte("")},
+			{int64(2), nil},
+			{int64(3), []byte("alice")},
+		},
+	}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	groups, nulls, err := NewEntityRepository[NullableNameEntity](db).FindAllGroupedBy("name", nil)
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	require.Equal(t, int64(1), groups[""][0].Id)
+	require.Equal(t, int64(3), groups["alice"][0].Id)
+	require.Len(t, nulls, 1)
+	require.Equal(t, int64(2), nulls[0].Id)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// IngestStream saves the entities received from src in batches of batchSize
+// with SaveAll, the write-side counterpart of Stream, and returns once src is
+// closed and the last, possibly shorter, batch is saved.
+//
+// When ctx is done IngestStream stops receiving, saves the entities already
+// received and returns ctx's error, joined with the error of that last save,
+// if any. The saves themselves run under the repository's context, see
+// WithContext, so cancelling ctx does not abort a batch halfway. The first
+// failing batch stops the ingest and its error is returned; the entities
+// still in src are left for the caller to drain or discard.
+func (r *entityRepository[E, ID]) IngestStream(ctx context.Context, src <-chan *E, batchSize int) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", batchSize)
+	}
+
+	batch := make([]*E, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := r.SaveAll(batch)
+		batch = make([]*E, 0, batchSize)
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), flush())
+		case entity, ok := <-src:
+			if !ok {
+				return flush()
+			}
+			batch = append(batch, entity)
+			if len(batch) == batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
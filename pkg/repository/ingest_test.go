@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestStream(t *testing.T) {
+	connector := &recordingConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	repo := NewEntityRepository[OrderEntity](db)
+
+	src := make(chan *OrderEntity)
+	go func() {
+		for i := 1; i <= 5; i++ {
+			src <- &OrderEntity{Id: int64(i), Customer: "alice"}
+		}
+		close(src)
+	}()
+	require.NoError(t, repo.IngestStream(context.Background(), src, 2))
+	require.Len(t, connector.executed, 3)
+	require.Len(t, connector.args, 15)
+	require.Equal(t, driver.Value(int64(5)), connector.args[12])
+}
+
+func TestIngestStream_Cancel(t *testing.T) {
+	connector := &recordingConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	repo := NewEntityRepository[OrderEntity](db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	src := make(chan *OrderEntity)
+	go func() {
+		src <- &OrderEntity{Id: 1, Customer: "alice"}
+		cancel()
+	}()
+	err := repo.IngestStream(ctx, src, 10)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, connector.executed, 1)
+
+	require.Error(t, repo.IngestStream(context.Background(), src, 0))
+}
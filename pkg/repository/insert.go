@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type insertStatement struct {
+	query           string
+	args            []interface{}
+	columns         []column
+	idField         column
+	idAutoIncrement bool
+}
+
+// buildInsert builds a multi-row INSERT for entities. An auto-incremented id
+// column is left out unless includeID is set, in which case it is bound like
+// any other column and a zero id still makes MySQL assign one.
+func (r *entityRepository[E, ID]) buildInsert(entities []*E, includeID bool) (*insertStatement, error) {
+	var columnNames []string
+	var placeholders []string
+	insert := &insertStatement{}
+
+	firstEntity := entities[0]
+
+	// Ensure entity implements Entity interface
+	entityInterface, ok := any(firstEntity).(Entity[ID])
+	if !ok {
+		return nil, fmt.Errorf("entity does not implement the Entity interface")
+	}
+
+	for _, entity := range entities {
+		if err := r.applyDefaults(entity); err != nil {
+			return nil, err
+		}
+		if err := r.stampTenant(entity); err != nil {
+			return nil, err
+		}
+		if err := verifyChecks(entity); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range r.columns() {
+		if c.has("readonly") {
+			continue
+		}
+		if c.Name == "id" {
+			insert.idAutoIncrement = c.has("autoincrement") && !includeID
+			insert.idField = c
+
+			if insert.idAutoIncrement {
+				continue
+			}
+		}
+		insert.columns = append(insert.columns, c)
+		columnNames = append(columnNames, c.Name)
+		placeholders = append(placeholders, ":"+c.Name)
+	}
+
+	// Bind values by column name so field order never has to match the
+	// placeholder order
+	rows := make([]map[string]interface{}, len(entities))
+	for i, entity := range entities {
+		entityValue := reflect.ValueOf(entity).Elem()
+		row := make(map[string]interface{}, len(insert.columns))
+		for _, c := range insert.columns {
+			value, err := r.columnValue(entityValue, c)
+			if err != nil {
+				return nil, err
+			}
+			row[c.Name] = value
+		}
+		rows[i] = row
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", entityInterface.GetTableName(), strings.Join(columnNames, ","), strings.Join(placeholders, ","))
+	query, args, err := r.DB.BindNamed(query, rows)
+	if err != nil {
+		return nil, err
+	}
+	insert.query = query
+	insert.args = args
+	return insert, nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionByID(t *testing.T) {
+	repo := &entityRepository[SampleEntity, int64]{options: newOptions(nil)}
+	first, second, third := &SampleEntity{Id: 7}, &SampleEntity{}, &SampleEntity{Id: 9}
+
+	explicit, generated := repo.partitionByID([]*SampleEntity{first, second, third})
+	require.Equal(t, []*SampleEntity{first, third}, explicit)
+	require.Equal(t, []*SampleEntity{second}, generated)
+
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// NullsOrder places NULLs of a keyset column before or after the other values.
+type NullsOrder int
+
+const (
+	// NullsFirst returns rows whose column is NULL before the others.
+	NullsFirst NullsOrder = iota
+	// NullsLast returns rows whose column is NULL after the others.
+	NullsLast
+)
+
+// KeysetOrder orders a keyset-paginated read by Column, which may be nullable,
+// with NULLs placed according to Nulls. Rows are further ordered by id in the
+// same direction: keyset pagination needs a unique, non-null tiebreaker to
+// resume exactly after the last row of a page, and id is always one.
+type KeysetOrder struct {
+	Column string
+	Desc   bool
+	Nulls  NullsOrder
+}
+
+// keysetKey is the position a FindPageBy token resumes after.
+type keysetKey struct {
+	Column string          `json:"c"`
+	Value  json.RawMessage `json:"v"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// FindPageBy is FindPage ordered by order instead of by id alone. Pages never
+// skip or repeat rows around NULLs: rows with a NULL column are paged as a
+// separate run, before or after the others, ordered by id, and the NULLs are
+// placed by the dialect's NULLS FIRST or NULLS LAST where it has them. A token
+// is only valid with the order it was issued for.
+func (r *entityRepository[E, ID]) FindPageBy(order KeysetOrder, token string, limit int) (*PaginatedResult[E], string, error) {
+	if len(r.options.pageTokenSecret) == 0 {
+		return nil, "", fmt.Errorf("page token secret is not configured")
+	}
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("limit must be positive")
+	}
+	sortColumn, ok := r.column(order.Column)
+	if !ok || sortColumn.has("readonly") {
+		return nil, "", fmt.Errorf("unknown column %q", order.Column)
+	}
+
+	tableName := r.table()
+
+	where := r.where()
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", tableName, where)
+	countArgs := where.args
+	if token != "" {
+		if err := r.addKeysetCondition(where, order, sortColumn, token); err != nil {
+			return nil, "", err
+		}
+	}
+
+	direction := "ASC"
+	if order.Desc {
+		direction = "DESC"
+	}
+	orderClause := fmt.Sprintf(" ORDER BY %s, id %s", r.orderNulls(order.Column, order.Desc, order.Nulls), direction)
+
+	var entities []*E
+	query, args := r.limit(fmt.Sprintf("SELECT * FROM %s%s%s", tableName, where, orderClause), where.args, limit+1, 0)
+	if err := r.selectAll(&entities, query, args...); err != nil {
+		return nil, "", err
+	}
+
+	var nextToken string
+	if len(entities) > limit {
+		entities = entities[:limit]
+		last := reflect.ValueOf(entities[limit-1]).Elem()
+		value, err := json.Marshal(last.FieldByIndex(sortColumn.Index).Interface())
+		if err != nil {
+			return nil, "", err
+		}
+		id, err := json.Marshal((*entities[limit-1]).GetID())
+		if err != nil {
+			return nil, "", err
+		}
+		key, err := json.Marshal(keysetKey{Column: order.Column, Value: value, ID: id})
+		if err != nil {
+			return nil, "", err
+		}
+		nextToken, err = encodePageToken(r.options.pageTokenSecret, pageToken{Kind: keysetTokenKind, Key: key, Direction: sortAscending})
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	var totalCount int
+	if err := r.getOne(&totalCount, countQuery, countArgs...); err != nil {
+		return nil, "", err
+	}
+
+	return &PaginatedResult[E]{
+		Pagination: Pagination{Limit: limit},
+		TotalCount: totalCount,
+		Results:    entities,
+	}, nextToken, nil
+}
+
+// addKeysetCondition restricts w to the rows after the position in token.
+func (r *entityRepository[E, ID]) addKeysetCondition(w *whereBuilder, order KeysetOrder, sortColumn column, token string) error {
+	pt, err := decodePageToken(r.options.pageTokenSecret, token, keysetTokenKind)
+	if err != nil {
+		return err
+	}
+	var key keysetKey
+	if err := json.Unmarshal(pt.Key, &key); err != nil || key.Column != order.Column {
+		return ErrInvalidPageToken
+	}
+	var lastID ID
+	if err := json.Unmarshal(key.ID, &lastID); err != nil {
+		return ErrInvalidPageToken
+	}
+	var emptyEntity E
+	lastValue := reflect.New(reflect.TypeOf(emptyEntity).FieldByIndex(sortColumn.Index).Type)
+	if err := json.Unmarshal(key.Value, lastValue.Interface()); err != nil {
+		return ErrInvalidPageToken
+	}
+
+	condition, args := keysetCondition(order, lastValue.Elem().Interface(), lastID)
+	w.add(condition, args...)
+	return nil
+}
+
+// keysetCondition returns the condition matching the rows after (value, id) in
+// order.
+func keysetCondition(order KeysetOrder, value, id any) (string, []any) {
+	column := order.Column
+	comparison := ">"
+	if order.Desc {
+		comparison = "<"
+	}
+
+	if isNullValue(value) {
+		if order.Nulls == NullsFirst {
+			return fmt.Sprintf("((%s IS NULL AND id %s ?) OR %s IS NOT NULL)", column, comparison, column), []any{id}
+		}
+		return fmt.Sprintf("(%s IS NULL AND id %s ?)", column, comparison), []any{id}
+	}
+
+	value = bindValue(value)
+	after := fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, comparison, column, comparison)
+	if order.Nulls == NullsFirst {
+		return fmt.Sprintf("(%s IS NOT NULL AND %s)", column, after), []any{value, value, id}
+	}
+	return fmt.Sprintf("(%s IS NULL OR %s)", column, after), []any{value, value, id}
+}
+
+// isNullValue reports whether v is bound as NULL.
+func isNullValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return err == nil && value == nil
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// column returns the field of E mapped to the column named name. The document
+// column has no field of its own and is never returned.
+func (r *entityRepository[E, ID]) column(name string) (column, bool) {
+	for _, c := range r.columns() {
+		if c.Name == name && c.Index != nil {
+			return c, true
+		}
+	}
+	return column{}, false
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	var null *int64
+	score := int64(5)
+
+	condition, args := keysetCondition(KeysetOrder{Column: "score"}, null, 3)
+	require.Equal(t, "((score IS NULL AND id > ?) OR score IS NOT NULL)", condition)
+	require.Equal(t, []any{3}, args)
+
+	condition, args = keysetCondition(KeysetOrder{Column: "score", Nulls: NullsLast}, null, 3)
+	require.Equal(t, "(score IS NULL AND id > ?)", condition)
+	require.Equal(t, []any{3}, args)
+
+	condition, args = keysetCondition(KeysetOrder{Column: "score"}, &score, 3)
+	require.Equal(t, "(score IS NOT NULL AND (score > ? OR (score = ? AND id > ?)))", condition)
+	require.Equal(t, []any{&score, &score, 3}, args)
+
+	condition, _ = keysetCondition(KeysetOrder{Column: "score", Desc: true, Nulls: NullsLast}, &score, 3)
+	require.Equal(t, "(score IS NULL OR (score < ? OR (score = ? AND id < ?)))", condition)
+
+	condition, _ = keysetCondition(KeysetOrder{Column: "name"}, sql.NullString{}, 3)
+	require.Equal(t, "((name IS NULL AND id > ?) OR name IS NOT NULL)", condition)
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrLazyContextDone is returned by Lazy.Get when the value has not been
+// loaded yet and the context it was created with is done, typically because
+// the request it belongs to has finished.
+var ErrLazyContextDone = errors.New("lazy value context done")
+
+// Lazy defers loading a value, such as a heavy related entity, until Get is
+// first called. It is bound to the context it is created with: a Lazy that
+// outlives its request cannot load anymore, so it cannot be used to query on
+// behalf of a request that has already finished.
+//
+// Lazy is safe for concurrent use. A successful load is kept and returned by
+// every later Get; a failed one is not, so Get may be retried.
+type Lazy[T any] struct {
+	ctx  context.Context
+	load func(ctx context.Context) (T, error)
+
+	mu     sync.Mutex
+	loaded bool
+	value  T
+}
+
+// NewLazy returns a Lazy calling load with ctx on the first Get.
+func NewLazy[T any](ctx context.Context, load func(ctx context.Context) (T, error)) *Lazy[T] {
+	return &Lazy[T]{ctx: ctx, load: load}
+}
+
+// LazyByID returns a Lazy loading the entity with id from repo on the first
+// Get, e.g. to attach an invoice's PDF only for the screens showing it:
+//
+//	view.Document = LazyByID(ctx, documents, invoice.DocumentID)
+func LazyByID[E Entity[ID], ID comparable](ctx context.Context, repo Repository[E, ID], id ID) *Lazy[*E] {
+	return NewLazy(ctx, func(ctx context.Context) (*E, error) {
+		return repo.WithContext(ctx).FindByID(id)
+	})
+}
+
+// Get returns the value, loading it on the first call.
+func (l *Lazy[T]) Get() (T, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.loaded {
+		return l.value, nil
+	}
+	var zero T
+	if err := l.ctx.Err(); err != nil {
+		return zero, fmt.Errorf("%w: %v", ErrLazyContextDone, err)
+	}
+	value, err := l.load(l.ctx)
+	if err != nil {
+		return zero, err
+	}
+	l.value, l.loaded = value, true
+	return value, nil
+}
+
+// Loaded reports whether the value has been loaded.
+func (l *Lazy[T]) Loaded() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.loaded
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	fail := true
+	lazy := NewLazy(ctx, func(context.Context) (string, error) {
+		calls++
+		if fail {
+			return "", errors.New("boom")
+		}
+		return "blob", nil
+	})
+	require.False(t, lazy.Loaded())
+	require.Zero(t, calls)
+
+	_, err := lazy.Get()
+	require.Error(t, err)
+	require.False(t, lazy.Loaded())
+
+	fail = false
+	value, err := lazy.Get()
+	require.NoError(t, err)
+	require.Equal(t, "blob", value)
+	require.True(t, lazy.Loaded())
+
+	cancel()
+	value, err = lazy.Get()
+	require.NoError(t, err)
+	require.Equal(t, "blob", value)
+	require.Equal(t, 2, calls)
+}
+
+func TestLazy_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	lazy := NewLazy(ctx, func(context.Context) (int, error) {
+		t.Fatal("load called after the context was done")
+		return 0, nil
+	})
+	_, err := lazy.Get()
+	require.ErrorIs(t, err, ErrLazyContextDone)
+	require.False(t, lazy.Loaded())
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+)
+
+// ListJoin describes the values of a related table to aggregate into a
+// []string field of an entity, e.g. the tag names of every post:
+//
+//	ListJoin{Field: "tags", Table: "post_tags", ForeignKey: "post_id", Column: "name"}
+//
+// for a post entity with a Tags []string `db:"tags,readonly"` field.
+type ListJoin struct {
+	// Field is the readonly []string column of the entity to fill.
+	Field string
+	// Table is the related table and ForeignKey its column holding the id
+	// of the entity a row belongs to.
+	Table      string
+	ForeignKey string
+	// Column is the column of Table whose values are collected.
+	Column string
+	// Separator joins the values in the aggregate and must not occur in
+	// them. It defaults to a comma and cannot contain quotes or backslashes.
+	Separator string
+}
+
+// FindAllWithList returns the entities matching conditions with the values of
+// list collected into list.Field, in ascending order, in the same query: the
+// related rows are aggregated by a correlated subquery using the dialect's
+// string aggregation, GROUP_CONCAT on MySQL. Entities without related rows get
+// a nil list.
+//
+// MySQL cuts GROUP_CONCAT results off at group_concat_max_len bytes, 1024 by
+// default, without an error, so the last values of long lists are silently
+// lost or truncated unless the session variable is raised, e.g. with
+// SessionVarsConnector.
+func (r *entityRepository[E, ID]) FindAllWithList(list ListJoin, conditions map[string]any) ([]*E, error) {
+	aggregator, ok := r.options.dialect.(StringAggregator)
+	if !ok {
+		return nil, ErrStringAggUnsupported
+	}
+	field, ok := r.column(list.Field)
+	var emptyEntity E
+	if !ok || !field.has("readonly") || reflect.TypeOf(emptyEntity).FieldByIndex(field.Index).Type != reflect.TypeOf([]string(nil)) {
+		return nil, fmt.Errorf("list field %q is not a readonly []string column", list.Field)
+	}
+	for _, name := range []string{list.Table, list.ForeignKey, list.Column} {
+		if !identifierPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid identifier %q", name)
+		}
+	}
+	separator := list.Separator
+	if separator == "" {
+		separator = ","
+	}
+	if strings.ContainsAny(separator, `'"\`) {
+		return nil, fmt.Errorf("invalid list separator %q", separator)
+	}
+
+	tableName := emptyEntity.GetTableName()
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+	aggregate := aggregator.StringAgg("list_rows."+list.Column, separator)
+	query := fmt.Sprintf("SELECT %s.*, (SELECT %s FROM %s list_rows WHERE list_rows.%s = %s.id) AS %s FROM %s%s",
+		tableName, aggregate, list.Table, list.ForeignKey, tableName, r.quoteIdentifier(list.Field), r.table(), where)
+
+	listed := *r
+	listed.options.transformers = maps.Clone(r.options.transformers)
+	if listed.options.transformers == nil {
+		listed.options.transformers = make(map[string]ColumnTransformer)
+	}
+	listed.options.transformers[list.Field] = listTransformer{separator: separator}
+
+	var entities []*E
+	if err := listed.selectAll(&entities, query, where.args...); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// listTransformer splits an aggregated list into a []string.
+type listTransformer struct {
+	separator string
+}
+
+func (t listTransformer) Decode(data []byte) (any, error) {
+	return strings.Split(string(data), t.separator), nil
+}
+
+func (listTransformer) Encode(any) ([]byte, error) {
+	return nil, errors.New("aggregated lists are read-only")
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindAllWithList(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[PostEntity](db, WithQueryCapture())
+	tags := ListJoin{Field: "tags", Table: "post_tags", ForeignKey: "post_id", Column: "name", Separator: "|"}
+	_, err = repo.FindAllWithList(tags, map[string]any{"title": "news"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT post_entities.*, (SELECT GROUP_CONCAT(list_rows.name ORDER BY list_rows.name SEPARATOR '|') FROM post_tags list_rows WHERE list_rows.post_id = post_entities.id) AS `tags` FROM post_entities WHERE title = ?", query)
+	require.Equal(t, []any{"news"}, args)
+
+	_, err = repo.FindAllWithList(ListJoin{Field: "title", Table: "post_tags", ForeignKey: "post_id", Column: "name"}, nil)
+	require.ErrorContains(t, err, "not a readonly []string column")
+	tags.Separator = "'"
+	_, err = repo.FindAllWithList(tags, nil)
+	require.ErrorContains(t, err, "invalid list separator")
+
+	decoded, err := listTransformer{separator: "|"}.Decode([]byte("go|sql"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"go", "sql"}, decoded)
+}
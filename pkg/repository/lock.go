@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrLockNotAvailable = errors.New("lock not available")
+
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrLockNoWait      = 3572
+)
+
+type LockOption func(*lockOptions)
+
+type lockOptions struct {
+	timeout time.Duration
+	noWait  bool
+	of      []string
+}
+
+// LockTimeout bounds how long a locking read waits for a row lock held by
+// another transaction. MySQL only supports whole seconds, so the duration is
+// rounded up.
+func LockTimeout(d time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.timeout = d
+	}
+}
+
+// NoWait makes a locking read fail immediately with ErrLockNotAvailable
+// instead of waiting for a row lock (MySQL 8.0+).
+func NoWait() LockOption {
+	return func(o *lockOptions) {
+		o.noWait = true
+	}
+}
+
+// LockOf restricts the lock to rows of the given tables with
+// FOR UPDATE OF (MySQL 8.0+, PostgreSQL), so that tables a locking read only
+// joins for filtering are not locked too. The repository's own locking reads
+// select from the entity's table alone, which is therefore the only table they
+// accept.
+func LockOf(tables ...string) LockOption {
+	return func(o *lockOptions) {
+		o.of = append(o.of, tables...)
+	}
+}
+
+func newLockOptions(opts []LockOption) lockOptions {
+	var o lockOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func (o lockOptions) clause() string {
+	clause := "FOR UPDATE"
+	if len(o.of) > 0 {
+		clause += " OF " + strings.Join(o.of, ", ")
+	}
+	if o.noWait {
+		clause += " NOWAIT"
+	}
+	return clause
+}
+
+// validate checks that the tables of LockOf are among tables, the tables the
+// locking read selects from.
+func (o lockOptions) validate(tables ...string) error {
+	for _, table := range o.of {
+		if !identifierPattern.MatchString(table) || !slices.Contains(tables, table) {
+			return fmt.Errorf("cannot lock rows of table %q", table)
+		}
+	}
+	return nil
+}
+
+func (r *entityRepository[E, ID]) FindByIDForUpdate(id ID, opts ...LockOption) (*E, error) {
+	if r.tx == nil {
+		return nil, fmt.Errorf("locking reads require a transaction")
+	}
+	lockOpts := newLockOptions(opts)
+	var emptyEntity E
+	if err := lockOpts.validate(emptyEntity.GetTableName()); err != nil {
+		return nil, err
+	}
+
+	if lockOpts.timeout > 0 && !lockOpts.noWait {
+		restore, err := r.setLockWaitTimeout(lockOpts.timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+
+	tableName := r.table()
+
+	var entities []*E
+	where := r.where()
+	where.add("id = ?", id)
+	query := fmt.Sprintf("SELECT * FROM %s%s %s", tableName, where, lockOpts.clause())
+	err := r.selectAll(&entities, query, where.args...)
+	if err != nil {
+		return nil, lockError(err)
+	}
+
+	if len(entities) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return entities[0], nil
+}
+
+func (r *entityRepository[E, ID]) FindByIDForUpdateNoWait(id ID) (*E, error) {
+	return r.FindByIDForUpdate(id, NoWait())
+}
+
+// setLockWaitTimeout changes innodb_lock_wait_timeout for the connection of
+// the current transaction and returns a function restoring the old value, so
+// the setting does not leak into the connection pool.
+func (r *entityRepository[E, ID]) setLockWaitTimeout(timeout time.Duration) (func(), error) {
+	var previous int
+	if err := r.tx.GetContext(r.ctx, &previous, "SELECT @@SESSION.innodb_lock_wait_timeout"); err != nil {
+		return nil, err
+	}
+	seconds := int(math.Ceil(timeout.Seconds()))
+	if _, err := r.tx.ExecContext(r.ctx, "SET SESSION innodb_lock_wait_timeout = ?", seconds); err != nil {
+		return nil, err
+	}
+	return func() {
+		_, _ = r.tx.Exec("SET SESSION innodb_lock_wait_timeout = ?", previous)
+	}, nil
+}
+
+// lockError translates MySQL lock failures into ErrLockNotAvailable.
+func lockError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && (mysqlErr.Number == mysqlErrLockWaitTimeout || mysqlErr.Number == mysqlErrLockNoWait) {
+		return fmt.Errorf("%w: %v", ErrLockNotAvailable, err)
+	}
+	return err
+}
+
+// FindOldestForUpdate locks and returns up to n entities with the lowest
+// orderColumn values, skipping rows already locked by other transactions.
+// Concurrent consumers calling it on a queue table each receive a disjoint
+// batch instead of waiting on one another.
+func (r *entityRepository[E, ID]) FindOldestForUpdate(n int, orderColumn string) ([]*E, error) {
+	if r.tx == nil {
+		return nil, fmt.Errorf("locking reads require a transaction")
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive, got %d", n)
+	}
+	order, err := orderBy(r.options.dialect, r.validColumns(), []OrderClause{{Column: orderColumn}})
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := r.table()
+
+	var entities []*E
+	where := r.where()
+	query, args := r.limit(fmt.Sprintf("SELECT * FROM %s%s%s", tableName, where, order), where.args, n, 0)
+	err = r.selectAll(&entities, query+" FOR UPDATE SKIP LOCKED", args...)
+	if err != nil {
+		return nil, lockError(err)
+	}
+	return entities, nil
+}
+
+// Edit is a safe read-modify-write of the entity with the given id: within a
+// transaction it locks the row with SELECT ... FOR UPDATE, hands the entity
+// to mutate and writes the result back with Update. The row lock keeps other
+// writers out until the commit, so no update made in between can be lost.
+//
+// A versioned entity, one with a column tagged db:"...,version", is only
+// written back if its row still holds the version read, and the version is
+// incremented, so copies read before the edit fail their own version checks.
+// Edit fails with ErrVersionConflict when the row's version differs or mutate
+// changed the version itself. Edit returns ErrNotFound when the row does not
+// exist, and rolls back and returns mutate's error when it fails. Inside a
+// transaction Edit joins it.
+func (r *entityRepository[E, ID]) Edit(id ID, mutate func(*E) error) error {
+	return r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		entity, err := txRepo.FindByIDForUpdate(id)
+		if err != nil {
+			return err
+		}
+		versionColumn, versioned := txRepo.versionColumn()
+		var version any
+		if versioned {
+			version = reflect.ValueOf(entity).Elem().FieldByIndex(versionColumn.Index).Interface()
+		}
+		if err := mutate(entity); err != nil {
+			return err
+		}
+		if (*entity).GetID() != id {
+			return fmt.Errorf("mutate changed the id of entity %v", id)
+		}
+		if !versioned {
+			return txRepo.Update(entity)
+		}
+		field := reflect.ValueOf(entity).Elem().FieldByIndex(versionColumn.Index)
+		if field.Interface() != version {
+			return fmt.Errorf("%w: mutate changed the version of entity %v", ErrVersionConflict, id)
+		}
+		if err := bumpVersion(field); err != nil {
+			return err
+		}
+		return txRepo.update(entity, version)
+	})
+}
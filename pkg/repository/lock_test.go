@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockOptions_Clause(t *testing.T) {
+	require.Equal(t, "FOR UPDATE", newLockOptions(nil).clause())
+	require.Equal(t, "FOR UPDATE NOWAIT", newLockOptions([]LockOption{NoWait()}).clause())
+	require.Equal(t, "FOR UPDATE OF orders NOWAIT", newLockOptions([]LockOption{LockOf("orders"), NoWait()}).clause())
+}
+
+func TestLockOptions_Validate(t *testing.T) {
+	require.NoError(t, newLockOptions(nil).validate("orders"))
+	require.NoError(t, newLockOptions([]LockOption{LockOf("orders")}).validate("orders"))
+	require.Error(t, newLockOptions([]LockOption{LockOf("customers")}).validate("orders"))
+	require.Error(t, newLockOptions([]LockOption{LockOf("orders; DROP TABLE orders")}).validate("orders; DROP TABLE orders"))
+}
+
+func TestUpdate_Version(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[ArticleEntity](db, WithQueryCapture()).(*entityRepository[ArticleEntity, int64])
+	require.Error(t, repo.update(&ArticleEntity{Id: 1, Title: "news", Version: 3}, int64(2)))
+	query, args := repo.LastQuery()
+	require.Equal(t, "UPDATE article_entities SET title = ?, updated_at = ?, version = ? WHERE id = ? AND version = ?", query)
+	require.Equal(t, int64(3), args[2])
+	require.Equal(t, []any{int64(1), int64(2)}, args[3:])
+
+	version := uint8(4)
+	require.NoError(t, bumpVersion(reflect.ValueOf(&version).Elem()))
+	require.Equal(t, uint8(5), version)
+	require.Error(t, bumpVersion(reflect.ValueOf(new(string)).Elem()))
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+type Option func(*options)
+
+type options struct {
+	statementCacheSize int
+	pageTokenSecret    []byte
+	readRetry          retryPolicy
+	writeRetry         retryPolicy
+	connectRetry       retryPolicy
+	maxFindAllRows     int
+	nameMapper         func(string) string
+
+	existenceFilterItems int
+	existenceFilterRate  float64
+	changeTracking       bool
+	pool                 []func(db *sql.DB)
+	transformers         map[string]ColumnTransformer
+	captureQueries       bool
+	autoIncrementCheck   bool
+	dialect              Dialect
+	writeAuditor         WriteAuditor
+	tenantColumn         string
+	changedOnlyUpsert    bool
+	changedOnlyIgnore    []string
+	entityPool           bool
+	strictFindByID       bool
+	queryTimeout         time.Duration
+	entityCacheTTL       time.Duration
+	entityCache          []CacheOption
+	documentColumn       string
+	defaultScope         Scope
+	children             []childRelation
+	// err is the first invalid argument given to an option.
+	err error
+}
+
+// WithStatementCache prepares every generated query once and reuses the
+// prepared statement for subsequent calls. At most size statements are kept;
+// preparing another one evicts and closes the least recently used.
+func WithStatementCache(size int) Option {
+	return func(o *options) {
+		o.statementCacheSize = size
+	}
+}
+
+// WithPageTokenSecret sets the key used to sign the tokens returned by
+// FindPage. FindPage refuses to run without it.
+func WithPageTokenSecret(secret []byte) Option {
+	return func(o *options) {
+		o.pageTokenSecret = secret
+	}
+}
+
+// WithMaxFindAllRows makes FindAll fail with ErrTooManyRows instead of
+// loading more than max rows into memory. FindAll is unlimited by default.
+func WithMaxFindAllRows(max int) Option {
+	return func(o *options) {
+		o.maxFindAllRows = max
+	}
+}
+
+// WithStrictFindByID makes FindByID fail with ErrTooManyRows when more than
+// one row has the id, which can only happen when the id column is not really
+// unique. By default FindByID returns the first of them.
+func WithStrictFindByID() Option {
+	return func(o *options) {
+		o.strictFindByID = true
+	}
+}
+
+// WithNameMapper sets how fields without a name in their db tag are mapped to
+// column names, for both reads and writes. Explicit tag names always win. The
+// default is SnakeCase, which a nil mapper restores.
+//
+// Repositories used to map such fields to their lowercased names, as sqlx
+// does, e.g. CreatedAt to createdat; SnakeCase maps it to created_at instead.
+// Entities relying on the old names should tag their fields or pass
+// strings.ToLower.
+func WithNameMapper(mapper func(string) string) Option {
+	return func(o *options) {
+		o.nameMapper = mapper
+		if mapper == nil {
+			o.nameMapper = SnakeCase
+		}
+	}
+}
+
+// invalid records err as the configuration error of the repository unless an
+// earlier option already failed.
+func (o *options) invalid(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{nameMapper: SnakeCase, dialect: MySQLDialect{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithChangedOnlyUpsert makes upserts leave a conflicting row untouched when
+// none of its updatable columns would change, ignoring the given columns in
+// the comparison. A sync that re-sends rows stamped with a fresh synced_at can
+// pass "synced_at" so that rows differing only in it are not rewritten.
+//
+// Every assignment of the ON DUPLICATE KEY UPDATE clause becomes
+// col = IF(NOT (a <=> VALUES(a) AND b <=> VALUES(b) ...), VALUES(col), col),
+// with the ignored columns assigned first because MySQL evaluates assignments
+// left to right. Unchanged rows are then not written, do not fire
+// ON UPDATE CURRENT_TIMESTAMP and count as skipped in UpsertAllSummary. The
+// price is a NULL-safe comparison of every compared column per conflicting
+// row and a statement that grows with the number of columns; MySQL already
+// skips rows whose every column is unchanged, so the option only pays off
+// with ignored columns or automatically updated ones.
+func WithChangedOnlyUpsert(ignore ...string) Option {
+	return func(o *options) {
+		o.changedOnlyUpsert = true
+		o.changedOnlyIgnore = ignore
+	}
+}
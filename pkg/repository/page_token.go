@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+const (
+	sortAscending  = "asc"
+	sortDescending = "desc"
+)
+
+// Kinds of page tokens. A token is only accepted by the method that issued
+// it, since each method interprets its key and direction differently.
+const (
+	pageTokenKind   = "page"
+	cursorTokenKind = "cursor"
+	findTokenKind   = "find"
+	keysetTokenKind = "keyset"
+)
+
+// pageToken is the payload of the opaque tokens handed out by FindPage,
+// FindCursor, FindPageBy and Find. It is signed so clients cannot craft a cursor
+// pointing anywhere they like.
+type pageToken struct {
+	Kind      string          `json:"t"`
+	Key       json.RawMessage `json:"k"`
+	Direction string          `json:"d"`
+}
+
+func encodePageToken(secret []byte, token pageToken) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signPageToken(secret, encoded)), nil
+}
+
+// decodePageToken verifies value and decodes the token it carries, which must
+// be of the given kind.
+func decodePageToken(secret []byte, value, kind string) (pageToken, error) {
+	var token pageToken
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return token, ErrInvalidPageToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, signPageToken(secret, encoded)) {
+		return token, ErrInvalidPageToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return token, ErrInvalidPageToken
+	}
+	if err := json.Unmarshal(payload, &token); err != nil {
+		return token, ErrInvalidPageToken
+	}
+	if token.Kind != kind || (token.Direction != sortAscending && token.Direction != sortDescending) {
+		return token, ErrInvalidPageToken
+	}
+	return token, nil
+}
+
+func signPageToken(secret []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
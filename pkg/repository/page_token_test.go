@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageToken_RoundTrip(t *testing.T) {
+	secret := []byte("secret")
+	token, err := encodePageToken(secret, pageToken{Kind: pageTokenKind, Key: []byte("42"), Direction: sortAscending})
+	require.NoError(t, err)
+
+	decoded, err := decodePageToken(secret, token, pageTokenKind)
+	require.NoError(t, err)
+	require.Equal(t, "42", string(decoded.Key))
+	require.Equal(t, sortAscending, decoded.Direction)
+}
+
+func TestPageToken_RejectsTampering(t *testing.T) {
+	secret := []byte("secret")
+	token, err := encodePageToken(secret, pageToken{Kind: pageTokenKind, Key: []byte("42"), Direction: sortAscending})
+	require.NoError(t, err)
+
+	forged, err := encodePageToken([]byte("other"), pageToken{Kind: pageTokenKind, Key: []byte("1"), Direction: sortAscending})
+	require.NoError(t, err)
+
+	_, err = decodePageToken(secret, forged, pageTokenKind)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, err = decodePageToken(secret, token[:len(token)-2], pageTokenKind)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, err = decodePageToken(secret, "garbage", pageTokenKind)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestPageToken_Kinds(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "3")
+	require.NoError(t, err)
+	defer db.Close()
+	repo := NewEntityRepository[SampleEntity](db, WithPageTokenSecret([]byte("secret"))).(*entityRepository[SampleEntity, int64])
+
+	page, err := repo.FindCursor("", 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+	_, _, err = repo.FindPage(page.NextCursor, 1, false)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+	_, _, err = repo.FindPageBy(KeysetOrder{Column: "name"}, page.NextCursor, 1)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	token, err := repo.idToken(pageTokenKind, page.Results[0], sortAscending)
+	require.NoError(t, err)
+	_, err = repo.FindCursor(token, 1)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestFindPage_Desc(t *testing.T) {
+	connector := &recordingConnector{ids: []int64{3, 2, 1}}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+	repo := NewEntityRepository[OrderEntity](db, WithPageTokenSecret([]byte("secret")))
+
+	page, token, err := repo.FindPage("", 2, true)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM order_entities ORDER BY id DESC LIMIT ?", connector.executed[0])
+	require.Equal(t, []int64{3, 2}, []int64{page.Results[0].Id, page.Results[1].Id})
+	require.NotEmpty(t, token)
+
+	connector.executed = nil
+	connector.args = nil
+	_, _, err = repo.FindPage(token, 2, false)
+	require.NoError(t, err)
+	require.Equal(t, "SELECT * FROM order_entities WHERE id < ? ORDER BY id DESC LIMIT ?", connector.executed[0])
+	require.Equal(t, int64(2), connector.args[0])
+}
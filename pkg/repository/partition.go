@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrPartitionsUnsupported = errors.New("dialect does not support partition selection")
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z0-9_$]+$`)
+
+// table returns the table reference reads and deletes run against, including
+// the partition selection of the repository, if any.
+func (r *entityRepository[E, ID]) table() string {
+	var emptyEntity E
+	tableName := emptyEntity.GetTableName()
+	if len(r.partitions) == 0 {
+		return tableName
+	}
+
+	quoted := make([]string, len(r.partitions))
+	for i, partition := range r.partitions {
+		quoted[i] = "`" + partition + "`"
+	}
+	return fmt.Sprintf("%s PARTITION (%s)", tableName, strings.Join(quoted, ","))
+}
+
+// InPartitions returns a view of the repository whose reads and deletes only
+// touch the given MySQL partitions, letting the server prune the others.
+// Partition names must be plain identifiers. Partition selection is MySQL
+// syntax, so other dialects fail with ErrPartitionsUnsupported.
+func (r *entityRepository[E, ID]) InPartitions(partitions ...string) (Repository[E, ID], error) {
+	if _, ok := r.options.dialect.(MySQLDialect); !ok {
+		return nil, ErrPartitionsUnsupported
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("at least one partition is required")
+	}
+	for _, partition := range partitions {
+		if !identifierPattern.MatchString(partition) {
+			return nil, fmt.Errorf("invalid partition name %q", partition)
+		}
+	}
+
+	partitioned := *r
+	partitioned.partitions = slices.Clone(partitions)
+	return &partitioned, nil
+}
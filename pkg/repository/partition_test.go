@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInPartitions(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	partitions := []string{"p0", "p1"}
+	repo, err := NewEntityRepository[SampleEntity](db, WithQueryCapture()).InPartitions(partitions...)
+	require.NoError(t, err)
+	partitions[0] = "p2"
+	_, err = repo.FindAll()
+	require.Error(t, err)
+	query, _ := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities PARTITION (`p0`,`p1`)", query)
+
+	_, err = NewEntityRepository[SampleEntity](db).InPartitions()
+	require.EqualError(t, err, "at least one partition is required")
+	_, err = NewEntityRepository[SampleEntity](db, WithDialect(StandardDialect{})).InPartitions("p0")
+	require.ErrorIs(t, err, ErrPartitionsUnsupported)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// WithMaxOpenConns calls SetMaxOpenConns(n) on the *sql.DB given to
+// NewEntityRepository. Pool settings belong to the *sql.DB, so they affect
+// every repository and caller sharing it, and the last one applied wins.
+func WithMaxOpenConns(n int) Option {
+	return func(o *options) {
+		o.pool = append(o.pool, func(db *sql.DB) { db.SetMaxOpenConns(n) })
+	}
+}
+
+// WithMaxIdleConns calls SetMaxIdleConns(n) on the *sql.DB given to
+// NewEntityRepository. Like WithMaxOpenConns it configures the shared pool.
+func WithMaxIdleConns(n int) Option {
+	return func(o *options) {
+		o.pool = append(o.pool, func(db *sql.DB) { db.SetMaxIdleConns(n) })
+	}
+}
+
+// WithConnMaxLifetime calls SetConnMaxLifetime(d) on the *sql.DB given to
+// NewEntityRepository. Like WithMaxOpenConns it configures the shared pool.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		o.pool = append(o.pool, func(db *sql.DB) { db.SetConnMaxLifetime(d) })
+	}
+}
+
+// Pool settings NewEntityRepositoryFromDSN applies before opts. database/sql
+// defaults to an unlimited number of open connections, which lets a burst of
+// requests exhaust the server's max_connections, and to connections that live
+// forever, which outlive server-side timeouts and load balancer idle limits.
+const (
+	defaultMaxOpenConns    = 25
+	defaultMaxIdleConns    = 25
+	defaultConnMaxLifetime = 5 * time.Minute
+)
+
+// NewEntityRepositoryFromDSN opens a database with sql.Open(driverName, dsn),
+// pings it, applies default pool settings and returns a repository backed by
+// it along with the database, which the caller owns and must close. Pool
+// options in opts override the defaults. When more than one repository should
+// share a pool, open the *sql.DB yourself and use NewEntityRepository.
+func NewEntityRepositoryFromDSN[E Entity[ID], ID comparable](driverName, dsn string, opts ...Option) (Repository[E, ID], *sql.DB, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+	defaults := []Option{
+		WithMaxOpenConns(defaultMaxOpenConns),
+		WithMaxIdleConns(defaultMaxIdleConns),
+		WithConnMaxLifetime(defaultConnMaxLifetime),
+	}
+	return NewEntityRepository[E](db, append(defaults, opts...)...), db, nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolOptions(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	defer db.Close()
+
+	NewEntityRepository[SampleEntity](db, WithMaxOpenConns(7), WithMaxIdleConns(3), WithConnMaxLifetime(time.Minute))
+	require.Equal(t, 7, db.Stats().MaxOpenConnections)
+}
+
+func TestNewEntityRepositoryFromDSN(t *testing.T) {
+	repo, db, err := NewEntityRepositoryFromDSN[SampleEntity, int64]("sqlrepo_rows", "2", WithMaxOpenConns(4))
+	require.NoError(t, err)
+	defer db.Close()
+	require.Equal(t, 4, db.Stats().MaxOpenConnections)
+
+	entities, err := repo.FindAll()
+	require.NoError(t, err)
+	require.Len(t, entities, 2)
+
+	_, _, err = NewEntityRepositoryFromDSN[SampleEntity, int64]("mysql", "user:password@tcp(localhost:1)/db")
+	require.Error(t, err)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+)
+
+// whereBuilder accumulates AND-ed conditions and their arguments. dialect
+// renders the collations of conditions; nil stands for MySQLDialect.
+// transformers encode the values compared to transformed columns.
+type whereBuilder struct {
+	conditions   []string
+	args         []interface{}
+	dialect      Dialect
+	transformers map[string]ColumnTransformer
+}
+
+func (w *whereBuilder) add(condition string, args ...interface{}) {
+	w.conditions = append(w.conditions, condition)
+	w.args = append(w.args, args...)
+}
+
+func (w *whereBuilder) String() string {
+	return w.clause("WHERE")
+}
+
+// clause renders the conditions after keyword, e.g. WHERE or HAVING.
+func (w *whereBuilder) clause(keyword string) string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " " + keyword + " " + strings.Join(w.conditions, " AND ")
+}
+
+// maxInListSize bounds how many values a single IN list binds; longer lists
+// are split across several queries.
+const maxInListSize = 1000
+
+// chunk splits items into consecutive slices of at most size elements.
+func chunk[T any](items []T, size int) [][]T {
+	var chunks [][]T
+	for size < len(items) {
+		chunks = append(chunks, items[:size])
+		items = items[size:]
+	}
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+	return chunks
+}
+
+// placeholders returns n comma separated bind placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// where starts a WHERE clause restricted to the rows visible through the
+// repository's tenant, scope and the entity's read scopes.
+func (r *entityRepository[E, ID]) where() *whereBuilder {
+	w := r.tenantWhere()
+	if condition := r.scopeCondition(); condition != "" {
+		w.add(condition)
+	}
+	// An invalid read scope fails the query in ready.
+	_ = r.addGlobalScopes(w)
+	return w
+}
+
+// validColumns returns the set of column names declared by the entity's db
+// tags, leaving out readonly columns, which only exist in the results of
+// queries computing them, and the document column, whose contents are opaque.
+func (r *entityRepository[E, ID]) validColumns() map[string]bool {
+	columns := make(map[string]bool)
+	for _, c := range r.columns() {
+		if c.has("readonly") || c.has("document") {
+			continue
+		}
+		columns[c.Name] = true
+	}
+	return columns
+}
+
+// addConditions adds an equality condition for every entry of conditions,
+// ordered by column name so the generated query is stable. Columns are
+// validated against the entity's db tags and a nil value matches NULL.
+func (r *entityRepository[E, ID]) addConditions(w *whereBuilder, conditions map[string]any) error {
+	validColumns := r.validColumns()
+	columns := make([]string, 0, len(conditions))
+	for column := range conditions {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		if err := addCondition(w, validColumns, Eq(column, conditions[column])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
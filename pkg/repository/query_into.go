@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueryScope is what a QueryInto builder needs to stay within a repository:
+// its table and the condition restricting reads to the rows visible through
+// it, such as live rows of the current tenant. Where is TRUE when nothing is
+// hidden, and Args are the arguments of its placeholders.
+type QueryScope struct {
+	Table string
+	Where string
+	Args  []any
+}
+
+// QueryInto runs the query returned by build on the connection or transaction
+// of repo and scans every row into T, which need not be the entity type, for
+// reports with aggregates or joins over the entity's table, e.g.
+//
+//	totals, err := QueryInto[CustomerTotal](repo, func(s QueryScope) (string, []any) {
+//		query := "SELECT customer, SUM(amount) AS total FROM " + s.Table +
+//			" WHERE " + s.Where + " AND amount > ? GROUP BY customer"
+//		return query, append(s.Args, 0)
+//	})
+//
+// build must use s.Where to honor the repository's scopes and bind its
+// arguments in placeholder order. The query is run verbatim, so build must
+// never splice user input into it.
+func QueryInto[T any, E Entity[ID], ID comparable](repo Repository[E, ID], build func(s QueryScope) (string, []any)) ([]*T, error) {
+	r, ok := repo.(*entityRepository[E, ID])
+	if !ok {
+		return nil, fmt.Errorf("unsupported repository implementation %T", repo)
+	}
+
+	where := r.where()
+	scope := QueryScope{Table: r.table(), Where: "TRUE", Args: where.args}
+	if len(where.conditions) > 0 {
+		scope.Where = strings.Join(where.conditions, " AND ")
+	}
+	query, args := build(scope)
+
+	var results []*T
+	if err := r.selectAll(&results, query, args...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryInto_Scope(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	var scopes []QueryScope
+	build := func(s QueryScope) (string, []any) {
+		scopes = append(scopes, s)
+		return "SELECT COUNT(*) AS total FROM " + s.Table + " WHERE " + s.Where, s.Args
+	}
+
+	_, err = QueryInto[struct{ Total int64 }](NewEntityRepository[SampleEntity](db), build)
+	require.Error(t, err)
+	_, err = QueryInto[struct{ Total int64 }](NewEntityRepository[SoftDeleteEntity](db), build)
+	require.Error(t, err)
+
+	require.Equal(t, []QueryScope{
+		{Table: "sample_entities", Where: "TRUE"},
+		{Table: "soft_delete_entities", Where: "deleted_at IS NULL"},
+	}, scopes)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunk(t *testing.T) {
+	require.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, chunk([]int{1, 2, 3, 4, 5}, 2))
+	require.Equal(t, [][]int{{1, 2}}, chunk([]int{1, 2}, 2))
+	require.Empty(t, chunk([]int{}, 2))
+}
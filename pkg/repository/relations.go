@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// OnDelete is what happens to the children of a deleted entity; see
+// WithChildRelation.
+type OnDelete int
+
+const (
+	// OnDeleteCascade deletes the children, bypassing their soft delete.
+	OnDeleteCascade OnDelete = iota
+	// OnDeleteSoftDelete soft-deletes the children, which must support it.
+	OnDeleteSoftDelete
+	// OnDeleteSetNull sets the foreign key of the children to NULL.
+	OnDeleteSetNull
+)
+
+// childRelation is a relation registered with WithChildRelation.
+type childRelation struct {
+	// onParentDelete applies the relation to the children of parentIDs
+	// within tx.
+	onParentDelete func(ctx context.Context, tx *sqlx.Tx, parentIDs []any) error
+}
+
+// WithChildRelation makes every delete of the repository, hard or soft, act
+// on the entities of child whose fkColumn references a deleted entity's id,
+// as onDelete says, in the same transaction as the delete itself. It enforces
+// referential actions in the application where the schema does not declare
+// them; with a declared ON DELETE clause the database already does.
+//
+// Children deleted or soft-deleted this way have their own relations applied
+// in turn. The child operations are restricted to the tenant of the
+// repository's context but ignore the child repository's scopes. Restoring a
+// soft-deleted parent does not restore its children.
+//
+// The repository fails every query when fkColumn is not a column stored by
+// the child entity, which excludes readonly and document columns, or when
+// onDelete is OnDeleteSoftDelete and the child entity has no soft delete
+// column.
+func WithChildRelation[C Entity[CID], CID comparable](child Repository[C, CID], fkColumn string, onDelete OnDelete) Option {
+	return func(o *options) {
+		r, ok := child.(*entityRepository[C, CID])
+		if !ok {
+			o.invalid(fmt.Errorf("unsupported repository implementation %T", child))
+			return
+		}
+		if err := r.checkChildRelation(fkColumn, onDelete); err != nil {
+			o.invalid(err)
+			return
+		}
+		o.children = append(o.children, childRelation{
+			onParentDelete: func(ctx context.Context, tx *sqlx.Tx, parentIDs []any) error {
+				txChild := r.withTx(tx)
+				txChild.ctx = ctx
+				return txChild.onParentDelete(fkColumn, onDelete, parentIDs)
+			},
+		})
+	}
+}
+
+// checkChildRelation fails when fkColumn and onDelete cannot relate the
+// entities of the repository to their parents.
+func (r *entityRepository[E, ID]) checkChildRelation(fkColumn string, onDelete OnDelete) error {
+	var emptyEntity E
+	if !r.validColumns()[fkColumn] || !identifierPattern.MatchString(fkColumn) {
+		return fmt.Errorf("foreign key %q is not a column of %T", fkColumn, emptyEntity)
+	}
+	switch onDelete {
+	case OnDeleteCascade, OnDeleteSetNull:
+	case OnDeleteSoftDelete:
+		if r.softDelete == nil {
+			return fmt.Errorf("%T does not support soft delete", emptyEntity)
+		}
+	default:
+		return fmt.Errorf("unknown OnDelete %d", onDelete)
+	}
+	return nil
+}
+
+// onParentDelete applies onDelete to the entities whose fkColumn is one of
+// parentIDs.
+func (r *entityRepository[E, ID]) onParentDelete(fkColumn string, onDelete OnDelete, parentIDs []any) error {
+	tableName := r.table()
+	for _, idChunk := range chunk(parentIDs, maxInListSize) {
+		where := r.tenantWhere()
+		where.add(fmt.Sprintf("%s IN (%s)", fkColumn, placeholders(len(idChunk))), idChunk...)
+
+		if onDelete == OnDeleteSetNull {
+			query := fmt.Sprintf("UPDATE %s SET %s = NULL%s", tableName, fkColumn, where)
+			if _, err := r.exec(query, where.args...); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := r.deleteWhere(onDelete == OnDeleteCascade, where, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteStatement returns the statement deleting the rows selected by clause,
+// or soft-deleting them unless hard is set or E does not support soft delete.
+func (r *entityRepository[E, ID]) deleteStatement(hard bool, clause string) string {
+	if r.softDelete != nil && !hard {
+		return fmt.Sprintf("UPDATE %s SET %s%s", r.table(), r.softDeleteAssignment(true), clause)
+	}
+	return fmt.Sprintf("DELETE FROM %s%s", r.table(), clause)
+}
+
+// deleteWhere deletes, or soft-deletes unless hard is set, the rows selected
+// by where, at most limit of them when it is positive, and returns the number
+// of rows it affected. Soft deletes skip rows already deleted, so their
+// deletion time is kept. With relations registered by WithChildRelation, the
+// rows are first locked and their children acted on, and then exactly the
+// locked rows are deleted by id, all in one transaction: a clause with a limit
+// could otherwise select other rows the second time.
+func (r *entityRepository[E, ID]) deleteWhere(hard bool, where *whereBuilder, limit int) (int64, error) {
+	if live := r.liveCondition(); r.softDelete != nil && !hard && !slices.Contains(where.conditions, live) {
+		where.add(live)
+	}
+	clause, args := r.limitWrite(where, limit)
+	if len(r.options.children) == 0 {
+		return r.execAffected(r.deleteStatement(hard, clause), args...)
+	}
+	var affected int64
+	err := r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		var ids []ID
+		selectQuery := fmt.Sprintf("SELECT id FROM %s%s FOR UPDATE", r.table(), clause)
+		if err := txRepo.selectAll(&ids, selectQuery, args...); err != nil {
+			return err
+		}
+		parentIDs := make([]any, len(ids))
+		for i, id := range ids {
+			parentIDs[i] = id
+		}
+		if len(parentIDs) > 0 {
+			for _, relation := range r.options.children {
+				if err := relation.onParentDelete(txRepo.ctx, txRepo.tx, parentIDs); err != nil {
+					return err
+				}
+			}
+		}
+		for _, idChunk := range chunk(parentIDs, maxInListSize) {
+			clause := fmt.Sprintf(" WHERE id IN (%s)", placeholders(len(idChunk)))
+			n, err := txRepo.execAffected(txRepo.deleteStatement(hard, clause), idChunk...)
+			if err != nil {
+				return err
+			}
+			affected += n
+		}
+		return nil
+	})
+	return affected, err
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithChildRelation_Validation(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	children := NewEntityRepository[ChildEntity](db)
+	for _, c := range []struct {
+		relation Option
+		err      string
+	}{
+		{WithChildRelation(children, "parent_id", OnDeleteSoftDelete), "sql: database is closed"},
+		{WithChildRelation(children, "sample_id", OnDeleteCascade), `foreign key "sample_id" is not a column of repository.ChildEntity`},
+		{WithChildRelation(children, "parent_id", OnDelete(7)), "unknown OnDelete 7"},
+		{WithChildRelation(NewEntityRepository[EventEntity](db), "document", OnDeleteCascade), `foreign key "document" is not a column of repository.EventEntity`},
+		{WithChildRelation(NewEntityRepository[OrderEntity](db), "customer", OnDeleteSoftDelete), "repository.OrderEntity does not support soft delete"},
+	} {
+		_, err := NewEntityRepository[SampleEntity](db, c.relation).FindAll()
+		require.EqualError(t, err, c.err)
+	}
+}
+
+func TestOnParentDelete(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	children := NewEntityRepository[ChildEntity](db, WithQueryCapture()).(*entityRepository[ChildEntity, int64])
+	for onDelete, want := range map[OnDelete]string{
+		OnDeleteCascade:    "DELETE FROM child_entities WHERE parent_id IN (?,?)",
+		OnDeleteSoftDelete: "UPDATE child_entities SET deleted_at = NOW(6) WHERE parent_id IN (?,?) AND deleted_at IS NULL",
+		OnDeleteSetNull:    "UPDATE child_entities SET parent_id = NULL WHERE parent_id IN (?,?)",
+	} {
+		require.Error(t, children.onParentDelete("parent_id", onDelete, []any{int64(1), int64(2)}))
+		query, args := children.LastQuery()
+		require.Equal(t, want, query)
+		require.Equal(t, []any{int64(1), int64(2)}, args)
+	}
+}
+
+func TestDeleteBy_ChildRelationDeletesLockedRows(t *testing.T) {
+	connector := &recordingConnector{ids: []int64{3, 5}}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	children := NewEntityRepository[ChildEntity](db)
+	repo := NewEntityRepository[SampleEntity](db, WithChildRelation(children, "parent_id", OnDeleteSoftDelete))
+	_, err := repo.DeleteBy(map[string]any{"name": "old"}, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"SELECT id FROM sample_entities WHERE name = ? ORDER BY id LIMIT ? FOR UPDATE",
+		"UPDATE child_entities SET deleted_at = NOW(6) WHERE parent_id IN (?,?) AND deleted_at IS NULL",
+		"DELETE FROM sample_entities WHERE id IN (?,?)",
+	}, connector.executed)
+	require.Equal(t, []driver.Value{"old", int64(2), int64(3), int64(5), int64(3), int64(5)}, connector.args)
+}
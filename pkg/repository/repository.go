@@ -1,162 +1,395 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
-	"slices"
-	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
 )
 
-func NewEntityRepository[E Entity[ID], ID comparable](db *sql.DB) Repository[E, ID] {
-	return &entityRepository[E, ID]{
-		DB: sqlx.NewDb(db, "mysql"),
+var (
+	ErrTooManyRows = errors.New("too many rows")
+	ErrNotFound    = errors.New("entity not found")
+)
+
+// bindDriver is the driver name given to sqlx, which only uses it to pick the
+// placeholder style. The repository generates ? placeholders whatever the
+// database, so it is MySQL's.
+const bindDriver = "mysql"
+
+// NewEntityRepository returns a repository for E backed by db.
+//
+// A repository is immutable once constructed and is safe for concurrent use
+// by multiple goroutines; all configuration happens through opts. When opts
+// are invalid, e.g. name a column E does not have, every query fails with the
+// configuration error.
+func NewEntityRepository[E Entity[ID], ID comparable](db *sql.DB, opts ...Option) Repository[E, ID] {
+	o := newOptions(opts)
+	for _, configure := range o.pool {
+		configure(db)
+	}
+	r := &entityRepository[E, ID]{
+		DB:      sqlx.NewDb(db, bindDriver),
+		ctx:     context.Background(),
+		options: o,
+	}
+	r.DB.Mapper = reflectx.NewMapperFunc("db", o.nameMapper)
+	if c, ok := softDeleteColumn(r.columns()); ok {
+		r.softDelete = &c
+		r.scope = o.defaultScope
+	}
+	if o.existenceFilterItems > 0 {
+		r.existence = &existenceFilter{
+			expectedItems:     o.existenceFilterItems,
+			falsePositiveRate: o.existenceFilterRate,
+		}
+	}
+	if o.changeTracking {
+		r.tracker = newChangeTracker[ID]()
+	}
+	r.windowFunctions = &windowFunctionCheck{}
+	if o.autoIncrementCheck {
+		r.autoIncrement = &autoIncrementCheck{}
+	}
+	if o.entityPool {
+		r.entities = newEntityPool[E]()
+	}
+	if o.entityCacheTTL > 0 {
+		r.cache = newEntityCache[E](o.entityCacheTTL, o.entityCache)
 	}
+	if o.captureQueries {
+		r.recorder = &queryRecorder{}
+	}
+	if o.statementCacheSize > 0 {
+		r.stmts = newStmtCache(o.statementCacheSize)
+	}
+	r.err = r.checkOptions()
+	return r
+}
+
+// checkOptions returns the first error in the configuration of the
+// repository, which its queries then fail with.
+func (r *entityRepository[E, ID]) checkOptions() error {
+	var emptyEntity E
+	switch {
+	case r.options.err != nil:
+		return r.options.err
+	case r.options.defaultScope < ScopeLive || r.options.defaultScope > ScopeTrashed:
+		return fmt.Errorf("unknown scope %d", r.options.defaultScope)
+	case r.cache != nil && r.cache.options.err != nil:
+		return r.cache.options.err
+	}
+	if _, ok := r.tenantField(); r.options.tenantColumn != "" && !ok {
+		return fmt.Errorf("tenant column %q is not a column of %T", r.options.tenantColumn, emptyEntity)
+	}
+	return r.checkTransformers()
 }
 
 type entityRepository[E Entity[ID], ID comparable] struct {
-	DB *sqlx.DB
+	DB              *sqlx.DB
+	tx              *sqlx.Tx
+	ctx             context.Context
+	options         options
+	stmts           *stmtCache
+	softDelete      *column
+	scope           Scope
+	scoped          bool
+	partitions      []string
+	existence       *existenceFilter
+	tracker         *changeTracker[ID]
+	recorder        *queryRecorder
+	autoIncrement   *autoIncrementCheck
+	windowFunctions *windowFunctionCheck
+	entities        *entityPool[E]
+	cache           *entityCache[E]
+	unscoped        bool
+	// err is the configuration error every query of the repository fails
+	// with.
+	err error
 }
 
 func (r *entityRepository[E, ID]) FindAll() ([]*E, error) {
-	var emptyEntity E
-	tableName := emptyEntity.GetTableName()
+	tableName := r.table()
 
 	var entities []*E
-	query := fmt.Sprintf("SELECT * FROM %s", tableName)
-	err := r.DB.Select(&entities, query)
+	where := r.where()
+	query := fmt.Sprintf("SELECT * FROM %s%s", tableName, where)
+	args := where.args
+	if r.options.maxFindAllRows > 0 {
+		query, args = r.limit(query, args, r.options.maxFindAllRows+1, 0)
+	}
+	err := r.selectAll(&entities, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	if r.options.maxFindAllRows > 0 && len(entities) > r.options.maxFindAllRows {
+		return nil, fmt.Errorf("%w: FindAll is limited to %d rows, use pagination or Stream", ErrTooManyRows, r.options.maxFindAllRows)
+	}
 	return entities, nil
 }
 
+// Stream calls fn for every entity visible to the repository, scanning one row
+// at a time so memory use stays bounded regardless of the table size. Each
+// call receives a freshly allocated entity, or with WithEntityPool one that is
+// only valid until fn returns. Iteration stops at the first error returned by
+// fn.
+func (r *entityRepository[E, ID]) Stream(fn func(*E) error) error {
+	tableName := r.table()
+
+	where := r.where()
+	query := fmt.Sprintf("SELECT * FROM %s%s", tableName, where)
+	rows, err := r.queryRows(query, where.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entity := r.newEntity()
+		if err := r.scanEntity(rows, entity); err != nil {
+			r.releaseEntity(entity)
+			return err
+		}
+		err := fn(entity)
+		r.releaseEntity(entity)
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// QueryRows runs the SELECT the repository would issue for conditions and
+// returns the open rows for the caller to scan. The caller owns the rows and
+// must close them.
+func (r *entityRepository[E, ID]) QueryRows(conditions map[string]any) (*sqlx.Rows, error) {
+	tableName := r.table()
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf("SELECT * FROM %s%s", tableName, where)
+	return r.queryRows(query, where.args...)
+}
+
 func (r *entityRepository[E, ID]) FindByID(id ID) (*E, error) {
+	var entity *E
+	var err error
+	if r.cache != nil && r.tx == nil {
+		entity, err = r.cache.get(r.ctx, r.cacheKey(id), func(ctx context.Context) (*E, error) {
+			view := *r
+			view.ctx = ctx
+			return view.findByID(id)
+		})
+	} else {
+		entity, err = r.findByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r.track(entity)
+	return entity, nil
+}
+
+func (r *entityRepository[E, ID]) findByID(id ID) (*E, error) {
 	entities, err := r.FindAllByID([]ID{id})
 	if err != nil {
 		return nil, err
 	}
 
 	if len(entities) == 0 {
-		return nil, fmt.Errorf("entity not found")
+		return nil, ErrNotFound
+	}
+	if r.options.strictFindByID && len(entities) > 1 {
+		return nil, fmt.Errorf("%w: %d rows have id %v", ErrTooManyRows, len(entities), id)
 	}
-
 	return entities[0], nil
 }
 
 func (r *entityRepository[E, ID]) FindAllByID(ids []ID) ([]*E, error) {
-	var emptyEntity E
-	tableName := emptyEntity.GetTableName()
+	tableName := r.table()
 	args := make([]interface{}, len(ids))
-	idStrings := make([]string, len(ids))
 	for i, id := range ids {
-		idStrings[i] = fmt.Sprintf("?")
 		args[i] = id
 	}
 
 	var entities []*E
-	query := fmt.Sprintf("SELECT * FROM %s WHERE id IN (%s)", tableName, strings.Join(idStrings, ","))
-	err := r.DB.Select(&entities, query, args...)
+	where := r.where()
+	where.add(fmt.Sprintf("id IN (%s)", placeholders(len(ids))), args...)
+	query := fmt.Sprintf("SELECT * FROM %s%s", tableName, where)
+	err := r.selectAll(&entities, query, where.args...)
 	if err != nil {
 		return nil, err
 	}
 	return entities, nil
 }
 
+// FindAllByIDWhere returns the entities with the given ids that also match
+// conditions. Long id lists are queried in chunks.
+func (r *entityRepository[E, ID]) FindAllByIDWhere(ids []ID, conditions map[string]any) ([]*E, error) {
+	tableName := r.table()
+
+	var entities []*E
+	for _, idChunk := range chunk(ids, maxInListSize) {
+		args := make([]interface{}, len(idChunk))
+		for i, id := range idChunk {
+			args[i] = id
+		}
+
+		where := r.where()
+		where.add(fmt.Sprintf("id IN (%s)", placeholders(len(idChunk))), args...)
+		if err := r.addConditions(where, conditions); err != nil {
+			return nil, err
+		}
+
+		var chunkEntities []*E
+		query := fmt.Sprintf("SELECT * FROM %s%s", tableName, where)
+		if err := r.selectAll(&chunkEntities, query, where.args...); err != nil {
+			return nil, err
+		}
+		entities = append(entities, chunkEntities...)
+	}
+	return entities, nil
+}
+
 func (r *entityRepository[E, ID]) Save(entity *E) error {
 	return r.SaveAll([]*E{entity})
 }
 
 func (r *entityRepository[E, ID]) SaveAll(entities []*E) error {
+	_, err := r.insertAll(entities)
+	return err
+}
+
+// insertAll inserts entities and writes auto-incremented ids back. Entities
+// with a non-zero auto-incremented id keep it: they are inserted with their
+// ids first, in the same transaction as the others.
+func (r *entityRepository[E, ID]) insertAll(entities []*E) (sql.Result, error) {
 	if len(entities) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	var columns []string
-	var placeholders []string
-	var values []interface{}
-
-	// Use the first entity to determine the columns
-	firstEntity := entities[0]
-	entityValue := reflect.ValueOf(firstEntity).Elem()
-	entityType := entityValue.Type()
-
-	// Ensure entity implements Entity interface
-	entityInterface, ok := any(firstEntity).(Entity[ID])
-	if !ok {
-		return fmt.Errorf("entity does not implement the Entity interface")
+	explicit, generated := r.partitionByID(entities)
+	switch {
+	case len(explicit) == 0:
+		return r.insertRows(entities, false)
+	case len(generated) == 0:
+		return r.insertRows(entities, true)
 	}
 
-	var idAutoIncrement bool
-	var idField reflect.StructField
-
-	// Iterate over the fields of the struct
-	for i := 0; i < entityType.NumField(); i++ {
-		field := entityType.Field(i)
-		dbTag := field.Tag.Get("db")
-		tagParts := strings.Split(dbTag, ",")
-		for j, tagPart := range tagParts {
-			tagParts[j] = strings.TrimSpace(tagPart)
-		}
-		if len(tagParts) > 0 {
-			columnName := tagParts[0]
-			if columnName == "id" {
-				idAutoIncrement = len(tagParts) > 1 && slices.Contains(tagParts, "autoincrement")
-				idField = field
-
-				if idAutoIncrement {
-					continue
-				}
+	result := &batchResult{}
+	err := r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		for _, part := range []struct {
+			entities  []*E
+			includeID bool
+		}{{explicit, true}, {generated, false}} {
+			res, err := txRepo.insertRows(part.entities, part.includeID)
+			if err != nil {
+				return err
+			}
+			if err := result.add(res); err != nil {
+				return err
 			}
-			columns = append(columns, columnName)
-			placeholders = append(placeholders, "?")
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	// Build the query
-	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", entityInterface.GetTableName(), strings.Join(columns, ","))
-
-	// Add placeholders and values for each entity
+// partitionByID splits entities with an auto-incremented id into those whose
+// id is set and those left for the database to assign. Entities without an
+// auto-incremented id are all returned as generated.
+func (r *entityRepository[E, ID]) partitionByID(entities []*E) (explicit, generated []*E) {
+	c, ok := r.column("id")
+	if !ok || !c.has("autoincrement") {
+		return nil, entities
+	}
 	for _, entity := range entities {
-		entityValue := reflect.ValueOf(entity).Elem()
-		for i := 0; i < entityType.NumField(); i++ {
-			field := entityType.Field(i)
-			dbTag := field.Tag.Get("db")
-			tagParts := strings.Split(dbTag, ",")
-			columnName := strings.TrimSpace(tagParts[0])
-			if columnName == "id" && idAutoIncrement {
-				continue
-			}
-			values = append(values, entityValue.Field(i).Interface())
+		if reflect.ValueOf(entity).Elem().FieldByIndex(c.Index).IsZero() {
+			generated = append(generated, entity)
+		} else {
+			explicit = append(explicit, entity)
 		}
-		query += fmt.Sprintf("(%s),", strings.Join(placeholders, ","))
 	}
+	return explicit, generated
+}
 
-	// Remove the trailing comma
-	query = strings.TrimSuffix(query, ",")
+// batchResult combines the results of the statements of one insert: the rows
+// affected by all of them and the last insert id of the last one.
+type batchResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
 
-	// Execute the query
-	result, err := r.DB.Exec(query, values...)
+func (b *batchResult) add(res sql.Result) error {
+	affected, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	b.rowsAffected += affected
+	b.lastInsertID, err = res.LastInsertId()
+	return err
+}
+
+func (b *batchResult) LastInsertId() (int64, error) { return b.lastInsertID, nil }
+
+func (b *batchResult) RowsAffected() (int64, error) { return b.rowsAffected, nil }
+
+// insertRows inserts entities in one statement, with their ids when includeID
+// is set, and otherwise writes the auto-incremented ids back.
+func (r *entityRepository[E, ID]) insertRows(entities []*E, includeID bool) (sql.Result, error) {
+	insert, err := r.buildInsert(entities, includeID)
+	if err != nil {
+		return nil, err
+	}
+	checkIDs := insert.idAutoIncrement && len(entities) > 1 && r.autoIncrement != nil
+	if checkIDs {
+		if err := r.checkAutoIncrement(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Execute the query
+	result, err := r.exec(insert.query, insert.args...)
+	if err != nil {
+		return nil, err
+	}
 
 	// Set auto-increment IDs if necessary
-	if idAutoIncrement {
+	if insert.idAutoIncrement {
+		if checkIDs {
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkInsertedRows(affected, len(entities)); err != nil {
+				return nil, err
+			}
+		}
 		lastInsertID, err := result.LastInsertId()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		for i, entity := range entities {
 			entityValue := reflect.ValueOf(entity).Elem()
-			entityValue.FieldByName(idField.Name).SetInt(lastInsertID + int64(i))
+			entityValue.FieldByIndex(insert.idField.Index).SetInt(lastInsertID + int64(i))
 		}
 	}
+	r.rememberIDs(entities)
 
-	return nil
+	return result, nil
 }
 
 func (r *entityRepository[E, ID]) DeleteByID(id ID) error {
@@ -164,32 +397,126 @@ func (r *entityRepository[E, ID]) DeleteByID(id ID) error {
 }
 
 func (r *entityRepository[E, ID]) DeleteByIDs(ids []ID) error {
-	var emptyEntity E
-	tableName := emptyEntity.GetTableName()
 	args := make([]interface{}, len(ids))
-	idStrings := make([]string, len(ids))
 	for i, id := range ids {
-		idStrings[i] = fmt.Sprintf("?")
 		args[i] = id
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", tableName, strings.Join(idStrings, ","))
-	_, err := r.DB.Exec(query, args...)
-	if err != nil {
-		return err
+	where := r.tenantWhere()
+	where.add(fmt.Sprintf("id IN (%s)", placeholders(len(ids))), args...)
+	_, err := r.deleteWhere(false, where, 0)
+	return err
+}
+
+// DeleteReturning deletes the rows matching conditions and returns them as they
+// were right before deletion. The rows are locked and deleted within a single
+// transaction, so the returned set is exactly the deleted set.
+func (r *entityRepository[E, ID]) DeleteReturning(conditions map[string]any) ([]*E, error) {
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("refusing to delete without conditions")
 	}
-	return nil
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+	return r.deleteReturning([]*whereBuilder{where})
 }
 
-func (r *entityRepository[E, ID]) DeleteAll() error {
-	var emptyEntity E
-	tableName := emptyEntity.GetTableName()
-	query := fmt.Sprintf("DELETE FROM %s", tableName)
-	_, err := r.DB.Exec(query)
+// DeleteByIDsReturning deletes the entities with the given ids and returns
+// them as they were right before deletion, e.g. to publish tombstone events
+// for exactly what was deleted. Ids without a row are left out.
+func (r *entityRepository[E, ID]) DeleteByIDsReturning(ids []ID) ([]*E, error) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	var wheres []*whereBuilder
+	for _, idChunk := range chunk(args, maxInListSize) {
+		where := r.where()
+		where.add(fmt.Sprintf("id IN (%s)", placeholders(len(idChunk))), idChunk...)
+		wheres = append(wheres, where)
+	}
+	return r.deleteReturning(wheres)
+}
+
+// deleteReturning deletes the rows selected by every one of wheres and
+// returns them as they were right before deletion. With a Returner dialect and
+// hard deletes each one is a single DELETE ... RETURNING *; otherwise the rows
+// are selected and locked first and deleted, or soft-deleted, in the same
+// transaction. Either way the returned set is exactly the deleted set.
+func (r *entityRepository[E, ID]) deleteReturning(wheres []*whereBuilder) ([]*E, error) {
+	entities := []*E{}
+	if len(wheres) == 0 {
+		return entities, nil
+	}
+	returner, ok := r.options.dialect.(Returner)
+	returning := ok && r.softDelete == nil && !r.scansManually() && len(r.options.children) == 0
+	deleteWhere := func(txRepo *entityRepository[E, ID], where *whereBuilder) error {
+		var deleted []*E
+		if returning {
+			query := fmt.Sprintf("DELETE FROM %s%s%s", txRepo.table(), where, returner.Returning("*"))
+			if err := txRepo.execReturning(&deleted, query, where.args...); err != nil {
+				return err
+			}
+			entities = append(entities, deleted...)
+			return nil
+		}
+		query := fmt.Sprintf("SELECT * FROM %s%s FOR UPDATE", txRepo.table(), where)
+		if err := txRepo.selectAll(&deleted, query, where.args...); err != nil {
+			return err
+		}
+		for _, part := range chunk(deleted, maxInListSize) {
+			if err := txRepo.DeleteEntities(part); err != nil {
+				return err
+			}
+		}
+		entities = append(entities, deleted...)
+		return nil
+	}
+
+	// A single DELETE ... RETURNING is atomic on its own.
+	if returning && len(wheres) == 1 {
+		if err := deleteWhere(r, wheres[0]); err != nil {
+			return nil, err
+		}
+		return entities, nil
+	}
+	err := r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		for _, where := range wheres {
+			if err := deleteWhere(txRepo, where); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return entities, nil
+}
+
+// DeleteAll deletes, or soft-deletes, every row visible through the
+// repository's tenant. Since a stray call wipes the table, for instance when
+// an upstream filter ends up empty and the caller falls back to DeleteAll, it
+// fails with ErrDeleteAllUnconfirmed unless called with
+// ConfirmFullDelete(). Use DeleteAllUnguarded where deleting everything is
+// the intent by construction, such as test cleanup.
+func (r *entityRepository[E, ID]) DeleteAll(opts ...DeleteOption) error {
+	var o deleteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if !o.confirmed {
+		return ErrDeleteAllUnconfirmed
+	}
+	return r.DeleteAllUnguarded()
+}
+
+// DeleteAllUnguarded is DeleteAll without the confirmation.
+func (r *entityRepository[E, ID]) DeleteAllUnguarded() error {
+	_, err := r.deleteWhere(false, r.tenantWhere(), 0)
+	return err
 }
 
 func (r *entityRepository[E, ID]) DeleteEntities(entities []*E) error {
@@ -215,33 +542,76 @@ func (r *entityRepository[E, ID]) ExistsByID(id ID) error {
 	}
 
 	if len(entities) == 0 {
-		return fmt.Errorf("entity not found")
+		return ErrNotFound
 	}
 
 	return nil
 }
 
 func (r *entityRepository[E, ID]) FindAllPaginated(pagination Pagination) (*PaginatedResult[E], error) {
-	var emptyEntity E
-	tableName := emptyEntity.GetTableName()
+	return r.Find(FindOptions{Pagination: &pagination, Count: true})
+}
 
-	var entities []*E
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT ? OFFSET ?", tableName)
-	err := r.DB.Select(&entities, query, pagination.Limit, pagination.Offset)
+// FindAllPaginatedColumns is FindAllPaginated reading only the given columns;
+// the other fields of the returned entities are left zero. It keeps large
+// columns out of list views.
+func (r *entityRepository[E, ID]) FindAllPaginatedColumns(pagination Pagination, columns []string) (*PaginatedResult[E], error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to select")
+	}
+	return r.Find(FindOptions{Columns: columns, Pagination: &pagination, Count: true})
+}
+
+// FindPage returns the page following the one token was issued for, ordered
+// by id, together with the token for the next page. An empty token requests
+// the first page, in descending order of id when desc is set; later pages
+// keep the order of the token they follow, whatever desc is. An empty next
+// token means there are no more rows.
+func (r *entityRepository[E, ID]) FindPage(token string, limit int, desc bool) (*PaginatedResult[E], string, error) {
+	entities, direction, hasMore, err := r.findByIDKey("*", r.where(), token, pageTokenKind, limit, desc)
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	var nextToken string
+	if hasMore {
+		nextToken, err = r.idToken(pageTokenKind, entities[len(entities)-1], direction)
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	var totalCount int
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)
-	err = r.DB.Get(&totalCount, countQuery)
-	if err != nil {
-		return nil, err
+	if err := r.countInto(&totalCount, r.where()); err != nil {
+		return nil, "", err
 	}
 
 	return &PaginatedResult[E]{
-		Pagination: pagination,
+		Pagination: Pagination{Limit: limit},
 		TotalCount: totalCount,
 		Results:    entities,
-	}, nil
+	}, nextToken, nil
+}
+
+// bindValue prepares a field value for use as a query argument. Times are
+// truncated to microseconds, the finest precision MySQL stores, because the
+// server would otherwise round the extra digits instead of dropping them.
+// JSON numbers are bound as int64 when they are integers and as their exact
+// decimal text otherwise.
+func bindValue(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n
+		}
+		return t.String()
+	case time.Time:
+		return t.Truncate(time.Microsecond)
+	case *time.Time:
+		if t == nil {
+			return t
+		}
+		return t.Truncate(time.Microsecond)
+	}
+	return v
 }
@@ -3,7 +3,13 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/docker/go-connections/nat"
 	_ "github.com/go-sql-driver/mysql"
@@ -50,7 +56,7 @@ func (s *IntegrationTestSuite) SetupSuite() {
 
 	dbHost, err := s.MySQLContainer.Host(s.Ctx)
 	s.Require().NoError(err)
-	s.DB, err = sql.Open("mysql", "root:password@tcp("+dbHost+":"+mappedPort.Port()+")/sqlrepo_test")
+	s.DB, err = sql.Open("mysql", "root:password@tcp("+dbHost+":"+mappedPort.Port()+")/sqlrepo_test?parseTime=true&loc=UTC")
 
 	s.Require().NoError(err)
 }
@@ -167,13 +173,31 @@ func (s *IntegrationTestSuite) TestEntityRepository_DeleteAll() {
 	s.Require().NoError(err)
 
 	err = repo.DeleteAll()
+	s.Assert().ErrorIs(err, ErrDeleteAllUnconfirmed)
+	result, err := repo.FindAll()
 	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
 
-	result, err := repo.FindAll()
+	err = repo.DeleteAll(ConfirmFullDelete())
+	s.Assert().NoError(err)
+
+	result, err = repo.FindAll()
 	s.Assert().NoError(err)
 	s.Assert().Len(result, 0)
 }
 
+func (s *IntegrationTestSuite) TestEntityRepository_DeleteAllUnguarded() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}})
+	s.Require().NoError(err)
+
+	s.Require().NoError(repo.DeleteAllUnguarded())
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Empty(result)
+}
+
 func (s *IntegrationTestSuite) TestEntityRepository_DeleteByIDs() {
 	repo := NewEntityRepository[SampleEntity](s.DB)
 	CreateSampleEntityTable(s.T(), s.DB)
@@ -273,3 +297,1827 @@ func (s *IntegrationTestSuite) TestEntityRepository_FindAllPaginated() {
 	s.Assert().Equal(result.TotalCount, 2)
 	s.Assert().Equal(result.Results[0].Name, "test2")
 }
+
+// TestEntityRepository_ConcurrentUse shares one repository, with every option
+// keeping state across calls enabled, between goroutines reading and writing
+// through it. Run it with -race.
+func (s *IntegrationTestSuite) TestEntityRepository_ConcurrentUse() {
+	repo := NewEntityRepository[SampleEntity](s.DB,
+		WithStatementCache(4),
+		WithQueryCapture(),
+		WithChangeTracking(),
+		WithEntityCache(time.Minute, StaleWhileRevalidate(time.Minute)),
+		WithEntityPool(),
+	)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			entity := SampleEntity{Name: fmt.Sprintf("test%d", i)}
+			if err := repo.Save(&entity); err != nil {
+				errs <- err
+				return
+			}
+			found, err := repo.FindByID(entity.GetID())
+			if err != nil {
+				errs <- err
+				return
+			}
+			if found.Name != entity.Name {
+				errs <- fmt.Errorf("expected %q, got %q", entity.Name, found.Name)
+				return
+			}
+			found.Name += " updated"
+			if err := repo.Update(found); err != nil {
+				errs <- err
+				return
+			}
+			if _, err := repo.FindAll(); err != nil {
+				errs <- err
+				return
+			}
+			if err := repo.Stream(func(*SampleEntity) error { return nil }); err != nil {
+				errs <- err
+				return
+			}
+			repo.LastQuery()
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		s.Assert().NoError(err)
+	}
+
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 50)
+	for _, entity := range result {
+		s.Assert().True(strings.HasSuffix(entity.Name, " updated"), entity.Name)
+	}
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_TimestampPrecision() {
+	repo := NewEntityRepository[TimestampEntity](s.DB)
+	CreateTimestampEntityTable(s.T(), s.DB)
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	entity := TimestampEntity{CreatedAt: createdAt}
+
+	err := repo.Save(&entity)
+	s.Require().NoError(err)
+
+	result, err := repo.FindByID(entity.GetID())
+	s.Assert().NoError(err)
+	s.Assert().Equal(createdAt.Truncate(time.Microsecond), result.CreatedAt.UTC())
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindPage() {
+	repo := NewEntityRepository[SampleEntity](s.DB, WithPageTokenSecret([]byte("secret")))
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test3"}})
+	s.Require().NoError(err)
+
+	result, token, err := repo.FindPage("", 2, false)
+	s.Assert().NoError(err)
+	s.Assert().Len(result.Results, 2)
+	s.Assert().Equal(result.TotalCount, 3)
+	s.Assert().Equal(result.Results[0].Name, "test")
+	s.Assert().Equal(result.Results[1].Name, "test2")
+	s.Assert().NotEmpty(token)
+
+	result, token, err = repo.FindPage(token, 2, false)
+	s.Assert().NoError(err)
+	s.Assert().Len(result.Results, 1)
+	s.Assert().Equal(result.Results[0].Name, "test3")
+	s.Assert().Empty(token)
+
+	_, _, err = repo.FindPage("tampered.token", 2, false)
+	s.Assert().ErrorIs(err, ErrInvalidPageToken)
+
+	result, token, err = repo.FindPage("", 2, true)
+	s.Assert().NoError(err)
+	s.Assert().Equal([]string{"test3", "test2"}, []string{result.Results[0].Name, result.Results[1].Name})
+
+	result, token, err = repo.FindPage(token, 2, false)
+	s.Assert().NoError(err)
+	s.Assert().Len(result.Results, 1)
+	s.Assert().Equal("test", result.Results[0].Name)
+	s.Assert().Empty(token)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SaveAllBindsByColumnName() {
+	repo := NewEntityRepository[ReorderedEntity](s.DB)
+	CreateReorderedEntityTable(s.T(), s.DB)
+	entity := ReorderedEntity{Name: "test", Email: "test@example.com"}
+	entityTwo := ReorderedEntity{Name: "test2", Email: "test2@example.com"}
+
+	err := repo.SaveAll([]*ReorderedEntity{&entity, &entityTwo})
+	s.Require().NoError(err)
+
+	var name, email string
+	err = s.DB.QueryRow("SELECT name, email FROM reordered_entities WHERE id = ?", entityTwo.GetID()).Scan(&name, &email)
+	s.Require().NoError(err)
+	s.Assert().Equal("test2", name)
+	s.Assert().Equal("test2@example.com", email)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_RunInTx() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	err := repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		return tx.Save(&SampleEntity{Name: "test"})
+	})
+	s.Assert().NoError(err)
+
+	err = repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		s.Require().NoError(tx.Save(&SampleEntity{Name: "test2"}))
+		return fmt.Errorf("rollback")
+	})
+	s.Assert().EqualError(err, "rollback")
+
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal(result[0].Name, "test")
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindByIDForUpdateNoWait() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	id, err := InsertRecordsToSampleEntity(s.DB, SampleEntity{Name: "test"})
+	s.Require().NoError(err)
+
+	lockingTx, err := s.DB.Begin()
+	s.Require().NoError(err)
+	defer lockingTx.Rollback()
+	_, err = lockingTx.Exec("SELECT * FROM sample_entities WHERE id = ? FOR UPDATE", id)
+	s.Require().NoError(err)
+
+	err = repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		_, err := tx.FindByIDForUpdateNoWait(id)
+		return err
+	})
+	s.Assert().ErrorIs(err, ErrLockNotAvailable)
+
+	err = repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		_, err := tx.FindByIDForUpdate(id, LockTimeout(time.Second))
+		return err
+	})
+	s.Assert().ErrorIs(err, ErrLockNotAvailable)
+
+	err = repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		_, err := tx.FindByIDForUpdate(id, LockOf("sample_entities"), NoWait())
+		return err
+	})
+	s.Assert().ErrorIs(err, ErrLockNotAvailable)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Edit() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	id, err := InsertRecordsToSampleEntity(s.DB, SampleEntity{Name: "test"})
+	s.Require().NoError(err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Assert().NoError(repo.Edit(id, func(e *SampleEntity) error {
+				e.Name += "!"
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	entity, err := repo.FindByID(id)
+	s.Require().NoError(err)
+	s.Assert().Equal("test!!!!!!!!!!", entity.Name)
+
+	errMutate := errors.New("mutate failed")
+	err = repo.Edit(id, func(e *SampleEntity) error {
+		e.Name = "changed"
+		return errMutate
+	})
+	s.Assert().ErrorIs(err, errMutate)
+	entity, err = repo.FindByID(id)
+	s.Require().NoError(err)
+	s.Assert().Equal("test!!!!!!!!!!", entity.Name)
+
+	err = repo.Edit(id+100, func(*SampleEntity) error { return nil })
+	s.Assert().ErrorIs(err, ErrNotFound)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Edit_Version() {
+	repo := NewEntityRepository[ArticleEntity](s.DB)
+	CreateArticleEntityTable(s.T(), s.DB)
+	article := ArticleEntity{Title: "news", UpdatedAt: time.Now()}
+	s.Require().NoError(repo.Save(&article))
+
+	s.Require().NoError(repo.Edit(article.Id, func(e *ArticleEntity) error {
+		e.Title = "edited"
+		return nil
+	}))
+	stored, err := repo.FindByID(article.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("edited", stored.Title)
+	s.Assert().Equal(int64(1), stored.Version)
+
+	err = repo.Edit(article.Id, func(e *ArticleEntity) error {
+		e.Version = 7
+		return nil
+	})
+	s.Assert().ErrorIs(err, ErrVersionConflict)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SoftDeleteScopes() {
+	repo := NewEntityRepository[SoftDeleteEntity](s.DB)
+	CreateSoftDeleteEntityTable(s.T(), s.DB)
+	entity := SoftDeleteEntity{Name: "test"}
+	entityTwo := SoftDeleteEntity{Name: "test2"}
+	s.Require().NoError(repo.SaveAll([]*SoftDeleteEntity{&entity, &entityTwo}))
+
+	err := repo.DeleteEntity(&entity)
+	s.Assert().NoError(err)
+
+	live, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(live, 1)
+	s.Assert().Equal(live[0].Name, "test2")
+
+	trashed, err := repo.OnlyTrashed().FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(trashed, 1)
+	s.Assert().Equal(trashed[0].Name, "test")
+	s.Assert().NotNil(trashed[0].DeletedAt)
+
+	all, err := repo.WithTrashed().FindAllPaginated(Pagination{Limit: 10})
+	s.Assert().NoError(err)
+	s.Assert().Len(all.Results, 2)
+	s.Assert().Equal(all.TotalCount, 2)
+
+	err = repo.Restore(entity.GetID())
+	s.Assert().NoError(err)
+
+	live, err = repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(live, 2)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DeleteReturning() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	deleted, err := repo.DeleteReturning(map[string]any{"name": "test"})
+	s.Assert().NoError(err)
+	s.Assert().Len(deleted, 1)
+	s.Assert().Equal(deleted[0].Name, "test")
+
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal(result[0].Name, "test2")
+
+	_, err = repo.DeleteReturning(map[string]any{})
+	s.Assert().Error(err)
+
+	_, err = repo.DeleteReturning(map[string]any{"unknown": "test"})
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DeleteByIDsReturning() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test3"}})
+	s.Require().NoError(err)
+
+	deleted, err := repo.DeleteByIDsReturning([]int64{ids[0], ids[2], ids[2] + 100})
+	s.Require().NoError(err)
+	s.Require().Len(deleted, 2)
+	s.Assert().Equal("test", deleted[0].Name)
+	s.Assert().Equal("test3", deleted[1].Name)
+
+	result, err := repo.FindAll()
+	s.Require().NoError(err)
+	s.Require().Len(result, 1)
+	s.Assert().Equal("test2", result[0].Name)
+
+	deleted, err = repo.DeleteByIDsReturning([]int64{ids[0]})
+	s.Require().NoError(err)
+	s.Assert().Empty(deleted)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindBySpec() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "b"}, {Name: "A"}, {Name: "c"}})
+	s.Require().NoError(err)
+
+	result, err := repo.FindBySpec(&QuerySpec{
+		Where:   []Condition{Where("name", "!=", "c")},
+		OrderBy: []OrderClause{{Column: "name", Collation: "utf8mb4_bin"}},
+	})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+	s.Assert().Equal(result[0].Name, "A")
+	s.Assert().Equal(result[1].Name, "b")
+
+	result, err = repo.FindBySpec(&QuerySpec{
+		Where: []Condition{Eq("name", "a").Collate("utf8mb4_0900_ai_ci")},
+	})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal(result[0].Name, "A")
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Stream() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	var names []string
+	err = repo.Stream(func(entity *SampleEntity) error {
+		names = append(names, entity.Name)
+		return nil
+	})
+	s.Assert().NoError(err)
+	s.Assert().Equal([]string{"test", "test2"}, names)
+
+	err = repo.Stream(func(entity *SampleEntity) error {
+		return fmt.Errorf("stop")
+	})
+	s.Assert().EqualError(err, "stop")
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_VerifySchema() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	err := repo.VerifySchema()
+	s.Assert().NoError(err)
+
+	_, err = s.DB.Exec("ALTER TABLE sample_entities ADD COLUMN extra INT NULL")
+	s.Require().NoError(err)
+
+	err = repo.VerifySchema()
+	var mismatch *SchemaMismatchError
+	s.Require().ErrorAs(err, &mismatch)
+	s.Assert().Equal([]string{"extra"}, mismatch.Extra)
+	s.Assert().Empty(mismatch.Missing)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SumGroupedBy() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	err := repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 70},
+		{Customer: "alice", Amount: 50},
+		{Customer: "bob", Amount: 30},
+	})
+	s.Require().NoError(err)
+
+	totals, nulls, err := repo.SumGroupedBy("customer", "amount")
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[string]float64{"alice": 120, "bob": 30}, totals)
+	s.Assert().Nil(nulls)
+
+	totals, _, err = repo.SumGroupedBy("customer", "amount", Where("", ">", 100))
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[string]float64{"alice": 120}, totals)
+
+	_, _, err = repo.SumGroupedBy("customer", "unknown")
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SampleRandom() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test3"}})
+	s.Require().NoError(err)
+
+	result, err := repo.SampleRandom(2)
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	result, err = repo.SampleRandomApprox(3)
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 3)
+
+	_, err = repo.SampleRandom(0)
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindAllMaxRows() {
+	repo := NewEntityRepository[SampleEntity](s.DB, WithMaxFindAllRows(2))
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	_, err = InsertRecordsToSampleEntity(s.DB, SampleEntity{Name: "test3"})
+	s.Require().NoError(err)
+
+	_, err = repo.FindAll()
+	s.Assert().ErrorIs(err, ErrTooManyRows)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SaveAllSummary() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	entity := SampleEntity{Name: "test"}
+	entityTwo := SampleEntity{Name: "test2"}
+
+	result, err := repo.SaveAllSummary([]*SampleEntity{&entity, &entityTwo})
+	s.Assert().NoError(err)
+	s.Assert().Equal(int64(2), result.Inserted)
+	s.Assert().Equal([]int64{entity.GetID(), entityTwo.GetID()}, result.IDs)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertAllSummary() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	result, err := repo.UpsertAllSummary([]*SampleEntity{
+		{Id: ids[0], Name: "changed"},
+		{Id: ids[1], Name: "test2"},
+		{Id: ids[1] + 1, Name: "test3"},
+	})
+	s.Assert().NoError(err)
+	s.Assert().Equal(int64(1), result.Inserted)
+	s.Assert().Equal(int64(1), result.Updated)
+	s.Assert().Equal(int64(1), result.Skipped)
+	s.Assert().Equal([]int64{ids[0], ids[1], ids[1] + 1}, result.IDs)
+
+	entity, err := SelectSampleEntityByID(s.DB, ids[0])
+	s.Require().NoError(err)
+	s.Assert().Equal("changed", entity.Name)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertAllSummary_AutoIncrement() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}})
+	s.Require().NoError(err)
+
+	first := SampleEntity{Name: "new"}
+	second := SampleEntity{Name: "newer"}
+	result, err := repo.UpsertAllSummary([]*SampleEntity{&first, {Id: ids[0], Name: "changed"}, &second})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), result.Inserted)
+	s.Assert().Equal(int64(1), result.Updated)
+	s.Assert().Equal(int64(0), result.Skipped)
+	s.Assert().NotZero(first.Id)
+	s.Assert().NotZero(second.Id)
+	s.Assert().Equal([]int64{first.Id, ids[0], second.Id}, result.IDs)
+
+	entity, err := SelectSampleEntityByID(s.DB, second.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("newer", entity.Name)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertAllSummary_DuplicateIDs() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	result, err := repo.UpsertAllSummary([]*SampleEntity{
+		{Id: 10, Name: "first"},
+		{Id: 10, Name: "second"},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), result.Inserted)
+	s.Assert().Equal(int64(1), result.Updated)
+	s.Assert().Equal(int64(0), result.Skipped)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertAllSummary_SoftDeleted() {
+	repo := NewEntityRepository[SoftDeleteEntity](s.DB)
+	CreateSoftDeleteEntityTable(s.T(), s.DB)
+	entity := SoftDeleteEntity{Name: "deleted"}
+	s.Require().NoError(repo.Save(&entity))
+	s.Require().NoError(repo.DeleteByID(entity.Id))
+
+	result, err := repo.UpsertAllSummary([]*SoftDeleteEntity{{Id: entity.Id, Name: "changed"}, {Id: entity.Id + 1, Name: "new"}})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), result.Inserted)
+	s.Assert().Equal(int64(1), result.Updated)
+	s.Assert().Equal(int64(0), result.Skipped)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertAllSummary_OtherTenant() {
+	CreateTenantEntityTable(s.T(), s.DB)
+	repo := NewEntityRepository[TenantEntity](s.DB, WithTenantColumn("tenant_id"))
+	tenantOne := repo.WithContext(ContextWithTenant(s.Ctx, int64(1)))
+	tenantTwo := repo.WithContext(ContextWithTenant(s.Ctx, int64(2)))
+	entity := TenantEntity{Name: "a"}
+	s.Require().NoError(tenantOne.Save(&entity))
+
+	result, err := tenantTwo.UpsertAllSummary([]*TenantEntity{{Id: entity.Id, Name: "hijacked"}, {Id: entity.Id + 1, Name: "b"}})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), result.Inserted)
+	s.Assert().Equal(int64(0), result.Updated)
+	s.Assert().Equal(int64(1), result.Skipped)
+
+	stored, err := tenantOne.FindByID(entity.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("a", stored.Name)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_InPartitions() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	_, err := s.DB.Exec(`CREATE TABLE sample_entities (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL
+	) PARTITION BY RANGE (id) (
+		PARTITION p0 VALUES LESS THAN (3),
+		PARTITION p1 VALUES LESS THAN MAXVALUE
+	)`)
+	s.Require().NoError(err)
+
+	_, err = InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test3"}})
+	s.Require().NoError(err)
+
+	partitioned, err := repo.InPartitions("p0")
+	s.Require().NoError(err)
+
+	result, err := partitioned.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	partitioned, err = repo.InPartitions("p1")
+	s.Require().NoError(err)
+	s.Assert().NoError(partitioned.DeleteAll(ConfirmFullDelete()))
+
+	result, err = repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	_, err = repo.InPartitions("p0`; DROP TABLE sample_entities; --")
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_NameMapper() {
+	repo := NewEntityRepository[UntaggedEntity](s.DB)
+	CreateUntaggedEntityTable(s.T(), s.DB)
+	entity := UntaggedEntity{FirstName: "Ada", LastName: "Lovelace"}
+
+	err := repo.Save(&entity)
+	s.Require().NoError(err)
+
+	result, err := repo.FindByID(entity.GetID())
+	s.Assert().NoError(err)
+	s.Assert().Equal("Ada", result.FirstName)
+	s.Assert().Equal("Lovelace", result.LastName)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_QueryRows() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	rows, err := repo.QueryRows(map[string]any{"name": "test2"})
+	s.Require().NoError(err)
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var entity SampleEntity
+		s.Require().NoError(rows.StructScan(&entity))
+		names = append(names, entity.Name)
+	}
+	s.Assert().NoError(rows.Err())
+	s.Assert().Equal([]string{"test2"}, names)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindAllByIDWhere() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test"}})
+	s.Require().NoError(err)
+
+	result, err := repo.FindAllByIDWhere(ids[:2], map[string]any{"name": "test"})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal(result[0].GetID(), ids[0])
+
+	_, err = repo.FindAllByIDWhere(ids, map[string]any{"unknown": "test"})
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SaveAppliesDefaults() {
+	repo := NewEntityRepository[DefaultsEntity](s.DB)
+	CreateDefaultsEntityTable(s.T(), s.DB)
+	entity := DefaultsEntity{}
+	entityTwo := DefaultsEntity{Status: "archived", Priority: 1, Source: "import"}
+
+	err := repo.SaveAll([]*DefaultsEntity{&entity, &entityTwo})
+	s.Require().NoError(err)
+	s.Assert().Equal("active", entity.Status)
+	s.Assert().Equal(3, entity.Priority)
+	s.Assert().Equal("api", entity.Source)
+
+	result, err := repo.FindAllByID([]int64{entity.GetID(), entityTwo.GetID()})
+	s.Assert().NoError(err)
+	s.Assert().Equal("active", result[0].Status)
+	s.Assert().Equal(3, result[0].Priority)
+	s.Assert().Equal("api", result[0].Source)
+	s.Assert().Equal("archived", result[1].Status)
+	s.Assert().Equal(1, result[1].Priority)
+	s.Assert().Equal("import", result[1].Source)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ExistsByIDs() {
+	repo := NewEntityRepository[SampleEntity](s.DB, WithExistencePrefilter(100, 0.01))
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	result, err := repo.ExistsByIDs([]int64{ids[0], ids[1], 999})
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[int64]bool{ids[0]: true, ids[1]: true, 999: false}, result)
+
+	entity := SampleEntity{Name: "test3"}
+	s.Require().NoError(repo.Save(&entity))
+	result, err = repo.ExistsByIDs([]int64{entity.GetID()})
+	s.Assert().NoError(err)
+	s.Assert().True(result[entity.GetID()])
+
+	outside, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test4"}})
+	s.Require().NoError(err)
+	s.Require().NoError(repo.RefreshExistenceFilter())
+	result, err = repo.ExistsByIDs(outside)
+	s.Assert().NoError(err)
+	s.Assert().True(result[outside[0]])
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpdateChangedColumns() {
+	repo := NewEntityRepository[OrderEntity](s.DB, WithChangeTracking())
+	CreateOrderEntityTable(s.T(), s.DB)
+	order := OrderEntity{Customer: "alice", Amount: 10}
+	s.Require().NoError(repo.Save(&order))
+
+	loaded, err := repo.FindByID(order.GetID())
+	s.Require().NoError(err)
+
+	_, err = s.DB.Exec("UPDATE order_entities SET customer = 'bob' WHERE id = ?", order.GetID())
+	s.Require().NoError(err)
+
+	loaded.Amount = 20
+	s.Assert().NoError(repo.Update(loaded))
+
+	result, err := NewEntityRepository[OrderEntity](s.DB).FindByID(order.GetID())
+	s.Assert().NoError(err)
+	s.Assert().Equal("bob", result.Customer)
+	s.Assert().Equal(int64(20), result.Amount)
+
+	repo.Untrack(order.GetID())
+	s.Assert().NoError(repo.Update(loaded))
+	result, err = repo.FindByID(order.GetID())
+	s.Assert().NoError(err)
+	s.Assert().Equal("alice", result.Customer)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ReadSnapshot() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	_, err := InsertRecordsToSampleEntity(s.DB, SampleEntity{Name: "test"})
+	s.Require().NoError(err)
+
+	err = repo.ReadSnapshot(func(snapshot Repository[SampleEntity, int64]) error {
+		before, err := snapshot.FindAll()
+		s.Require().NoError(err)
+
+		_, err = InsertRecordsToSampleEntity(s.DB, SampleEntity{Name: "test2"})
+		s.Require().NoError(err)
+
+		after, err := snapshot.FindAll()
+		s.Require().NoError(err)
+		s.Assert().Len(after, len(before))
+
+		s.Assert().Error(snapshot.Save(&SampleEntity{Name: "test3"}))
+		return nil
+	})
+	s.Assert().NoError(err)
+
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	err = repo.RunInTx(func(tx TxRepository[SampleEntity, int64]) error {
+		return tx.ReadSnapshot(func(Repository[SampleEntity, int64]) error { return nil })
+	})
+	s.Assert().ErrorIs(err, ErrNestedSnapshot)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_CountGroupedBy() {
+	type customer string
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	err := repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 10},
+		{Customer: "alice", Amount: 20},
+		{Customer: "bob", Amount: 10},
+	})
+	s.Require().NoError(err)
+
+	counts, nulls, err := repo.CountGroupedBy("customer")
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[string]int64{"alice": 2, "bob": 1}, counts)
+	s.Assert().Zero(nulls)
+
+	byCustomer, nulls, err := CountGroupedByTyped[customer](repo, "customer")
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[customer]int64{"alice": 2, "bob": 1}, byCustomer)
+	s.Assert().Zero(nulls)
+
+	byAmount, _, err := CountGroupedByTyped[int64](repo, "amount")
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[int64]int64{10: 2, 20: 1}, byAmount)
+
+	_, _, err = CountGroupedByTyped[string](repo, "unknown")
+	s.Assert().Error(err)
+
+	softDeleteRepo := NewEntityRepository[SoftDeleteEntity](s.DB)
+	CreateSoftDeleteEntityTable(s.T(), s.DB)
+	s.Require().NoError(softDeleteRepo.SaveAll([]*SoftDeleteEntity{{Name: "a"}, {Name: "b"}}))
+	byDeletedAt, nulls, err := CountGroupedByTyped[time.Time](softDeleteRepo, "deleted_at")
+	s.Assert().NoError(err)
+	s.Assert().Empty(byDeletedAt)
+	s.Assert().Equal(int64(2), nulls)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindByColumnNotIn() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test3"}})
+	s.Require().NoError(err)
+
+	result, err := repo.FindByColumnNotIn("name", []any{"test", "test3"})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal("test2", result[0].Name)
+
+	result, err = repo.FindByColumnNotIn("name", nil)
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 3)
+
+	_, err = repo.FindByColumnNotIn("unknown", []any{"test"})
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestRunUnitOfWork() {
+	CreateSampleEntityTable(s.T(), s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+
+	err := RunUnitOfWork(s.Ctx, s.DB, func(uow *UnitOfWork) error {
+		s.Require().NoError(Repo[SampleEntity](uow).Save(&SampleEntity{Name: "test"}))
+		return Repo[OrderEntity](uow).Save(&OrderEntity{Customer: "test", Amount: 10})
+	})
+	s.Assert().NoError(err)
+
+	err = RunUnitOfWork(s.Ctx, s.DB, func(uow *UnitOfWork) error {
+		s.Require().NoError(Repo[SampleEntity](uow).Save(&SampleEntity{Name: "test2"}))
+		s.Require().NoError(Repo[OrderEntity](uow).Save(&OrderEntity{Customer: "test2", Amount: 20}))
+		return fmt.Errorf("rollback")
+	})
+	s.Assert().EqualError(err, "rollback")
+
+	samples, err := NewEntityRepository[SampleEntity](s.DB).FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(samples, 1)
+	orders, err := NewEntityRepository[OrderEntity](s.DB).FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(orders, 1)
+}
+
+func (s *IntegrationTestSuite) TestRunUnitOfWork_NameMapper() {
+	CreateUntaggedEntityTable(s.T(), s.DB)
+
+	err := RunUnitOfWork(s.Ctx, s.DB, func(uow *UnitOfWork) error {
+		repo := Repo[UntaggedEntity](uow)
+		if err := repo.Save(&UntaggedEntity{FirstName: "Ada", LastName: "Lovelace"}); err != nil {
+			return err
+		}
+		entities, err := repo.FindAll()
+		if err != nil {
+			return err
+		}
+		s.Require().Len(entities, 1)
+		s.Assert().Equal("Lovelace", entities[0].LastName)
+		return nil
+	})
+	s.Assert().NoError(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ExistsByColumnValues() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	err := repo.SaveAll([]*OrderEntity{{Customer: "alice", Amount: 10}, {Customer: "bob", Amount: 20}})
+	s.Require().NoError(err)
+
+	result, err := repo.ExistsByColumnValues("customer", []any{"alice", "carol"})
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[any]bool{"alice": true, "carol": false}, result)
+
+	result, err = repo.ExistsByColumnValues("amount", []any{20, 30})
+	s.Assert().NoError(err)
+	s.Assert().Equal(map[any]bool{20: true, 30: false}, result)
+
+	_, err = repo.ExistsByColumnValues("unknown", []any{"alice"})
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ColumnTransformer() {
+	repo := NewEntityRepository[CompressedEntity](s.DB, WithColumnTransformer("body", gzipTransformer{}))
+	CreateCompressedEntityTable(s.T(), s.DB)
+
+	entity := CompressedEntity{Body: "hello hello hello"}
+	s.Require().NoError(repo.Save(&entity))
+
+	var stored []byte
+	s.Require().NoError(s.DB.QueryRow("SELECT body FROM compressed_entities WHERE id = ?", entity.GetID()).Scan(&stored))
+	s.Assert().NotEqual([]byte(entity.Body), stored)
+
+	result, err := repo.FindByID(entity.GetID())
+	s.Assert().NoError(err)
+	s.Assert().Equal("hello hello hello", result.Body)
+
+	var streamed []string
+	err = repo.Stream(func(e *CompressedEntity) error {
+		streamed = append(streamed, e.Body)
+		return nil
+	})
+	s.Assert().NoError(err)
+	s.Assert().Equal([]string{"hello hello hello"}, streamed)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindOldestForUpdate() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	err := repo.SaveAll([]*OrderEntity{
+		{Customer: "c", Amount: 3},
+		{Customer: "a", Amount: 1},
+		{Customer: "b", Amount: 2},
+	})
+	s.Require().NoError(err)
+
+	lockingTx, err := s.DB.Begin()
+	s.Require().NoError(err)
+	defer lockingTx.Rollback()
+	_, err = lockingTx.Exec("SELECT * FROM order_entities WHERE amount = 1 FOR UPDATE")
+	s.Require().NoError(err)
+
+	err = repo.RunInTx(func(tx TxRepository[OrderEntity, int64]) error {
+		result, err := tx.FindOldestForUpdate(2, "amount")
+		s.Require().NoError(err)
+		s.Assert().Len(result, 2)
+		s.Assert().Equal(int64(2), result[0].Amount)
+		s.Assert().Equal(int64(3), result[1].Amount)
+
+		_, err = tx.FindOldestForUpdate(0, "amount")
+		s.Assert().Error(err)
+		_, err = tx.FindOldestForUpdate(1, "unknown")
+		s.Assert().Error(err)
+		return nil
+	})
+	s.Assert().NoError(err)
+
+	_, err = repo.(TxRepository[OrderEntity, int64]).FindOldestForUpdate(1, "amount")
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_AutoIncrementCheck() {
+	repo := NewEntityRepository[SampleEntity](s.DB, WithAutoIncrementCheck())
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	var lockMode int
+	s.Require().NoError(s.DB.QueryRow("SELECT @@innodb_autoinc_lock_mode").Scan(&lockMode))
+
+	s.Assert().NoError(repo.Save(&SampleEntity{Name: "test"}))
+
+	err := repo.SaveAll([]*SampleEntity{{Name: "test2"}, {Name: "test3"}})
+	if lockMode == 2 {
+		s.Assert().ErrorIs(err, ErrAutoIncrementIDs)
+	} else {
+		s.Assert().NoError(err)
+	}
+}
+
+func (s *IntegrationTestSuite) TestFindAllComputed() {
+	type orderWithTax struct {
+		OrderEntity
+		Tax      float64 `db:"tax"`
+		Shouting string  `db:"shouting"`
+	}
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	err := repo.SaveAll([]*OrderEntity{{Customer: "alice", Amount: 100}, {Customer: "bob", Amount: 50}})
+	s.Require().NoError(err)
+
+	exprs := []SelectExpr{{Expr: "amount * 0.2", As: "tax"}, {Expr: "UPPER(customer)", As: "shouting"}}
+	result, err := FindAllComputed[orderWithTax](repo, exprs, map[string]any{"customer": "alice"})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal("alice", result[0].Customer)
+	s.Assert().Equal(20.0, result[0].Tax)
+	s.Assert().Equal("ALICE", result[0].Shouting)
+
+	_, err = FindAllComputed[orderWithTax](repo, []SelectExpr{{Expr: "1", As: "amount"}}, nil)
+	s.Assert().Error(err)
+	_, err = FindAllComputed[orderWithTax](repo, []SelectExpr{{Expr: "1", As: "x; DROP TABLE y"}}, nil)
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DeleteEntitiesStrict() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	entities := []*SampleEntity{{Name: "test"}, {Name: "test2"}, {Name: "test3"}}
+	s.Require().NoError(repo.SaveAll(entities))
+
+	s.Require().NoError(repo.DeleteEntity(entities[0]))
+	err := repo.DeleteEntitiesStrict(entities)
+	s.Assert().ErrorIs(err, ErrEntitiesMissing)
+
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	s.Assert().NoError(repo.DeleteEntitiesStrict(entities[1:]))
+	result, err = repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Empty(result)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindBySpecExists() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	_, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "alice"}, {Name: "bob"}})
+	s.Require().NoError(err)
+	s.Require().NoError(NewEntityRepository[OrderEntity](s.DB).Save(&OrderEntity{Customer: "alice", Amount: 10}))
+
+	result, err := repo.FindBySpec(&QuerySpec{Where: []Condition{
+		Exists("order_entities", "order_entities.customer = sample_entities.name AND order_entities.amount > ?", 5),
+	}})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal("alice", result[0].Name)
+
+	result, err = repo.FindBySpec(&QuerySpec{Where: []Condition{
+		NotExists("order_entities", "order_entities.customer = sample_entities.name"),
+	}})
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal("bob", result[0].Name)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_GlobalScopes() {
+	CreateTenantEntityTable(s.T(), s.DB)
+	_, err := s.DB.Exec("INSERT INTO tenant_entities (tenant_id, name) VALUES (1, 'a'), (1, 'b'), (2, 'c')")
+	s.Require().NoError(err)
+
+	repo := NewEntityRepository[ScopedTenantEntity](s.DB).WithContext(context.WithValue(s.Ctx, tenantKey{}, int64(1)))
+	result, err := repo.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 2)
+
+	result, err = NewEntityRepository[ScopedTenantEntity](s.DB).FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Empty(result)
+
+	result, err = repo.WithoutGlobalScopes().FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 3)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_TenantIsolation() {
+	CreateTenantEntityTable(s.T(), s.DB)
+	repo := NewEntityRepository[TenantEntity](s.DB, WithTenantColumn("tenant_id"))
+	tenantOne := repo.WithContext(ContextWithTenant(s.Ctx, int64(1)))
+	tenantTwo := repo.WithContext(ContextWithTenant(s.Ctx, int64(2)))
+
+	entity := TenantEntity{Name: "a"}
+	s.Require().NoError(tenantOne.Save(&entity))
+	s.Assert().Equal(int64(1), entity.TenantID)
+	s.Require().NoError(tenantTwo.Save(&TenantEntity{Name: "b"}))
+
+	result, err := tenantOne.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal("a", result[0].Name)
+
+	_, err = tenantTwo.FindByID(entity.GetID())
+	s.Assert().Error(err)
+
+	s.Assert().NoError(tenantTwo.DeleteByID(entity.GetID()))
+	s.Assert().NoError(tenantTwo.UpsertAll([]*TenantEntity{{Id: entity.GetID(), TenantID: 2, Name: "hijacked"}}))
+	result, err = tenantOne.FindAll()
+	s.Assert().NoError(err)
+	s.Assert().Len(result, 1)
+	s.Assert().Equal("a", result[0].Name)
+
+	_, err = repo.FindAll()
+	s.Assert().ErrorIs(err, ErrNoTenant)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindCursor() {
+	repo := NewEntityRepository[SampleEntity](s.DB, WithPageTokenSecret([]byte("secret")))
+	CreateSampleEntityTable(s.T(), s.DB)
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "1"}, {Name: "2"}, {Name: "3"}, {Name: "4"}, {Name: "5"}})
+	s.Require().NoError(err)
+
+	first, err := repo.FindCursor("", 2)
+	s.Require().NoError(err)
+	s.Assert().Equal(ids[0], first.Results[0].Id)
+	s.Assert().Empty(first.PrevCursor)
+	s.Require().NotEmpty(first.NextCursor)
+
+	second, err := repo.FindCursor(first.NextCursor, 2)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{ids[2], ids[3]}, []int64{second.Results[0].Id, second.Results[1].Id})
+	s.Require().NotEmpty(second.PrevCursor)
+
+	last, err := repo.FindCursor(second.NextCursor, 2)
+	s.Require().NoError(err)
+	s.Assert().Len(last.Results, 1)
+	s.Assert().Empty(last.NextCursor)
+
+	back, err := repo.FindCursor(second.PrevCursor, 2)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{ids[0], ids[1]}, []int64{back.Results[0].Id, back.Results[1].Id})
+	s.Assert().Empty(back.PrevCursor)
+	s.Assert().Equal(first.NextCursor, back.NextCursor)
+
+	_, err = repo.FindCursor("forged", 2)
+	s.Assert().ErrorIs(err, ErrInvalidPageToken)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindAllPaginatedColumns() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	err := repo.SaveAll([]*OrderEntity{{Customer: "alice", Amount: 10}, {Customer: "bob", Amount: 20}, {Customer: "carol", Amount: 30}})
+	s.Require().NoError(err)
+
+	page, err := repo.FindAllPaginatedColumns(Pagination{Limit: 2}, []string{"id", "customer"})
+	s.Assert().NoError(err)
+	s.Assert().Equal(3, page.TotalCount)
+	s.Assert().Len(page.Results, 2)
+	s.Assert().Equal("alice", page.Results[0].Customer)
+	s.Assert().NotZero(page.Results[0].Id)
+	s.Assert().Zero(page.Results[0].Amount)
+
+	_, err = repo.FindAllPaginatedColumns(Pagination{Limit: 2}, []string{"customer", "secret"})
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpdateSkipsProtectedColumns() {
+	repo := NewEntityRepository[AccountEntity](s.DB)
+	CreateAccountEntityTable(s.T(), s.DB)
+	account := AccountEntity{Name: "alice", Email: "alice@example.com", Role: "user"}
+	s.Require().NoError(repo.Save(&account))
+
+	submitted := AccountEntity{Id: account.Id, Name: "mallory", Email: "mallory@example.com", Role: "admin"}
+	s.Assert().NoError(repo.Update(&submitted))
+	s.Assert().NoError(repo.UpsertAll([]*AccountEntity{&submitted}))
+
+	result, err := repo.FindByID(account.Id)
+	s.Assert().NoError(err)
+	s.Assert().Equal("mallory", result.Name)
+	s.Assert().Equal("alice@example.com", result.Email)
+	s.Assert().Equal("user", result.Role)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindPageByNullableColumn() {
+	repo := NewEntityRepository[ScoredEntity](s.DB, WithPageTokenSecret([]byte("secret")))
+	CreateScoredEntityTable(s.T(), s.DB)
+	_, err := s.DB.Exec("INSERT INTO scored_entities (score) VALUES (2), (NULL), (1), (NULL), (2), (NULL), (3)")
+	s.Require().NoError(err)
+
+	pageAll := func(order KeysetOrder) []string {
+		var seen []string
+		token := ""
+		for {
+			page, next, err := repo.FindPageBy(order, token, 2)
+			s.Require().NoError(err)
+			s.Require().Equal(7, page.TotalCount)
+			for _, e := range page.Results {
+				if e.Score == nil {
+					seen = append(seen, fmt.Sprintf("null/%d", e.Id))
+				} else {
+					seen = append(seen, fmt.Sprintf("%d/%d", *e.Score, e.Id))
+				}
+			}
+			if next == "" {
+				return seen
+			}
+			token = next
+		}
+	}
+
+	s.Assert().Equal([]string{"null/2", "null/4", "null/6", "1/3", "2/1", "2/5", "3/7"},
+		pageAll(KeysetOrder{Column: "score", Nulls: NullsFirst}))
+	s.Assert().Equal([]string{"3/7", "2/5", "2/1", "1/3", "null/6", "null/4", "null/2"},
+		pageAll(KeysetOrder{Column: "score", Desc: true, Nulls: NullsLast}))
+	s.Assert().Equal([]string{"1/3", "2/1", "2/5", "3/7", "null/2", "null/4", "null/6"},
+		pageAll(KeysetOrder{Column: "score", Nulls: NullsLast}))
+	s.Assert().Equal([]string{"null/6", "null/4", "null/2", "3/7", "2/5", "2/1", "1/3"},
+		pageAll(KeysetOrder{Column: "score", Desc: true, Nulls: NullsFirst}))
+
+	_, _, err = repo.FindPageBy(KeysetOrder{Column: "unknown"}, "", 2)
+	s.Assert().Error(err)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ChangedOnlyUpsert() {
+	repo := NewEntityRepository[OrderEntity](s.DB, WithChangedOnlyUpsert("amount"))
+	CreateOrderEntityTable(s.T(), s.DB)
+	first := OrderEntity{Customer: "alice", Amount: 1}
+	second := OrderEntity{Customer: "bob", Amount: 1}
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{&first, &second}))
+
+	result, err := repo.UpsertAllSummary([]*OrderEntity{
+		{Id: first.Id, Customer: "alice", Amount: 2},
+		{Id: second.Id, Customer: "carol", Amount: 2},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), result.Updated)
+	s.Assert().Equal(int64(1), result.Skipped)
+
+	entity, err := repo.FindByID(first.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), entity.Amount)
+	entity, err = repo.FindByID(second.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("carol", entity.Customer)
+	s.Assert().Equal(int64(2), entity.Amount)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_CountByDateBucket() {
+	repo := NewEntityRepository[TimestampEntity](s.DB)
+	CreateTimestampEntityTable(s.T(), s.DB)
+	for _, at := range []time.Time{
+		time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 6, 23, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		s.Require().NoError(repo.Save(&TimestampEntity{CreatedAt: at}))
+	}
+
+	days, err := repo.CountByDateBucket("created_at", BucketDay)
+	s.Require().NoError(err)
+	s.Assert().Len(days, 3)
+
+	weeks, err := repo.CountByDateBucket("created_at", BucketWeek)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), weeks[time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)])
+
+	months, err := repo.CountByDateBucket("created_at", BucketMonth)
+	s.Require().NoError(err)
+	s.Assert().Equal(map[time.Time]int64{
+		time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC): 2,
+		time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC): 1,
+	}, months)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Duplicate() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	original := SampleEntity{Name: "template"}
+	s.Require().NoError(repo.Save(&original))
+
+	duplicate, err := repo.Duplicate(original.Id, map[string]any{"name": "copy"})
+	s.Require().NoError(err)
+	s.Assert().NotEqual(original.Id, duplicate.Id)
+	s.Assert().Equal("copy", duplicate.Name)
+
+	stored, err := repo.FindByID(duplicate.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("copy", stored.Name)
+	stored, err = repo.FindByID(original.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("template", stored.Name)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ListGroupedBy() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 20},
+		{Customer: "bob", Amount: 5},
+		{Customer: "alice", Amount: 10},
+	}))
+
+	lists, nulls, err := repo.ListGroupedBy("customer", "amount")
+	s.Require().NoError(err)
+	s.Assert().Empty(nulls)
+	s.Assert().Equal(map[string][]string{
+		"alice": {"10", "20"},
+		"bob":   {"5"},
+	}, lists)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindRanked() {
+	repo := NewEntityRepository[PlayerEntity](s.DB)
+	CreatePlayerEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*PlayerEntity{
+		{Name: "alice", Score: 10, Rank: 99},
+		{Name: "bob", Score: 30},
+		{Name: "carol", Score: 20},
+	}))
+
+	players, err := repo.FindRanked("rank", OrderClause{Column: "score", Desc: true}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(players, 3)
+	for i, name := range []string{"bob", "carol", "alice"} {
+		s.Assert().Equal(name, players[i].Name)
+		s.Assert().Equal(int64(i+1), players[i].Rank)
+	}
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_StrictFindByID() {
+	_, err := s.DB.Exec(`CREATE TABLE sample_entities (
+		id BIGINT NOT NULL,
+		name VARCHAR(255) NOT NULL
+	)`)
+	s.Require().NoError(err)
+	_, err = s.DB.Exec("INSERT INTO sample_entities (id, name) VALUES (1, 'first'), (1, 'second')")
+	s.Require().NoError(err)
+
+	entity, err := NewEntityRepository[SampleEntity](s.DB).FindByID(1)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), entity.Id)
+
+	_, err = NewEntityRepository[SampleEntity](s.DB, WithStrictFindByID()).FindByID(1)
+	s.Assert().ErrorIs(err, ErrTooManyRows)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DiffUpdate() {
+	repo := NewEntityRepository[AccountEntity](s.DB)
+	CreateAccountEntityTable(s.T(), s.DB)
+	account := AccountEntity{Name: "alice", Email: "alice@example.com", Role: "user"}
+	s.Require().NoError(repo.Save(&account))
+
+	account.Name = "alicia"
+	account.Email = "alicia@example.com"
+	account.Role = "admin"
+	changes, err := repo.DiffUpdate(&account)
+	s.Require().NoError(err)
+	s.Assert().Equal(map[string]ColumnChange{"name": {Old: "alice", New: "alicia"}}, changes)
+
+	stored, err := repo.FindByID(account.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("alice", stored.Name)
+
+	_, err = repo.DiffUpdate(&AccountEntity{Id: account.Id + 1})
+	s.Assert().ErrorIs(err, ErrNotFound)
+	_, err = repo.FindByID(account.Id + 1)
+	s.Assert().ErrorIs(err, ErrNotFound)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindAllByIDStrict() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "test"}, {Name: "test2"}})
+	s.Require().NoError(err)
+
+	entities, err := repo.FindAllByIDStrict(ids)
+	s.Require().NoError(err)
+	s.Assert().Len(entities, 2)
+
+	_, err = repo.FindAllByIDStrict([]int64{ids[0], ids[1] + 1, ids[1] + 1, ids[1] + 2})
+	var missing *MissingIDsError[int64]
+	s.Require().ErrorAs(err, &missing)
+	s.Assert().Equal([]int64{ids[1] + 1, ids[1] + 2}, missing.IDs)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertMergeStrategy() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	order := OrderEntity{Customer: "alice", Amount: 10}
+	s.Require().NoError(repo.Save(&order))
+
+	err := repo.Upsert([]*OrderEntity{{Id: order.Id, Customer: "bob", Amount: 5}}, MergeStrategy{"amount": GreatestWins})
+	s.Require().NoError(err)
+
+	stored, err := repo.FindByID(order.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("bob", stored.Customer)
+	s.Assert().Equal(int64(10), stored.Amount)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_QueryInto() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 10},
+		{Customer: "alice", Amount: 5},
+		{Customer: "bob", Amount: 1},
+	}))
+
+	type customerTotal struct {
+		Customer string `db:"customer"`
+		Total    int64  `db:"total"`
+	}
+	totals, err := QueryInto[customerTotal](repo, func(q QueryScope) (string, []any) {
+		query := "SELECT customer, SUM(amount) AS total FROM " + q.Table +
+			" WHERE " + q.Where + " AND amount > ? GROUP BY customer ORDER BY customer"
+		return query, append(q.Args, 1)
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal([]*customerTotal{{Customer: "alice", Total: 15}}, totals)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Touch() {
+	repo := NewEntityRepository[ArticleEntity](s.DB)
+	CreateArticleEntityTable(s.T(), s.DB)
+	article := ArticleEntity{Title: "news", UpdatedAt: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s.Require().NoError(repo.Save(&article))
+
+	for i := 0; i < 2; i++ {
+		touched, err := repo.Touch(article.Id)
+		s.Require().NoError(err)
+		s.Assert().Equal(int64(1), touched)
+	}
+	stored, err := repo.FindByID(article.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal("news", stored.Title)
+	s.Assert().True(stored.UpdatedAt.After(article.UpdatedAt))
+	s.Assert().Equal(int64(2), stored.Version)
+
+	_, err = repo.Touch(article.Id + 1)
+	s.Assert().ErrorIs(err, ErrNotFound)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DeleteByLimit() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	orders := make([]*OrderEntity, 5)
+	for i := range orders {
+		orders[i] = &OrderEntity{Customer: "alice", Amount: int64(i)}
+	}
+	s.Require().NoError(repo.SaveAll(append(orders, &OrderEntity{Customer: "bob"})))
+
+	updated, err := repo.UpdateWhere(map[string]any{"customer": "alice"}, map[string]any{"amount": 9}, 2)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), updated)
+
+	var batches []int64
+	for {
+		deleted, err := repo.DeleteBy(map[string]any{"customer": "alice"}, 2)
+		s.Require().NoError(err)
+		if deleted == 0 {
+			break
+		}
+		batches = append(batches, deleted)
+	}
+	s.Assert().Equal([]int64{2, 2, 1}, batches)
+
+	remaining, err := repo.FindAll()
+	s.Require().NoError(err)
+	s.Require().Len(remaining, 1)
+	s.Assert().Equal("bob", remaining[0].Customer)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindByTuples() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 1},
+		{Customer: "alice", Amount: 2},
+		{Customer: "bob", Amount: 2},
+	}))
+
+	entities, err := repo.FindByTuples([]string{"customer", "amount"}, [][]any{{"alice", 2}, {"bob", 2}, {"carol", 1}})
+	s.Require().NoError(err)
+	s.Require().Len(entities, 2)
+	s.Assert().Equal(int64(2), entities[0].Amount)
+	s.Assert().Equal(int64(2), entities[1].Amount)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_QueryWithCTE() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 10},
+		{Customer: "alice", Amount: 20},
+		{Customer: "bob", Amount: 5},
+	}))
+
+	orders, err := repo.QueryWithCTE(
+		"big_spenders AS (SELECT customer FROM order_entities GROUP BY customer HAVING SUM(amount) > ?)",
+		[]any{15},
+		"customer IN (SELECT customer FROM big_spenders)", nil)
+	s.Require().NoError(err)
+	s.Require().Len(orders, 2)
+	s.Assert().Equal("alice", orders[0].Customer)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_SaveAllMixedIDs() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	imported := SampleEntity{Id: 100, Name: "imported"}
+	first := SampleEntity{Name: "first"}
+	second := SampleEntity{Name: "second"}
+
+	result, err := repo.SaveAllSummary([]*SampleEntity{&first, &imported, &second})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(3), result.Inserted)
+	s.Assert().Equal(int64(100), imported.Id)
+	s.Assert().Equal([]int64{101, 100, 102}, result.IDs)
+
+	for _, entity := range []SampleEntity{imported, first, second} {
+		stored, err := repo.FindByID(entity.Id)
+		s.Require().NoError(err)
+		s.Assert().Equal(entity.Name, stored.Name)
+	}
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Checks() {
+	repo := NewEntityRepository[InventoryEntity](s.DB)
+	CreateInventoryEntityTable(s.T(), s.DB)
+
+	err := repo.Save(&InventoryEntity{Quantity: -1})
+	var violation *CheckViolationError
+	s.Require().ErrorAs(err, &violation)
+	s.Assert().False(violation.FromDatabase)
+
+	item := InventoryEntity{Quantity: 1}
+	s.Require().NoError(repo.Save(&item))
+	_, err = repo.UpdateWhere(map[string]any{"id": item.Id}, map[string]any{"quantity": -1}, 0)
+	s.Require().ErrorAs(err, &violation)
+	s.Assert().True(violation.FromDatabase)
+	s.Assert().Equal("inventory_quantity_chk", violation.Check)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindAllWithList() {
+	repo := NewEntityRepository[PostEntity](s.DB)
+	CreatePostEntityTables(s.T(), s.DB)
+	tagged := PostEntity{Title: "tagged"}
+	untagged := PostEntity{Title: "untagged"}
+	s.Require().NoError(repo.SaveAll([]*PostEntity{&tagged, &untagged}))
+	_, err := s.DB.Exec("INSERT INTO post_tags (post_id, name) VALUES (?, 'sql'), (?, 'go')", tagged.Id, tagged.Id)
+	s.Require().NoError(err)
+
+	posts, err := repo.FindAllWithList(ListJoin{Field: "tags", Table: "post_tags", ForeignKey: "post_id", Column: "name"}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(posts, 2)
+	s.Assert().Equal([]string{"go", "sql"}, posts[0].Tags)
+	s.Assert().Nil(posts[1].Tags)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_LazyByID() {
+	repo := NewEntityRepository[ArticleEntity](s.DB)
+	CreateArticleEntityTable(s.T(), s.DB)
+	article := ArticleEntity{Title: "news"}
+	s.Require().NoError(repo.Save(&article))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lazy := LazyByID[ArticleEntity](ctx, repo, article.Id)
+	loaded, err := lazy.Get()
+	s.Require().NoError(err)
+	s.Assert().Equal("news", loaded.Title)
+
+	cancel()
+	_, err = LazyByID[ArticleEntity](ctx, repo, article.Id).Get()
+	s.Assert().ErrorIs(err, ErrLazyContextDone)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DocumentEntity() {
+	repo := NewEntityRepository[EventEntity](s.DB)
+	CreateEventEntityTable(s.T(), s.DB)
+
+	event := EventEntity{Kind: "created", Payload: "order 1"}
+	s.Require().NoError(repo.Save(&event))
+	event.Kind = "shipped"
+	s.Require().NoError(repo.Update(&event))
+
+	stored, err := repo.FindByID(event.Id)
+	s.Require().NoError(err)
+	s.Assert().Equal(event, *stored)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_MaxID() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	max, err := repo.MaxID()
+	s.Require().NoError(err)
+	s.Assert().Zero(max)
+
+	entities := []*SampleEntity{{Name: "a"}, {Name: "b"}}
+	s.Require().NoError(repo.SaveAll(entities))
+	max, err = repo.MaxID()
+	s.Require().NoError(err)
+	s.Assert().Equal(entities[1].Id, max)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_EstimatedCount() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*SampleEntity{{Name: "a"}, {Name: "b"}}))
+	_, err := s.DB.Exec("ANALYZE TABLE sample_entities")
+	s.Require().NoError(err)
+
+	estimate, err := repo.EstimatedCount()
+	s.Require().NoError(err)
+	s.Assert().InDelta(2, estimate, 2)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpdateWhereReturning() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	orders := []*OrderEntity{{Customer: "alice", Amount: 1}, {Customer: "bob", Amount: 2}, {Customer: "alice", Amount: 3}}
+	s.Require().NoError(repo.SaveAll(orders))
+
+	ids, err := repo.UpdateWhereReturning(map[string]any{"customer": "alice"}, map[string]any{"amount": 0})
+	s.Require().NoError(err)
+	s.Assert().ElementsMatch([]int64{orders[0].Id, orders[2].Id}, ids)
+
+	stored, err := repo.FindByID(orders[1].Id)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), stored.Amount)
+
+	ids, err = repo.UpdateWhereReturning(map[string]any{"customer": "carol"}, map[string]any{"amount": 0})
+	s.Require().NoError(err)
+	s.Assert().Empty(ids)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ContextWithTrashed() {
+	repo := NewEntityRepository[SoftDeleteEntity](s.DB)
+	CreateSoftDeleteEntityTable(s.T(), s.DB)
+	live, deleted := SoftDeleteEntity{Name: "live"}, SoftDeleteEntity{Name: "deleted"}
+	s.Require().NoError(repo.SaveAll([]*SoftDeleteEntity{&live, &deleted}))
+	s.Require().NoError(repo.DeleteByID(deleted.Id))
+
+	ctx := ContextWithTrashed(context.Background())
+	entities, err := repo.WithContext(ctx).FindAll()
+	s.Require().NoError(err)
+	s.Assert().Len(entities, 2)
+
+	found, err := repo.WithContext(ctx).FindByID(deleted.Id)
+	s.Require().NoError(err)
+	s.Assert().NotNil(found.DeletedAt)
+
+	entities, err = repo.FindAll()
+	s.Require().NoError(err)
+	s.Assert().Len(entities, 1)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_UpsertWithCounts() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "a"}, {Name: "b"}})
+	s.Require().NoError(err)
+
+	inserted, updated, err := repo.UpsertWithCounts([]*SampleEntity{
+		{Id: ids[0], Name: "changed"},
+		{Id: ids[1], Name: "b"},
+		{Id: ids[1] + 1, Name: "c"},
+		{Id: ids[1] + 2, Name: "d"},
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), inserted)
+	s.Assert().Equal(int64(1), updated)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Find() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 5},
+		{Customer: "alice", Amount: 15},
+		{Customer: "alice", Amount: 25},
+		{Customer: "bob", Amount: 35},
+	}))
+
+	result, err := repo.Find(FindOptions{
+		Where:      []Condition{Eq("customer", "alice"), Where("amount", ">", 10)},
+		OrderBy:    []OrderClause{{Column: "amount", Desc: true}},
+		Columns:    []string{"id", "amount"},
+		Pagination: &Pagination{Limit: 1},
+		Count:      true,
+	})
+	s.Require().NoError(err)
+	s.Assert().Equal(2, result.TotalCount)
+	s.Require().Len(result.Results, 1)
+	s.Assert().Equal(int64(25), result.Results[0].Amount)
+	s.Assert().Empty(result.Results[0].Customer)
+
+	result, err = repo.Find(FindOptions{})
+	s.Require().NoError(err)
+	s.Assert().Len(result.Results, 4)
+	s.Assert().Zero(result.TotalCount)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Search() {
+	repo := NewEntityRepository[DocEntity](s.DB)
+	CreateDocEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*DocEntity{
+		{Title: "Gardening", Body: "Tomatoes need sun"},
+		{Title: "Databases", Body: "Indexes make database queries fast; a database index is a tree"},
+		{Title: "Cooking", Body: "A database of recipes"},
+	}))
+
+	results, err := repo.Search("database index", []string{"title", "body"})
+	s.Require().NoError(err)
+	s.Require().Len(results, 2)
+	s.Assert().Equal("Databases", results[0].Title)
+	s.Assert().Greater(results[0].Score, results[1].Score)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindByExample() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 0},
+		{Customer: "alice", Amount: 10},
+		{Customer: "bob", Amount: 0},
+	}))
+
+	found, err := repo.FindByExample(&OrderEntity{Customer: "alice"})
+	s.Require().NoError(err)
+	s.Assert().Len(found, 2)
+
+	found, err = repo.FindByExample(&OrderEntity{Customer: "alice"}, "amount")
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Assert().Equal(int64(0), found[0].Amount)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindAllGroupedBy() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "alice", Amount: 20},
+		{Customer: "bob", Amount: 5},
+		{Customer: "alice", Amount: 10},
+	}))
+
+	groups, nulls, err := repo.FindAllGroupedBy("customer", nil, OrderClause{Column: "amount"})
+	s.Require().NoError(err)
+	s.Assert().Empty(nulls)
+	s.Require().Len(groups["alice"], 2)
+	s.Assert().Equal(int64(10), groups["alice"][0].Amount)
+	s.Assert().Equal(int64(20), groups["alice"][1].Amount)
+	s.Assert().Len(groups["bob"], 1)
+
+	byAmount, _, err := FindAllGroupedByTyped[int64](repo, "amount", map[string]any{"customer": "alice"})
+	s.Require().NoError(err)
+	s.Assert().Len(byAmount, 2)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_Explain() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+
+	plan, err := repo.Explain(&QuerySpec{Where: []Condition{Eq("id", 1)}})
+	s.Require().NoError(err)
+	var parsed map[string]any
+	s.Require().NoError(json.Unmarshal([]byte(plan), &parsed))
+	s.Assert().Contains(parsed, "query_block")
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_ReserveIDs() {
+	repo := NewEntityRepository[SampleEntity](s.DB)
+	CreateSampleEntityTable(s.T(), s.DB)
+	CreateSequenceTable(s.T(), s.DB)
+	existing, err := InsertRecordsToSampleEntity(s.DB, SampleEntity{Name: "existing"})
+	s.Require().NoError(err)
+
+	ids, err := repo.ReserveIDs(3)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{existing + 1, existing + 2, existing + 3}, ids)
+
+	more, err := repo.ReserveIDs(2)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{existing + 4, existing + 5}, more)
+
+	entities := []*SampleEntity{{Id: ids[0], Name: "a"}, {Id: more[1], Name: "b"}}
+	s.Require().NoError(repo.SaveAll(entities))
+	found, err := repo.FindByID(more[1])
+	s.Require().NoError(err)
+	s.Assert().Equal("b", found.Name)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_FindWithWindows() {
+	repo := NewEntityRepository[OrderTotalsEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderTotalsEntity{
+		{Customer: "a", Amount: 10},
+		{Customer: "b", Amount: 5},
+		{Customer: "a", Amount: -3},
+		{Customer: "a", Amount: 7},
+	}))
+
+	entries, err := repo.FindWithWindows([]WindowExpr{
+		{As: "running_total", Function: "SUM", Column: "amount", PartitionBy: []string{"customer"}, OrderBy: []OrderClause{{Column: "id"}}},
+		{As: "customer_total", Function: "SUM", Column: "amount", PartitionBy: []string{"customer"}},
+	}, nil, OrderClause{Column: "id"})
+	s.Require().NoError(err)
+	s.Require().Len(entries, 4)
+	for i, want := range [][2]int64{{10, 14}, {5, 5}, {7, 14}, {14, 14}} {
+		s.Assert().Equal(want[0], entries[i].RunningTotal)
+		s.Assert().Equal(want[1], entries[i].CustomerTotal)
+	}
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_RestoreBy() {
+	repo := NewEntityRepository[SoftDeleteEntity](s.DB)
+	CreateSoftDeleteEntityTable(s.T(), s.DB)
+	entities := []*SoftDeleteEntity{{Name: "a"}, {Name: "a"}, {Name: "b"}}
+	s.Require().NoError(repo.SaveAll(entities))
+	s.Require().NoError(repo.DeleteEntities(entities))
+
+	restored, err := repo.RestoreBy(map[string]any{"name": "a"})
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(2), restored)
+	live, err := repo.FindAll()
+	s.Require().NoError(err)
+	s.Assert().Len(live, 2)
+
+	_, err = repo.RestoreBy(nil)
+	s.Assert().ErrorIs(err, ErrRestoreAllUnconfirmed)
+	restored, err = repo.RestoreBy(nil, ConfirmFullRestore())
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), restored)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_WithChildRelation() {
+	CreateSampleEntityTable(s.T(), s.DB)
+	CreateChildEntityTable(s.T(), s.DB)
+	children := NewEntityRepository[ChildEntity](s.DB)
+	ids, err := InsertManyRecordsToSampleEntity(s.DB, []SampleEntity{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	s.Require().NoError(err)
+	s.Require().NoError(children.SaveAll([]*ChildEntity{
+		{ParentID: &ids[0], Name: "a1"},
+		{ParentID: &ids[0], Name: "a2"},
+		{ParentID: &ids[1], Name: "b1"},
+		{ParentID: &ids[2], Name: "c1"},
+	}))
+
+	cascading := NewEntityRepository[SampleEntity](s.DB, WithChildRelation(children, "parent_id", OnDeleteCascade))
+	s.Require().NoError(cascading.DeleteByID(ids[0]))
+	all, err := children.WithTrashed().FindAll()
+	s.Require().NoError(err)
+	s.Assert().Len(all, 2)
+
+	softDeleting := NewEntityRepository[SampleEntity](s.DB, WithChildRelation(children, "parent_id", OnDeleteSoftDelete))
+	deleted, err := softDeleting.DeleteBy(map[string]any{"name": "b"}, 0)
+	s.Require().NoError(err)
+	s.Assert().Equal(int64(1), deleted)
+	trashed, err := children.OnlyTrashed().FindAll()
+	s.Require().NoError(err)
+	s.Require().Len(trashed, 1)
+	s.Assert().Equal("b1", trashed[0].Name)
+
+	nulling := NewEntityRepository[SampleEntity](s.DB, WithChildRelation(children, "parent_id", OnDeleteSetNull))
+	s.Require().NoError(nulling.DeleteAll(ConfirmFullDelete()))
+	live, err := children.FindAll()
+	s.Require().NoError(err)
+	s.Require().Len(live, 1)
+	s.Assert().Equal("c1", live[0].Name)
+	s.Assert().Nil(live[0].ParentID)
+}
+
+func (s *IntegrationTestSuite) TestEntityRepository_DistinctValues() {
+	repo := NewEntityRepository[OrderEntity](s.DB)
+	CreateOrderEntityTable(s.T(), s.DB)
+	s.Require().NoError(repo.SaveAll([]*OrderEntity{
+		{Customer: "bob", Amount: 10},
+		{Customer: "alice", Amount: 10},
+		{Customer: "bob", Amount: 20},
+		{Customer: "carol", Amount: 30},
+	}))
+
+	customers, err := DistinctValues[string](repo, "customer", map[string]any{"amount": 10})
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"alice", "bob"}, customers)
+
+	amounts, err := DistinctValues[int64](repo, "amount", nil)
+	s.Require().NoError(err)
+	s.Assert().Equal([]int64{10, 20, 30}, amounts)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrReserveUnsupported = errors.New("dialect does not support reserving ids")
+
+// ReserveIDs allocates n ids for the entity's auto-incremented id column
+// without inserting anything, so that related entities can be built with
+// known ids before they are saved. Set the ids on the entities and save them
+// with SaveAll, which inserts explicit ids as given. Ids that end up unused
+// are simply skipped; they are never handed out twice.
+//
+// How ids are allocated depends on the dialect:
+//
+//   - MySQLDialect keeps a counter per table in SequenceTable, which must
+//     exist, and returns consecutive ids. The counter starts after the
+//     highest id in the table, but MySQL's own AUTO_INCREMENT counter does
+//     not know about it and only moves past reserved ids once they are
+//     inserted. Rows inserted without an id in the meantime can take a
+//     reserved id, so a table using ReserveIDs should get all its ids from
+//     it.
+//   - StandardDialect draws from the sequence behind a serial or identity id
+//     column, as PostgreSQL does for inserts without an id, so both can be
+//     mixed freely. The ids are unique but need not be consecutive.
+//
+// Other dialects fail with ErrReserveUnsupported. The tenant, soft delete and
+// global scopes of the repository play no part: ids are unique across the
+// whole table.
+func (r *entityRepository[E, ID]) ReserveIDs(n int) ([]ID, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n must be positive, got %d", n)
+	}
+	if c, ok := r.column("id"); !ok || !c.has("autoincrement") {
+		return nil, fmt.Errorf("reserving ids needs an auto-incremented id column")
+	}
+	var emptyEntity E
+	tableName := emptyEntity.GetTableName()
+
+	var ids []int64
+	switch dialect := r.options.dialect.(type) {
+	case IDBlockReserver:
+		query, args := dialect.ReserveIDBlock(tableName, n)
+		res, err := r.exec(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		last, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		for id := last - int64(n) + 1; id <= last; id++ {
+			ids = append(ids, id)
+		}
+	case IDSequencer:
+		query, args := dialect.NextIDs(tableName, n)
+		if err := r.selectAll(&ids, query, args...); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrReserveUnsupported
+	}
+	return convertIDs[ID](ids)
+}
+
+// convertIDs converts ids to the entity's integer id type.
+func convertIDs[ID comparable](ids []int64) ([]ID, error) {
+	idType := reflect.TypeOf((*ID)(nil)).Elem()
+	switch idType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil, fmt.Errorf("ids of type %s cannot be reserved", idType)
+	}
+	result := make([]ID, len(ids))
+	for i, id := range ids {
+		result[i] = reflect.ValueOf(id).Convert(idType).Interface().(ID)
+	}
+	return result, nil
+}
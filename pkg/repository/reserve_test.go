@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// CodeEntity has an id that is not auto-incremented.
+type CodeEntity struct {
+	Code string `db:"id"`
+}
+
+func (e CodeEntity) GetID() string {
+	return e.Code
+}
+
+func (e CodeEntity) GetTableName() string {
+	return "codes"
+}
+
+func (e CodeEntity) ToMap() map[string]interface{} {
+	return make(map[string]interface{})
+}
+
+func TestReserveIDs_Queries(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	_, err = repo.ReserveIDs(3)
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "INSERT INTO sqlrepo_sequences (name, last_id) VALUES (?, LAST_INSERT_ID((SELECT COALESCE(MAX(id), 0) FROM sample_entities) + ?)) "+
+		"ON DUPLICATE KEY UPDATE last_id = LAST_INSERT_ID(last_id + ?)", query)
+	require.Equal(t, []any{"sample_entities", 3, 3}, args)
+
+	repo = NewEntityRepository[SampleEntity](db, WithQueryCapture(), WithDialect(StandardDialect{}))
+	_, err = repo.ReserveIDs(3)
+	require.Error(t, err)
+	query, args = repo.LastQuery()
+	require.Equal(t, "SELECT nextval(pg_get_serial_sequence(?, 'id')) FROM generate_series(1, ?)", query)
+	require.Equal(t, []any{"sample_entities", 3}, args)
+
+	_, err = repo.ReserveIDs(0)
+	require.Error(t, err)
+	_, err = NewEntityRepository[CodeEntity](db, WithDialect(StandardDialect{})).ReserveIDs(1)
+	require.Error(t, err)
+}
+
+func TestConvertIDs(t *testing.T) {
+	ids, err := convertIDs[uint32]([]int64{1, 2})
+	require.NoError(t, err)
+	require.Equal(t, []uint32{1, 2}, ids)
+
+	_, err = convertIDs[string]([]int64{1})
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type retryPolicy struct {
+	retries int
+	backoff time.Duration
+}
+
+// WithReadRetry retries reads up to retries times when they fail with a
+// transient connection error, waiting backoff before the first retry and
+// increasing the wait linearly after that. Query errors are never retried.
+//
+// The errors considered transient are driver.ErrBadConn, mysql.ErrInvalidConn
+// and sql.ErrConnDone: the query did not reach the server or its connection
+// broke, and a fresh pooled connection may succeed.
+func WithReadRetry(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.readRetry = retryPolicy{retries: retries, backoff: backoff}
+	}
+}
+
+// WithWriteRetry is the write counterpart of WithReadRetry. Only enable it for
+// idempotent workloads: a write whose connection broke after the server
+// received it may have been applied, and retrying applies it twice.
+func WithWriteRetry(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.writeRetry = retryPolicy{retries: retries, backoff: backoff}
+	}
+}
+
+// WithConnectRetry retries any operation, read or write, whose connection
+// could not be established, making at most maxAttempts attempts in total and
+// waiting backoff before the first retry and linearly longer after that. It is
+// meant to ride out database restarts: the pool dials a fresh connection on
+// every attempt, and since the statement never reached the server retrying a
+// write is safe. Errors returned by the server are never retried.
+//
+// Waits end early when the repository's context is done; see WithContext.
+func WithConnectRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.connectRetry = retryPolicy{retries: maxAttempts - 1, backoff: backoff}
+	}
+}
+
+// isConnectError reports whether err means a connection to the server could
+// not be established at all.
+func isConnectError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+func isTransientConnError(err error) bool {
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, sql.ErrConnDone)
+}
+
+// retry runs fn, retrying it according to policy while it fails with a
+// transient connection error and according to the connect retry policy while
+// no connection can be established. Transaction-bound repositories never retry
+// because the transaction dies with its connection.
+func (r *entityRepository[E, ID]) retry(policy retryPolicy, fn func() error) error {
+	return r.retryWhile(policy, isTransientConnError, func() error {
+		return r.retryWhile(r.options.connectRetry, isConnectError, fn)
+	})
+}
+
+// retryWhile runs fn, retrying it according to policy while its error
+// satisfies retryable.
+func (r *entityRepository[E, ID]) retryWhile(policy retryPolicy, retryable func(error) bool, fn func() error) error {
+	err := fn()
+	for attempt := 1; attempt <= policy.retries && err != nil && r.tx == nil && retryable(err); attempt++ {
+		if waitErr := r.wait(policy.backoff * time.Duration(attempt)); waitErr != nil {
+			return errors.Join(err, waitErr)
+		}
+		err = fn()
+	}
+	return err
+}
+
+// wait sleeps for d unless the repository's context is done first, in which
+// case it returns the context's error.
+func (r *entityRepository[E, ID]) wait(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	}
+}
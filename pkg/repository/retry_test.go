@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry_TransientErrors(t *testing.T) {
+	repo := &entityRepository[SampleEntity, int64]{ctx: context.Background()}
+
+	attempts := 0
+	err := repo.retry(retryPolicy{retries: 2}, func() error {
+		attempts++
+		if attempts < 3 {
+			return fmt.Errorf("query: %w", driver.ErrBadConn)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = repo.retry(retryPolicy{retries: 2}, func() error {
+		attempts++
+		return mysql.ErrInvalidConn
+	})
+	require.ErrorIs(t, err, mysql.ErrInvalidConn)
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetry_QueryErrors(t *testing.T) {
+	repo := &entityRepository[SampleEntity, int64]{ctx: context.Background()}
+
+	attempts := 0
+	err := repo.retry(retryPolicy{retries: 2}, func() error {
+		attempts++
+		return errors.New("syntax error")
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestRetry_ConnectErrors(t *testing.T) {
+	repo := &entityRepository[SampleEntity, int64]{
+		ctx:     context.Background(),
+		options: options{connectRetry: retryPolicy{retries: 2}},
+	}
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	attempts := 0
+	err := repo.retry(retryPolicy{}, func() error {
+		attempts++
+		if attempts < 3 {
+			return refused
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+	err = repo.retry(retryPolicy{}, func() error {
+		attempts++
+		return &mysql.MySQLError{Number: 1064, Message: "syntax error"}
+	})
+	require.Error(t, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestRetry_ContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	repo := &entityRepository[SampleEntity, int64]{
+		ctx:     ctx,
+		options: options{connectRetry: retryPolicy{retries: 5, backoff: time.Hour}},
+	}
+
+	attempts := 0
+	err := repo.retry(retryPolicy{}, func() error {
+		attempts++
+		return &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	require.Equal(t, 1, attempts)
+}
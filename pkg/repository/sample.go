@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+)
+
+// SampleRandom returns up to n rows chosen uniformly at random. It relies on
+// ORDER BY RAND(), which reads and sorts the whole table, so it is only
+// suitable for small tables; see SampleRandomApprox for large ones.
+func (r *entityRepository[E, ID]) SampleRandom(n int) ([]*E, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("sample size must be positive")
+	}
+
+	tableName := r.table()
+
+	var entities []*E
+	where := r.where()
+	query, args := r.limit(fmt.Sprintf("SELECT * FROM %s%s ORDER BY RAND()", tableName, where), where.args, n, 0)
+	err := r.selectAll(&entities, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// SampleRandomApprox returns up to n rows starting at a random id, wrapping
+// around to the lowest ids if needed. It only reads an index range, so it is
+// cheap on large tables, but the rows are consecutive rather than
+// independently sampled and gaps in the id sequence skew the choice. It
+// requires an integer id.
+func (r *entityRepository[E, ID]) SampleRandomApprox(n int) ([]*E, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("sample size must be positive")
+	}
+
+	tableName := r.table()
+
+	var bounds struct {
+		Min sql.NullInt64 `db:"min_id"`
+		Max sql.NullInt64 `db:"max_id"`
+	}
+	where := r.where()
+	boundsQuery := fmt.Sprintf("SELECT MIN(id) AS min_id, MAX(id) AS max_id FROM %s%s", tableName, where)
+	if err := r.getOne(&bounds, boundsQuery, where.args...); err != nil {
+		return nil, err
+	}
+	if !bounds.Min.Valid {
+		return nil, nil
+	}
+	threshold := bounds.Min.Int64 + rand.Int63n(bounds.Max.Int64-bounds.Min.Int64+1)
+
+	var entities []*E
+	from := r.where()
+	from.add("id >= ?", threshold)
+	query, args := r.limit(fmt.Sprintf("SELECT * FROM %s%s ORDER BY id", tableName, from), from.args, n, 0)
+	if err := r.selectAll(&entities, query, args...); err != nil {
+		return nil, err
+	}
+	if len(entities) == n {
+		return entities, nil
+	}
+
+	var wrapped []*E
+	before := r.where()
+	before.add("id < ?", threshold)
+	query, args = r.limit(fmt.Sprintf("SELECT * FROM %s%s ORDER BY id", tableName, before), before.args, n-len(entities), 0)
+	if err := r.selectAll(&wrapped, query, args...); err != nil {
+		return nil, err
+	}
+	return append(entities, wrapped...), nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaMismatchError lists the differences VerifySchema found between an
+// entity and its table.
+type SchemaMismatchError struct {
+	Table      string
+	Missing    []string
+	Extra      []string
+	Mismatches []string
+}
+
+func (e *SchemaMismatchError) Error() string {
+	var problems []string
+	if len(e.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing columns: %s", strings.Join(e.Missing, ", ")))
+	}
+	if len(e.Extra) > 0 {
+		problems = append(problems, fmt.Sprintf("unmapped columns: %s", strings.Join(e.Extra, ", ")))
+	}
+	problems = append(problems, e.Mismatches...)
+	return fmt.Sprintf("table %s does not match entity: %s", e.Table, strings.Join(problems, "; "))
+}
+
+type liveColumn struct {
+	Name     string `db:"COLUMN_NAME"`
+	DataType string `db:"DATA_TYPE"`
+	Nullable string `db:"IS_NULLABLE"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// compatibleDataTypes maps Go kinds to the MySQL data types they can be
+// scanned from.
+var compatibleDataTypes = map[reflect.Kind][]string{
+	reflect.Bool:    {"tinyint", "bit"},
+	reflect.Int:     {"tinyint", "smallint", "mediumint", "int", "bigint", "year"},
+	reflect.Int8:    {"tinyint"},
+	reflect.Int16:   {"tinyint", "smallint", "year"},
+	reflect.Int32:   {"tinyint", "smallint", "mediumint", "int", "year"},
+	reflect.Int64:   {"tinyint", "smallint", "mediumint", "int", "bigint", "year"},
+	reflect.Uint:    {"tinyint", "smallint", "mediumint", "int", "bigint"},
+	reflect.Uint8:   {"tinyint"},
+	reflect.Uint16:  {"tinyint", "smallint"},
+	reflect.Uint32:  {"tinyint", "smallint", "mediumint", "int"},
+	reflect.Uint64:  {"tinyint", "smallint", "mediumint", "int", "bigint"},
+	reflect.Float32: {"float", "decimal"},
+	reflect.Float64: {"float", "double", "decimal"},
+	reflect.String:  {"char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "json", "decimal"},
+}
+
+var byteSliceDataTypes = []string{"binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "json",
+	"char", "varchar", "tinytext", "text", "mediumtext", "longtext"}
+
+var timeDataTypes = []string{"date", "datetime", "timestamp"}
+
+// VerifySchema compares the entity's db-tagged fields to the live columns of
+// its table in the current MySQL database. It reports columns missing from
+// the table, table columns not mapped by the entity, fields whose type cannot
+// hold the column's values and nullable columns mapped to fields that cannot
+// hold NULL. It only reads information_schema.
+func (r *entityRepository[E, ID]) VerifySchema() error {
+	var emptyEntity E
+	tableName := emptyEntity.GetTableName()
+	entityType := reflect.TypeOf(emptyEntity)
+
+	var live []liveColumn
+	query := "SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?"
+	if err := r.selectAll(&live, query, tableName); err != nil {
+		return err
+	}
+	if len(live) == 0 {
+		return fmt.Errorf("table %s does not exist", tableName)
+	}
+
+	liveByName := make(map[string]liveColumn, len(live))
+	for _, c := range live {
+		liveByName[strings.ToLower(c.Name)] = c
+	}
+
+	mismatch := &SchemaMismatchError{Table: tableName}
+	mapped := make(map[string]bool)
+	for _, c := range r.columns() {
+		if c.has("readonly") {
+			continue
+		}
+		name := strings.ToLower(c.Name)
+		mapped[name] = true
+		liveCol, ok := liveByName[name]
+		if !ok {
+			mismatch.Missing = append(mismatch.Missing, c.Name)
+			continue
+		}
+		if c.Index == nil {
+			continue
+		}
+		field := entityType.FieldByIndex(c.Index)
+		if problem := checkColumnType(field.Type, liveCol); problem != "" {
+			mismatch.Mismatches = append(mismatch.Mismatches, fmt.Sprintf("column %s (field %s): %s", c.Name, field.Name, problem))
+		}
+	}
+	for _, c := range live {
+		if !mapped[strings.ToLower(c.Name)] {
+			mismatch.Extra = append(mismatch.Extra, c.Name)
+		}
+	}
+
+	if len(mismatch.Missing) > 0 || len(mismatch.Extra) > 0 || len(mismatch.Mismatches) > 0 {
+		return mismatch
+	}
+	return nil
+}
+
+// checkColumnType returns a description of why a field of type t cannot hold
+// the values of c, or an empty string if it can. Types implementing
+// sql.Scanner other than the sql.Null* family are trusted.
+func checkColumnType(t reflect.Type, c liveColumn) string {
+	nullable := false
+	if t.Kind() == reflect.Pointer {
+		nullable = true
+		t = t.Elem()
+	}
+	if t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null") {
+		nullable = true
+		t = t.Field(0).Type
+	} else if reflect.PointerTo(t).Implements(scannerType) {
+		return ""
+	}
+
+	if c.Nullable == "YES" && !nullable {
+		return "column is nullable but the field cannot hold NULL"
+	}
+
+	var compatible []string
+	switch {
+	case t == timeType:
+		compatible = timeDataTypes
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		compatible = byteSliceDataTypes
+	default:
+		compatible = compatibleDataTypes[t.Kind()]
+	}
+	if compatible == nil {
+		return ""
+	}
+	for _, dataType := range compatible {
+		if strings.EqualFold(dataType, c.DataType) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s cannot hold %s values", t, c.DataType)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckColumnType(t *testing.T) {
+	require.Empty(t, checkColumnType(reflect.TypeOf(int64(0)), liveColumn{DataType: "bigint", Nullable: "NO"}))
+	require.Empty(t, checkColumnType(reflect.TypeOf(""), liveColumn{DataType: "varchar", Nullable: "NO"}))
+	require.Empty(t, checkColumnType(reflect.TypeOf(time.Time{}), liveColumn{DataType: "timestamp", Nullable: "NO"}))
+	require.Empty(t, checkColumnType(reflect.TypeOf(&time.Time{}), liveColumn{DataType: "datetime", Nullable: "YES"}))
+	require.Empty(t, checkColumnType(reflect.TypeOf(sql.NullString{}), liveColumn{DataType: "text", Nullable: "YES"}))
+	require.Empty(t, checkColumnType(reflect.TypeOf([]byte{}), liveColumn{DataType: "blob", Nullable: "NO"}))
+
+	require.NotEmpty(t, checkColumnType(reflect.TypeOf(""), liveColumn{DataType: "varchar", Nullable: "YES"}))
+	require.NotEmpty(t, checkColumnType(reflect.TypeOf(int64(0)), liveColumn{DataType: "varchar", Nullable: "NO"}))
+	require.NotEmpty(t, checkColumnType(reflect.TypeOf(int32(0)), liveColumn{DataType: "bigint", Nullable: "NO"}))
+}
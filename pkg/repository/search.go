@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrFullTextUnsupported = errors.New("dialect does not support full-text search")
+
+// Search returns the entities whose columns match the full-text search query,
+// most relevant first. The columns must be covered together by a FULLTEXT
+// index, e.g. FULLTEXT (title, body) for Search(q, []string{"title", "body"}),
+// or MySQL rejects the query. Words shorter than the index's minimum token
+// size and stopwords are ignored, and so are words found in more than half of
+// the rows of a MyISAM table.
+//
+// The relevance of each row is written into a readonly float field tagged
+// with the relevance option, if E has one, e.g.
+//
+//	Score float64 `db:"score,readonly,relevance"`
+//
+// Search fails with ErrFullTextUnsupported unless the dialect is a
+// FullTextSearcher.
+func (r *entityRepository[E, ID]) Search(query string, columns []string) ([]*E, error) {
+	searcher, ok := r.options.dialect.(FullTextSearcher)
+	if !ok {
+		return nil, ErrFullTextUnsupported
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to search")
+	}
+	validColumns := r.validColumns()
+	for _, column := range columns {
+		if !validColumns[column] {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+
+	match := searcher.MatchAgainst(columns)
+	where := r.where()
+	where.add(match, query)
+
+	var statement string
+	var args []any
+	if score, ok := r.relevanceColumn(); ok {
+		statement = fmt.Sprintf("SELECT *, %s AS %s FROM %s%s ORDER BY %s DESC", match, score.Name, r.table(), where, score.Name)
+		args = append([]any{query}, where.args...)
+	} else {
+		statement = fmt.Sprintf("SELECT * FROM %s%s ORDER BY %s DESC", r.table(), where, match)
+		args = append(where.args, query)
+	}
+
+	var entities []*E
+	if err := r.selectAll(&entities, statement, args...); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// relevanceColumn returns the readonly column receiving Search's relevance.
+func (r *entityRepository[E, ID]) relevanceColumn() (column, bool) {
+	for _, c := range r.columns() {
+		if c.has("readonly") && c.has("relevance") {
+			return c, true
+		}
+	}
+	return column{}, false
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_Query(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[DocEntity](db, WithQueryCapture())
+	_, err = repo.Search("database", []string{"title", "body"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT *, MATCH(title, body) AGAINST (? IN NATURAL LANGUAGE MODE) AS score FROM doc_entities"+
+		" WHERE MATCH(title, body) AGAINST (? IN NATURAL LANGUAGE MODE) ORDER BY score DESC", query)
+	require.Equal(t, []any{"database", "database"}, args)
+
+	samples := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	_, err = samples.Search("x", []string{"name"})
+	require.Error(t, err)
+	query, _ = samples.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE MATCH(name) AGAINST (? IN NATURAL LANGUAGE MODE)"+
+		" ORDER BY MATCH(name) AGAINST (? IN NATURAL LANGUAGE MODE) DESC", query)
+
+	_, err = repo.Search("x", []string{"score"})
+	require.Error(t, err)
+	_, err = NewEntityRepository[DocEntity](db, WithDialect(StandardDialect{})).Search("x", []string{"title"})
+	require.ErrorIs(t, err, ErrFullTextUnsupported)
+}
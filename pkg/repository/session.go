@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+)
+
+// SessionVarsConnector wraps connector so that every connection it opens first
+// sets the given session variables, e.g.
+//
+//	connector, err := mysql.NewConnector(cfg)
+//	db := sql.OpenDB(repository.SessionVarsConnector(connector, map[string]any{
+//		"sql_mode":             "STRICT_ALL_TABLES",
+//		"time_zone":            "+00:00",
+//		"group_concat_max_len": 1 << 20,
+//	}))
+//
+// Variables set with SET on a *sql.DB only reach whichever pooled connection
+// ran the statement, while this applies them to every connection of the pool,
+// including those opened later to replace expired ones. A repository cannot do
+// this for a *sql.DB it is given, so the wrapped connector must be used to
+// open the *sql.DB passed to NewEntityRepository.
+//
+// Values are bound as arguments of SET @@session.name = ?, so numeric
+// variables need numeric values. Opening a connection fails if a variable
+// name is not a plain identifier or a variable cannot be set.
+func SessionVarsConnector(connector driver.Connector, vars map[string]any) driver.Connector {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &sessionConnector{Connector: connector, names: names, vars: vars}
+}
+
+type sessionConnector struct {
+	driver.Connector
+	names []string
+	vars  map[string]any
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	for _, name := range c.names {
+		if !identifierPattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid session variable name %q", name)
+		}
+	}
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range c.names {
+		value, err := driver.DefaultParameterConverter.ConvertValue(c.vars[name])
+		if err == nil {
+			err = execConn(ctx, conn, fmt.Sprintf("SET @@session.%s = ?", name), value)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("set session variable %s: %w", name, err)
+		}
+	}
+	return conn, nil
+}
+
+// execConn executes query on conn, directly when the driver can execute
+// statements with arguments and through a prepared statement otherwise.
+func execConn(ctx context.Context, conn driver.Conn, query string, arg driver.Value) error {
+	args := []driver.NamedValue{{Ordinal: 1, Value: arg}}
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, args)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, args)
+		return err
+	}
+	_, err = stmt.Exec([]driver.Value{arg})
+	return err
+}
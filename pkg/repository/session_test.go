@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordingConnector opens connections that record the statements executed
+// through them. Queries return a row with just an id for every element of
+// ids, statements report affected rows, and transactions always commit.
+type recordingConnector struct {
+	executed []string
+	args     []driver.Value
+	ids      []int64
+	affected int64
+	// columns and rows, when set, answer every query instead of ids.
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (c *recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return recordingConn{c}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver { return rowsDriver{} }
+
+type recordingConn struct{ c *recordingConnector }
+
+func (conn recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{conn.c, query}, nil
+}
+func (recordingConn) Close() error              { return nil }
+func (recordingConn) Begin() (driver.Tx, error) { return recordingTx{}, nil }
+
+type recordingTx struct{}
+
+func (recordingTx) Commit() error   { return nil }
+func (recordingTx) Rollback() error { return nil }
+
+type recordingStmt struct {
+	c     *recordingConnector
+	query string
+}
+
+func (recordingStmt) Close() error  { return nil }
+func (recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.executed = append(s.c.executed, s.query)
+	s.c.args = append(s.c.args, args...)
+	return driver.RowsAffected(s.c.affected), nil
+}
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.executed = append(s.c.executed, s.query)
+	s.c.args = append(s.c.args, args...)
+	if s.c.columns != nil {
+		return &valueRows{columns: s.c.columns, rows: s.c.rows}, nil
+	}
+	if len(s.c.ids) > 0 {
+		return &idRows{ids: s.c.ids}, nil
+	}
+	return &sampleRows{}, nil
+}
+
+type valueRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *valueRows) Columns() []string { return r.columns }
+func (*valueRows) Close() error        { return nil }
+func (r *valueRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+type idRows struct {
+	ids []int64
+	i   int
+}
+
+func (*idRows) Columns() []string { return []string{"id"} }
+func (*idRows) Close() error      { return nil }
+func (r *idRows) Next(dest []driver.Value) error {
+	if r.i == len(r.ids) {
+		return io.EOF
+	}
+	dest[0] = r.ids[r.i]
+	r.i++
+	return nil
+}
+
+func TestSessionVarsConnector(t *testing.T) {
+	connector := &recordingConnector{}
+	db := sql.OpenDB(SessionVarsConnector(connector, map[string]any{
+		"time_zone":            "+00:00",
+		"group_concat_max_len": 1 << 20,
+	}))
+	defer db.Close()
+
+	_, err := NewEntityRepository[SampleEntity](db).FindAll()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"SET @@session.group_concat_max_len = ?",
+		"SET @@session.time_zone = ?",
+		"SELECT * FROM sample_entities",
+	}, connector.executed)
+	require.Equal(t, []driver.Value{int64(1 << 20), "+00:00"}, connector.args)
+
+	invalid := sql.OpenDB(SessionVarsConnector(connector, map[string]any{"time_zone; DROP TABLE x": "+00:00"}))
+	defer invalid.Close()
+	_, err = NewEntityRepository[SampleEntity](invalid).FindAll()
+	require.EqualError(t, err, `invalid session variable name "time_zone; DROP TABLE x"`)
+}
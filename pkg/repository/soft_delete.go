@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Scope selects which rows of a soft-deletable entity reads can see.
+type Scope int
+
+const (
+	// ScopeLive only sees rows that have not been soft-deleted.
+	ScopeLive Scope = iota
+	// ScopeAll sees every row, deleted or not.
+	ScopeAll
+	// ScopeTrashed only sees soft-deleted rows.
+	ScopeTrashed
+)
+
+type trashedContextKey struct{}
+
+// ContextWithTrashed returns a copy of ctx that makes the reads of
+// repositories running under it, see WithContext, include soft-deleted rows,
+// as if WithTrashed had been called on them, e.g. for admin tooling sharing
+// code paths with the rest of the application. Explicitly scoped views take
+// precedence over the context: WithoutTrashed still reads only live rows,
+// OnlyTrashed only deleted rows, and WithTrashed every row regardless.
+func ContextWithTrashed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, trashedContextKey{}, true)
+}
+
+// includesTrashed reports whether ctx was returned by ContextWithTrashed.
+func includesTrashed(ctx context.Context) bool {
+	included, _ := ctx.Value(trashedContextKey{}).(bool)
+	return included
+}
+
+// softDeleteColumn returns the column tagged with the softdelete option. The
+// column is either a nullable timestamp, set when the row is deleted, or a
+// boolean flag.
+func softDeleteColumn(columns []column) (column, bool) {
+	for _, c := range columns {
+		if c.has("softdelete") {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+func (r *entityRepository[E, ID]) isFlagSoftDelete() bool {
+	var emptyEntity E
+	return reflect.TypeOf(emptyEntity).FieldByIndex(r.softDelete.Index).Type.Kind() == reflect.Bool
+}
+
+// scopeCondition returns the condition restricting reads to the repository's
+// scope, or an empty string when every row is visible.
+func (r *entityRepository[E, ID]) scopeCondition() string {
+	if r.softDelete == nil {
+		if r.scope == ScopeTrashed {
+			// Rows that cannot be soft-deleted are never trashed.
+			return "1 = 0"
+		}
+		return ""
+	}
+	switch r.scope {
+	case ScopeLive:
+		if !r.scoped && includesTrashed(r.ctx) {
+			return ""
+		}
+		return r.liveCondition()
+	case ScopeTrashed:
+		if r.isFlagSoftDelete() {
+			return fmt.Sprintf("%s = TRUE", r.softDelete.Name)
+		}
+		return fmt.Sprintf("%s IS NOT NULL", r.softDelete.Name)
+	}
+	return ""
+}
+
+// liveCondition returns the condition matching the rows that have not been
+// soft-deleted, or an empty string when E does not support soft delete.
+func (r *entityRepository[E, ID]) liveCondition() string {
+	if r.softDelete == nil {
+		return ""
+	}
+	if r.isFlagSoftDelete() {
+		return fmt.Sprintf("%s = FALSE", r.softDelete.Name)
+	}
+	return fmt.Sprintf("%s IS NULL", r.softDelete.Name)
+}
+
+// softDeleteAssignment returns the SET expression marking a row as deleted or,
+// when deleted is false, as restored.
+func (r *entityRepository[E, ID]) softDeleteAssignment(deleted bool) string {
+	if r.isFlagSoftDelete() {
+		return fmt.Sprintf("%s = %t", r.softDelete.Name, deleted)
+	}
+	if deleted {
+		return fmt.Sprintf("%s = NOW(6)", r.softDelete.Name)
+	}
+	return fmt.Sprintf("%s = NULL", r.softDelete.Name)
+}
+
+// WithDefaultScope sets which rows of a soft-deletable entity the reads of
+// the repository see unless a view overrides it with WithoutTrashed,
+// WithTrashed or OnlyTrashed, e.g. ScopeAll for audit tooling that should see
+// deleted rows by default. The default is ScopeLive. The repository fails
+// every query if scope is not one of the Scope constants.
+func WithDefaultScope(scope Scope) Option {
+	return func(o *options) {
+		o.defaultScope = scope
+	}
+}
+
+// WithoutTrashed returns a view of the repository that only reads rows that
+// have not been soft-deleted, whatever its default scope.
+func (r *entityRepository[E, ID]) WithoutTrashed() Repository[E, ID] {
+	return r.withScope(ScopeLive)
+}
+
+// OnlyTrashed returns a view of the repository that only reads soft-deleted
+// rows, which means none for an entity without soft delete.
+func (r *entityRepository[E, ID]) OnlyTrashed() Repository[E, ID] {
+	return r.withScope(ScopeTrashed)
+}
+
+// WithTrashed returns a view of the repository that reads rows regardless of
+// whether they were soft-deleted.
+func (r *entityRepository[E, ID]) WithTrashed() Repository[E, ID] {
+	return r.withScope(ScopeAll)
+}
+
+func (r *entityRepository[E, ID]) withScope(scope Scope) *entityRepository[E, ID] {
+	view := *r
+	view.scope = scope
+	view.scoped = true
+	return &view
+}
+
+// Restore clears the soft-delete marker of the entity with the given id.
+func (r *entityRepository[E, ID]) Restore(id ID) error {
+	if r.softDelete == nil {
+		return fmt.Errorf("entity does not support soft delete")
+	}
+
+	tableName := r.table()
+	where := r.tenantWhere()
+	where.add("id = ?", id)
+	query := fmt.Sprintf("UPDATE %s SET %s%s", tableName, r.softDeleteAssignment(false), where)
+	_, err := r.exec(query, where.args...)
+	return err
+}
+
+var ErrRestoreAllUnconfirmed = errors.New("restoring without conditions requires ConfirmFullRestore")
+
+type RestoreOption func(*restoreOptions)
+
+type restoreOptions struct {
+	confirmed bool
+}
+
+// ConfirmFullRestore confirms that a RestoreBy call without conditions is
+// meant to restore every soft-deleted row.
+func ConfirmFullRestore() RestoreOption {
+	return func(o *restoreOptions) {
+		o.confirmed = true
+	}
+}
+
+// RestoreBy clears the soft-delete marker of every soft-deleted row matching
+// conditions, e.g. to undo a bulk delete, and returns the number of rows
+// restored. Like DeleteAll, it refuses to run without conditions, failing
+// with ErrRestoreAllUnconfirmed, unless called with ConfirmFullRestore().
+func (r *entityRepository[E, ID]) RestoreBy(conditions map[string]any, opts ...RestoreOption) (int64, error) {
+	if r.softDelete == nil {
+		return 0, fmt.Errorf("entity does not support soft delete")
+	}
+	var o restoreOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(conditions) == 0 && !o.confirmed {
+		return 0, ErrRestoreAllUnconfirmed
+	}
+
+	where := r.withScope(ScopeTrashed).where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s%s", r.table(), r.softDeleteAssignment(false), where)
+	return r.execAffected(query, where.args...)
+}
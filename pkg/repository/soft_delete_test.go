@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWithTrashed(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SoftDeleteEntity](db, WithQueryCapture())
+	ctx := ContextWithTrashed(context.Background())
+	lastQuery := func(r Repository[SoftDeleteEntity, int64]) string {
+		_, err := r.FindAll()
+		require.Error(t, err)
+		query, _ := repo.LastQuery()
+		return query
+	}
+
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NULL", lastQuery(repo))
+	require.Equal(t, "SELECT * FROM soft_delete_entities", lastQuery(repo.WithContext(ctx)))
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NOT NULL", lastQuery(repo.WithContext(ctx).OnlyTrashed()))
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NOT NULL", lastQuery(repo.OnlyTrashed().WithContext(ctx)))
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NULL", lastQuery(repo.WithoutTrashed().WithContext(ctx)))
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NULL", lastQuery(repo.WithContext(ctx).WithoutTrashed()))
+}
+
+func TestWithDefaultScope(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SoftDeleteEntity](db, WithDefaultScope(ScopeAll), WithQueryCapture())
+	lastQuery := func(r Repository[SoftDeleteEntity, int64]) string {
+		_, err := r.FindAll()
+		require.Error(t, err)
+		query, _ := repo.LastQuery()
+		return query
+	}
+
+	require.Equal(t, "SELECT * FROM soft_delete_entities", lastQuery(repo))
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NULL", lastQuery(repo.WithoutTrashed()))
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NOT NULL", lastQuery(repo.OnlyTrashed()))
+
+	trashed := NewEntityRepository[SoftDeleteEntity](db, WithDefaultScope(ScopeTrashed), WithQueryCapture())
+	_, err = trashed.FindAll()
+	require.Error(t, err)
+	query, _ := trashed.LastQuery()
+	require.Equal(t, "SELECT * FROM soft_delete_entities WHERE deleted_at IS NOT NULL", query)
+
+	_, err = NewEntityRepository[SoftDeleteEntity](db, WithDefaultScope(Scope(9))).FindAll()
+	require.EqualError(t, err, "unknown scope 9")
+}
+
+func TestRestoreBy(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SoftDeleteEntity](db, WithQueryCapture())
+	_, err = repo.RestoreBy(map[string]any{"name": "a"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "UPDATE soft_delete_entities SET deleted_at = NULL WHERE deleted_at IS NOT NULL AND name = ?", query)
+	require.Equal(t, []any{"a"}, args)
+
+	_, err = repo.RestoreBy(nil)
+	require.ErrorIs(t, err, ErrRestoreAllUnconfirmed)
+	_, err = repo.RestoreBy(nil, ConfirmFullRestore())
+	require.Error(t, err)
+	query, _ = repo.LastQuery()
+	require.Equal(t, "UPDATE soft_delete_entities SET deleted_at = NULL WHERE deleted_at IS NOT NULL", query)
+
+	_, err = repo.RestoreBy(map[string]any{"unknown": "a"})
+	require.ErrorContains(t, err, "unknown")
+	_, err = NewEntityRepository[SampleEntity](db).RestoreBy(map[string]any{"name": "a"})
+	require.ErrorContains(t, err, "soft delete")
+}
+
+func TestSoftDelete_SkipsTrashedRows(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SoftDeleteEntity](db, WithQueryCapture())
+	require.Error(t, repo.DeleteByIDs([]int64{1}))
+	query, _ := repo.LastQuery()
+	require.Equal(t, "UPDATE soft_delete_entities SET deleted_at = NOW(6) WHERE id IN (?) AND deleted_at IS NULL", query)
+
+	require.Error(t, repo.DeleteAll(ConfirmFullDelete()))
+	query, _ = repo.LastQuery()
+	require.Equal(t, "UPDATE soft_delete_entities SET deleted_at = NOW(6) WHERE deleted_at IS NULL", query)
+
+	_, err = repo.DeleteBy(map[string]any{"name": "a"}, 0)
+	require.Error(t, err)
+	query, _ = repo.LastQuery()
+	require.Equal(t, "UPDATE soft_delete_entities SET deleted_at = NOW(6) WHERE deleted_at IS NULL AND name = ?", query)
+}
+
+func TestOnlyTrashed_WithoutSoftDelete(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+	_, err = repo.OnlyTrashed().FindAll()
+	require.Error(t, err)
+	query, _ := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE 1 = 0", query)
+}
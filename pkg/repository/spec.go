@@ -0,0 +1,402 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition compares a column to a value. A nil Value compared with = or !=
+// matches NULL or NOT NULL respectively.
+type Condition struct {
+	Column    string `json:"column"`
+	Operator  string `json:"operator"`
+	Value     any    `json:"value,omitempty"`
+	Collation string `json:"collation,omitempty"`
+
+	// ValueColumn, when set, compares Column to this column instead of to
+	// Value; see WhereColumns.
+	ValueColumn string `json:"value_column,omitempty"`
+
+	// subquery is set by Exists and NotExists only, so such conditions can
+	// not be created from decoded JSON.
+	subquery *subquery
+	// expression is set by ExprCond only, for the same reason.
+	expression *expression
+}
+
+// expression is the raw SQL of an ExprCond condition.
+type expression struct {
+	sql  string
+	args []any
+}
+
+// subquery is the correlated subquery of an EXISTS condition.
+type subquery struct {
+	table string
+	where string
+	args  []any
+}
+
+// Eq returns a condition matching rows where column equals value.
+func Eq(column string, value any) Condition {
+	return Condition{Column: column, Operator: "=", Value: value}
+}
+
+// Where returns a condition comparing column to value with operator, which
+// must be one of the supported comparison operators.
+func Where(column, operator string, value any) Condition {
+	return Condition{Column: column, Operator: operator, Value: value}
+}
+
+// Between returns the conditions matching rows where column lies between low
+// and high, both included, to be ANDed with the others of a spec:
+//
+//	Where: append([]Condition{Eq("status", "paid")}, Between("created_at", from, to)...)
+//
+// It is shorthand for a >= and a <= condition on the same column; any other
+// pair of operators, e.g. for a half-open range, is written out the same way.
+func Between(column string, low, high any) []Condition {
+	return []Condition{Where(column, ">=", low), Where(column, "<=", high)}
+}
+
+// WhereNotIn returns a condition matching rows where column is not one of
+// values. An empty list matches every row; as in SQL, rows where column is
+// NULL never match, and neither does any row if values contains nil.
+func WhereNotIn(column string, values ...any) Condition {
+	return Condition{Column: column, Operator: "NOT IN", Value: values}
+}
+
+// WhereColumns returns a condition comparing two columns of the same row, e.g.
+// WhereColumns("start_date", ">", "end_date") to find rows whose dates are in
+// the wrong order. operator must be one of =, !=, <>, <, <=, > and >=. As in
+// SQL, rows where either column is NULL never match.
+func WhereColumns(left, operator, right string) Condition {
+	return Condition{Column: left, Operator: operator, ValueColumn: right}
+}
+
+// Exists returns a condition matching rows for which
+// SELECT 1 FROM table WHERE where returns a row. The subquery may refer to the
+// outer table by name to correlate with it, e.g.
+// Exists("orders", "orders.user_id = users.id AND orders.status = ?", "paid").
+// table must be a plain identifier; where is spliced into the query verbatim
+// with its ? placeholders bound to args, so it must never contain user input.
+func Exists(table, where string, args ...any) Condition {
+	return Condition{Operator: "EXISTS", subquery: &subquery{table: table, where: where, args: args}}
+}
+
+// NotExists is the negation of Exists.
+func NotExists(table, where string, args ...any) Condition {
+	return Condition{Operator: "NOT EXISTS", subquery: &subquery{table: table, where: where, args: args}}
+}
+
+// ExprCond returns a condition matching rows for which the SQL expression expr
+// is true, for filters the other conditions cannot express, e.g.
+// ExprCond("LENGTH(name) > ?", 10). expr is spliced into the query verbatim,
+// in parentheses, with its ? placeholders bound to args, so it must never
+// contain user input, and the caller is responsible for the columns it refers
+// to, which are not checked against the entity's db tags. Expressions with a
+// different number of placeholders than args, or containing statement
+// separators or comments, are rejected.
+func ExprCond(expr string, args ...any) Condition {
+	return Condition{Operator: "EXPR", expression: &expression{sql: expr, args: args}}
+}
+
+// Collate returns a copy of the condition comparing the column under the given
+// MySQL collation. The collation is ignored with dialects other than MySQL's.
+func (c Condition) Collate(collation string) Condition {
+	c.Collation = collation
+	return c
+}
+
+type OrderClause struct {
+	Column    string `json:"column"`
+	Desc      bool   `json:"desc,omitempty"`
+	Collation string `json:"collation,omitempty"`
+}
+
+// QuerySpec describes a filtered, ordered and optionally paginated read. It
+// can be decoded from JSON sent by clients, e.g. with ParseQuerySpec, to back
+// generic list endpoints:
+//
+//	{"where": [{"column": "status", "operator": "=", "value": "paid"}],
+//	 "order_by": [{"column": "created_at", "desc": true}],
+//	 "pagination": {"limit": 20, "offset": 40}}
+//
+// A spec is validated by FindBySpec, on the server, whatever the client sent:
+// columns must be mapped by the entity's db tags, operators and collations
+// must be in the allowlists, values are always bound as arguments and never
+// spliced into the query, and the conditions are ANDed with the repository's
+// tenant, soft delete and global scopes, so a spec can narrow what the
+// repository returns but never widen it. A column may appear in any number of
+// conditions, e.g. twice for a range. Conditions spliced verbatim, such as
+// Exists and ExprCond, cannot be decoded from JSON. Limiting what a client
+// may filter or sort on beyond the entity's columns is up to the caller.
+//
+// The page size is not capped: a spec without pagination, or with a large
+// limit, reads every matching row. Callers serving untrusted clients must set
+// or clamp Pagination.Limit themselves before running the spec.
+type QuerySpec struct {
+	Where      []Condition   `json:"where,omitempty"`
+	OrderBy    []OrderClause `json:"order_by,omitempty"`
+	Pagination *Pagination   `json:"pagination,omitempty"`
+}
+
+// ParseQuerySpec decodes a QuerySpec from JSON, rejecting unknown fields so
+// typos in client requests fail instead of being ignored. Numbers in values
+// are decoded as json.Number rather than float64, so large integer ids are
+// not rounded. The spec is only validated against an entity when it is run
+// by FindBySpec.
+func ParseQuerySpec(data []byte) (*QuerySpec, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	var spec QuerySpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid query spec: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("invalid query spec: unexpected data after the spec")
+	}
+	return &spec, nil
+}
+
+var conditionOperators = map[string]bool{
+	"=":        true,
+	"!=":       true,
+	"<>":       true,
+	"<":        true,
+	"<=":       true,
+	">":        true,
+	">=":       true,
+	"LIKE":     true,
+	"NOT LIKE": true,
+	"NOT IN":   true,
+}
+
+// columnOperators lists the operators that may compare two columns.
+var columnOperators = map[string]bool{
+	"=":  true,
+	"!=": true,
+	"<>": true,
+	"<":  true,
+	"<=": true,
+	">":  true,
+	">=": true,
+}
+
+// collations lists the MySQL collations that may be spliced into queries.
+var collations = map[string]bool{
+	"binary":                 true,
+	"ascii_bin":              true,
+	"ascii_general_ci":       true,
+	"latin1_bin":             true,
+	"latin1_general_ci":      true,
+	"latin1_swedish_ci":      true,
+	"utf8mb3_bin":            true,
+	"utf8mb3_general_ci":     true,
+	"utf8mb3_unicode_ci":     true,
+	"utf8mb4_bin":            true,
+	"utf8mb4_general_ci":     true,
+	"utf8mb4_unicode_ci":     true,
+	"utf8mb4_unicode_520_ci": true,
+	"utf8mb4_0900_ai_ci":     true,
+	"utf8mb4_0900_as_ci":     true,
+	"utf8mb4_0900_as_cs":     true,
+	"utf8mb4_0900_bin":       true,
+}
+
+// collate returns column compared or sorted under collation, which must be in
+// the allowlist. Collations are MySQL's, so they are ignored with a dialect
+// that is not a Collator; a nil dialect stands for MySQLDialect.
+func collate(dialect Dialect, column, collation string) (string, error) {
+	if collation == "" {
+		return column, nil
+	}
+	if !collations[collation] {
+		return "", fmt.Errorf("unsupported collation %q", collation)
+	}
+	if dialect == nil {
+		dialect = MySQLDialect{}
+	}
+	collator, ok := dialect.(Collator)
+	if !ok {
+		return column, nil
+	}
+	return collator.Collate(column, collation), nil
+}
+
+// addCondition validates c and adds it to w.
+func addCondition(w *whereBuilder, validColumns map[string]bool, c Condition) error {
+	if c.subquery != nil {
+		return addExists(w, c.Operator, c.subquery)
+	}
+	if c.expression != nil {
+		return addExpression(w, c.expression)
+	}
+	if !validColumns[c.Column] {
+		return fmt.Errorf("unknown column %q", c.Column)
+	}
+	if c.ValueColumn != "" {
+		return addColumnComparison(w, validColumns, c)
+	}
+	if transformer := w.transformers[c.Column]; transformer != nil {
+		encoded, err := encodeCondition(transformer, c)
+		if err != nil {
+			return err
+		}
+		c = encoded
+	}
+	return addComparison(w, c.Column, c)
+}
+
+// addColumnComparison adds a condition comparing the columns of c.
+func addColumnComparison(w *whereBuilder, validColumns map[string]bool, c Condition) error {
+	if !validColumns[c.ValueColumn] {
+		return fmt.Errorf("unknown column %q", c.ValueColumn)
+	}
+	operator := strings.ToUpper(strings.TrimSpace(c.Operator))
+	if !columnOperators[operator] {
+		return fmt.Errorf("unsupported operator %q for comparing columns", c.Operator)
+	}
+	left, err := collate(w.dialect, c.Column, c.Collation)
+	if err != nil {
+		return err
+	}
+	w.add(fmt.Sprintf("%s %s %s", left, operator, c.ValueColumn))
+	return nil
+}
+
+// addComparison adds a condition comparing expr, a trusted SQL expression, to
+// the value of c using its operator and collation.
+func addComparison(w *whereBuilder, expr string, c Condition) error {
+	operator := strings.ToUpper(strings.TrimSpace(c.Operator))
+	if !conditionOperators[operator] {
+		return fmt.Errorf("unsupported operator %q", c.Operator)
+	}
+	collated, err := collate(w.dialect, expr, c.Collation)
+	if err != nil {
+		return err
+	}
+
+	if operator == "NOT IN" {
+		return addNotIn(w, collated, c.Value)
+	}
+	if c.Value == nil {
+		switch operator {
+		case "=":
+			w.add(fmt.Sprintf("%s IS NULL", expr))
+			return nil
+		case "!=", "<>":
+			w.add(fmt.Sprintf("%s IS NOT NULL", expr))
+			return nil
+		}
+		return fmt.Errorf("operator %q cannot compare with NULL", c.Operator)
+	}
+	switch c.Value.(type) {
+	case map[string]any, []any:
+		// Objects and lists decoded from JSON.
+		return fmt.Errorf("operator %q needs a single value, got %T", c.Operator, c.Value)
+	}
+	w.add(fmt.Sprintf("%s %s ?", collated, operator), bindValue(c.Value))
+	return nil
+}
+
+// addExists adds an EXISTS or NOT EXISTS condition for sub.
+func addExists(w *whereBuilder, operator string, sub *subquery) error {
+	if !identifierPattern.MatchString(sub.table) {
+		return fmt.Errorf("invalid table name %q", sub.table)
+	}
+	query := fmt.Sprintf("SELECT 1 FROM %s", sub.table)
+	if sub.where != "" {
+		query += " WHERE " + sub.where
+	}
+	w.add(fmt.Sprintf("%s (%s)", operator, query), sub.args...)
+	return nil
+}
+
+// addExpression adds the raw condition expr.
+func addExpression(w *whereBuilder, expr *expression) error {
+	if strings.TrimSpace(expr.sql) == "" {
+		return fmt.Errorf("empty expression")
+	}
+	for _, forbidden := range []string{";", "--", "#", "/*"} {
+		if strings.Contains(expr.sql, forbidden) {
+			return fmt.Errorf("expression %q must not contain %q", expr.sql, forbidden)
+		}
+	}
+	if n := strings.Count(expr.sql, "?"); n != len(expr.args) {
+		return fmt.Errorf("expression %q has %d placeholders but %d arguments", expr.sql, n, len(expr.args))
+	}
+	args := make([]any, len(expr.args))
+	for i, arg := range expr.args {
+		args[i] = bindValue(arg)
+	}
+	w.add("("+expr.sql+")", args...)
+	return nil
+}
+
+// addNotIn adds collated NOT IN (...) for the elements of the slice values,
+// split into one condition per chunk of at most maxInListSize values. No
+// condition is added for an empty list.
+func addNotIn(w *whereBuilder, collated string, values any) error {
+	if values == nil {
+		return nil
+	}
+	list := reflect.ValueOf(values)
+	if list.Kind() != reflect.Slice {
+		return fmt.Errorf("NOT IN needs a list of values, got %T", values)
+	}
+	args := make([]any, list.Len())
+	for i := range args {
+		args[i] = bindValue(list.Index(i).Interface())
+	}
+	for _, argChunk := range chunk(args, maxInListSize) {
+		w.add(fmt.Sprintf("%s NOT IN (%s)", collated, placeholders(len(argChunk))), argChunk...)
+	}
+	return nil
+}
+
+// orderBy renders an ORDER BY clause for clauses, validating every column.
+// Collations are rendered as by collate.
+func orderBy(dialect Dialect, validColumns map[string]bool, clauses []OrderClause) (string, error) {
+	if len(clauses) == 0 {
+		return "", nil
+	}
+	parts := make([]string, len(clauses))
+	for i, clause := range clauses {
+		if !validColumns[clause.Column] {
+			return "", fmt.Errorf("unknown column %q", clause.Column)
+		}
+		column, err := collate(dialect, clause.Column, clause.Collation)
+		if err != nil {
+			return "", err
+		}
+		if clause.Desc {
+			column += " DESC"
+		}
+		parts[i] = column
+	}
+	return " ORDER BY " + strings.Join(parts, ", "), nil
+}
+
+// FindByColumnNotIn returns the entities whose column is not one of values.
+// See WhereNotIn for how empty lists and NULLs are handled.
+func (r *entityRepository[E, ID]) FindByColumnNotIn(column string, values []any) ([]*E, error) {
+	return r.FindBySpec(&QuerySpec{Where: []Condition{WhereNotIn(column, values...)}})
+}
+
+// FindBySpec returns the entities matching spec.
+func (r *entityRepository[E, ID]) FindBySpec(spec *QuerySpec) ([]*E, error) {
+	if spec == nil {
+		return nil, errors.New("query spec is required")
+	}
+	result, err := r.Find(FindOptions{Where: spec.Where, OrderBy: spec.OrderBy, Pagination: spec.Pagination})
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddCondition_Collation(t *testing.T) {
+	validColumns := map[string]bool{"name": true}
+
+	w := &whereBuilder{}
+	err := addCondition(w, validColumns, Eq("name", "Ève").Collate("utf8mb4_unicode_ci"))
+	require.NoError(t, err)
+	require.Equal(t, " WHERE name COLLATE utf8mb4_unicode_ci = ?", w.String())
+	require.Equal(t, []interface{}{"Ève"}, w.args)
+
+	err = addCondition(w, validColumns, Eq("name", "x").Collate("utf8mb4_bin; DROP TABLE users"))
+	require.Error(t, err)
+}
+
+func TestOrderBy_Collation(t *testing.T) {
+	validColumns := map[string]bool{"name": true, "id": true}
+
+	order, err := orderBy(nil, validColumns, []OrderClause{{Column: "name", Collation: "utf8mb4_unicode_ci"}, {Column: "id", Desc: true}})
+	require.NoError(t, err)
+	require.Equal(t, " ORDER BY name COLLATE utf8mb4_unicode_ci, id DESC", order)
+
+	_, err = orderBy(nil, validColumns, []OrderClause{{Column: "name", Collation: "bogus"}})
+	require.Error(t, err)
+
+	_, err = orderBy(nil, validColumns, []OrderClause{{Column: "unknown"}})
+	require.Error(t, err)
+}
+
+func TestAddCondition_NotIn(t *testing.T) {
+	validColumns := map[string]bool{"name": true}
+
+	w := &whereBuilder{}
+	require.NoError(t, addCondition(w, validColumns, WhereNotIn("name")))
+	require.Equal(t, "", w.String())
+
+	values := make([]any, maxInListSize+1)
+	for i := range values {
+		values[i] = i
+	}
+	require.NoError(t, addCondition(w, validColumns, WhereNotIn("name", values...)))
+	require.Equal(t, " WHERE name NOT IN ("+placeholders(maxInListSize)+") AND name NOT IN (?)", w.String())
+	require.Len(t, w.args, maxInListSize+1)
+
+	err := addCondition(&whereBuilder{}, validColumns, Where("name", "NOT IN", "x"))
+	require.Error(t, err)
+}
+
+func TestAddCondition_Exists(t *testing.T) {
+	w := &whereBuilder{}
+	require.NoError(t, addCondition(w, nil, Exists("orders", "orders.user_id = users.id AND orders.status = ?", "paid")))
+	require.NoError(t, addCondition(w, nil, NotExists("bans", "bans.user_id = users.id")))
+	require.Equal(t, " WHERE EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.status = ?)"+
+		" AND NOT EXISTS (SELECT 1 FROM bans WHERE bans.user_id = users.id)", w.String())
+	require.Equal(t, []interface{}{"paid"}, w.args)
+
+	require.Error(t, addCondition(&whereBuilder{}, nil, Exists("orders o, users", "1")))
+
+	var decoded Condition
+	require.NoError(t, json.Unmarshal([]byte(`{"column":"id","operator":"EXISTS"}`), &decoded))
+	require.Error(t, addCondition(&whereBuilder{}, map[string]bool{"id": true}, decoded))
+}
+
+func TestAddCondition_WhereColumns(t *testing.T) {
+	validColumns := map[string]bool{"start_date": true, "end_date": true}
+
+	w := &whereBuilder{}
+	require.NoError(t, addCondition(w, validColumns, WhereColumns("start_date", ">", "end_date")))
+	require.Equal(t, " WHERE start_date > end_date", w.String())
+	require.Empty(t, w.args)
+
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, WhereColumns("start_date", ">", "end_date OR 1=1")))
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, WhereColumns("start_date", "LIKE", "end_date")))
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, WhereColumns("unknown", "=", "end_date")))
+
+	var decoded Condition
+	require.NoError(t, json.Unmarshal([]byte(`{"column":"end_date","operator":"<=","value_column":"start_date"}`), &decoded))
+	w = &whereBuilder{}
+	require.NoError(t, addCondition(w, validColumns, decoded))
+	require.Equal(t, " WHERE end_date <= start_date", w.String())
+}
+
+func TestAddCondition_ExprCond(t *testing.T) {
+	validColumns := map[string]bool{"name": true}
+
+	w := &whereBuilder{}
+	require.NoError(t, addCondition(w, validColumns, Eq("name", "x")))
+	require.NoError(t, addCondition(w, validColumns, ExprCond("LENGTH(name) > ?", 10)))
+	require.Equal(t, " WHERE name = ? AND (LENGTH(name) > ?)", w.String())
+	require.Equal(t, []interface{}{"x", 10}, w.args)
+
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, ExprCond("LENGTH(name) > ?")))
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, ExprCond("1 = 1; DROP TABLE users")))
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, ExprCond("1 = 1 -- ")))
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, ExprCond(" ")))
+
+	var decoded Condition
+	require.NoError(t, json.Unmarshal([]byte(`{"column":"name","operator":"EXPR","value":"1=1"}`), &decoded))
+	require.Error(t, addCondition(&whereBuilder{}, validColumns, decoded))
+}
+
+func TestParseQuerySpec(t *testing.T) {
+	spec, err := ParseQuerySpec([]byte(`{
+		"where": [{"column": "name", "operator": "LIKE", "value": "a%"}, {"column": "id", "operator": "NOT IN", "value": [1, 2]}],
+		"order_by": [{"column": "name", "desc": true}],
+		"pagination": {"limit": 10, "offset": 20}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, &QuerySpec{
+		Where:      []Condition{Where("name", "LIKE", "a%"), WhereNotIn("id", json.Number("1"), json.Number("2"))},
+		OrderBy:    []OrderClause{{Column: "name", Desc: true}},
+		Pagination: &Pagination{Limit: 10, Offset: 20},
+	}, spec)
+
+	encoded, err := json.Marshal(spec)
+	require.NoError(t, err)
+	decoded, err := ParseQuerySpec(encoded)
+	require.NoError(t, err)
+	require.Equal(t, spec, decoded)
+
+	_, err = ParseQuerySpec([]byte(`{"where": [{"column": "name", "operator": "=", "vaule": "x"}]}`))
+	require.Error(t, err)
+	_, err = ParseQuerySpec([]byte(`{} {}`))
+	require.Error(t, err)
+}
+
+func TestFindBySpec_Validation(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+
+	for _, spec := range []string{
+		`{"where": [{"column": "name; DROP TABLE users", "operator": "=", "value": "x"}]}`,
+		`{"where": [{"column": "name", "operator": "= 1 OR 1 =", "value": "x"}]}`,
+		`{"where": [{"column": "name", "operator": "=", "value": {"x": 1}}]}`,
+		`{"where": [{"column": "name", "operator": "=", "value": "x", "collation": "x; --"}]}`,
+		`{"order_by": [{"column": "(SELECT 1)"}]}`,
+		`{"pagination": {"limit": -1}}`,
+	} {
+		parsed, err := ParseQuerySpec([]byte(spec))
+		require.NoError(t, err)
+		_, err = repo.FindBySpec(parsed)
+		require.Error(t, err, spec)
+		query, _ := repo.LastQuery()
+		require.Empty(t, query, spec)
+	}
+
+	_, err = repo.FindBySpec(nil)
+	require.EqualError(t, err, "query spec is required")
+}
+
+func TestFindBySpec_CollationWithStandardDialect(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+	repo := NewEntityRepository[SampleEntity](db, WithDialect(StandardDialect{}), WithQueryCapture())
+
+	_, _ = repo.FindBySpec(&QuerySpec{
+		Where:   []Condition{Eq("name", "x").Collate("utf8mb4_unicode_ci")},
+		OrderBy: []OrderClause{{Column: "name", Collation: "utf8mb4_bin"}},
+	})
+	query, _ := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE name = ? ORDER BY name", query)
+
+	_, err = repo.FindBySpec(&QuerySpec{Where: []Condition{Eq("name", "x").Collate("x; --")}})
+	require.Error(t, err)
+}
+
+func TestFindBySpec_Range(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+	repo := NewEntityRepository[SampleEntity](db, WithQueryCapture())
+
+	_, _ = repo.FindBySpec(&QuerySpec{Where: append([]Condition{Where("name", "!=", "x")}, Between("id", 10, 20)...)})
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE name != ? AND id >= ? AND id <= ?", query)
+	require.Equal(t, []any{"x", 10, 20}, args)
+
+	spec, err := ParseQuerySpec([]byte(`{"where": [{"column": "id", "operator": ">", "value": 10}, {"column": "id", "operator": "<", "value": 20}, {"column": "id", "operator": "!=", "value": 15}]}`))
+	require.NoError(t, err)
+	_, _ = repo.FindBySpec(spec)
+	query, args = repo.LastQuery()
+	require.Equal(t, "SELECT * FROM sample_entities WHERE id > ? AND id < ? AND id != ?", query)
+	require.Equal(t, []any{int64(10), int64(20), int64(15)}, args)
+
+	spec, err = ParseQuerySpec([]byte(`{"where": [{"column": "id", "operator": "=", "value": 9007199254740993}, {"column": "name", "operator": "!=", "value": 1.5}]}`))
+	require.NoError(t, err)
+	_, _ = repo.FindBySpec(spec)
+	_, args = repo.LastQuery()
+	require.Equal(t, []any{int64(9007199254740993), "1.5"}, args)
+
+	_, err = repo.FindBySpec(&QuerySpec{Where: Between("created", 1, 2)})
+	require.ErrorContains(t, err, "unknown column")
+}
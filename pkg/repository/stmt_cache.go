@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// stmtCache holds up to size prepared statements keyed by their query text,
+// evicting the least recently used one to make room for a new query. It is
+// safe for concurrent use.
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	// recent orders the entries from most to least recently used.
+	recent *list.List
+}
+
+// cachedStmt is a statement of the cache. An evicted statement is closed once
+// its last user releases it.
+type cachedStmt struct {
+	query   string
+	stmt    *sqlx.Stmt
+	users   int
+	evicted bool
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		recent:  list.New(),
+	}
+}
+
+// get returns the cached statement for query, preparing it on first use, and
+// release, which the caller must call once it no longer uses the statement.
+// The statement is prepared without holding c.mu, so that a slow prepare does
+// not block the users of other statements; when goroutines race to prepare
+// the same query, the first to finish caches its statement and the others
+// close theirs.
+func (c *stmtCache) get(db *sqlx.DB, query string) (stmt *sqlx.Stmt, release func(), err error) {
+	c.mu.Lock()
+	if element, ok := c.entries[query]; ok {
+		defer c.mu.Unlock()
+		return c.use(element)
+	}
+	c.mu.Unlock()
+
+	prepared, err := db.Preparex(query)
+	if err != nil {
+		return nil, func() {}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[query]; ok {
+		prepared.Close()
+		return c.use(element)
+	}
+	for c.recent.Len() >= c.size {
+		c.evict(c.recent.Back())
+	}
+	element := c.recent.PushFront(&cachedStmt{query: query, stmt: prepared})
+	c.entries[query] = element
+	return c.use(element)
+}
+
+// use marks the statement of element as the most recently used and in use by
+// one more caller. c.mu must be held.
+func (c *stmtCache) use(element *list.Element) (*sqlx.Stmt, func(), error) {
+	c.recent.MoveToFront(element)
+	entry := element.Value.(*cachedStmt)
+	entry.users++
+	return entry.stmt, func() { c.release(entry) }, nil
+}
+
+// evict removes element from the cache, closing its statement unless it is in
+// use. c.mu must be held.
+func (c *stmtCache) evict(element *list.Element) {
+	entry := c.recent.Remove(element).(*cachedStmt)
+	delete(c.entries, entry.query)
+	entry.evicted = true
+	if entry.users == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// release ends a use of entry, closing its statement if it was evicted and
+// this was the last use.
+func (c *stmtCache) release(entry *cachedStmt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.users--
+	if entry.evicted && entry.users == 0 {
+		entry.stmt.Close()
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStmtCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "1")
+	require.NoError(t, err)
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, bindDriver)
+	cache := newStmtCache(2)
+
+	get := func(query string) (*sqlx.Stmt, func()) {
+		stmt, release, err := cache.get(sqlxDB, query)
+		require.NoError(t, err)
+		return stmt, release
+	}
+
+	a, releaseA := get("SELECT 1")
+	releaseA()
+	b, releaseB := get("SELECT 2")
+	releaseB()
+	again, releaseA := get("SELECT 1")
+	releaseA()
+	require.Same(t, a, again)
+
+	// SELECT 2 is the least recently used and is evicted and closed.
+	c, releaseC := get("SELECT 3")
+	require.Len(t, cache.entries, 2)
+	require.NotContains(t, cache.entries, "SELECT 2")
+	_, err = b.Exec()
+	require.ErrorContains(t, err, "statement is closed")
+
+	// SELECT 3 is still in use when it is evicted, so it is only closed once
+	// it is released.
+	_, releaseD := get("SELECT 4")
+	releaseD()
+	_, releaseE := get("SELECT 5")
+	releaseE()
+	require.NotContains(t, cache.entries, "SELECT 3")
+	_, err = c.Exec()
+	require.NoError(t, err)
+	releaseC()
+	_, err = c.Exec()
+	require.ErrorContains(t, err, "statement is closed")
+}
+
+// slowConnector opens connections whose prepares of SLOW signal preparing and
+// then wait until release is closed.
+type slowConnector struct {
+	preparing chan struct{}
+	release   chan struct{}
+}
+
+func (c slowConnector) Connect(context.Context) (driver.Conn, error) {
+	return slowConn{rowsConn(1), c}, nil
+}
+
+func (slowConnector) Driver() driver.Driver { return rowsDriver{} }
+
+type slowConn struct {
+	rowsConn
+	c slowConnector
+}
+
+func (conn slowConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "SLOW" {
+		conn.c.preparing <- struct{}{}
+		<-conn.c.release
+	}
+	return conn.rowsConn.Prepare(query)
+}
+
+func TestStmtCache_PreparesWithoutLock(t *testing.T) {
+	connector := slowConnector{preparing: make(chan struct{}), release: make(chan struct{})}
+	db := sqlx.NewDb(sql.OpenDB(connector), bindDriver)
+	defer db.Close()
+	cache := newStmtCache(2)
+
+	_, release, err := cache.get(db, "SELECT 1")
+	require.NoError(t, err)
+	release()
+
+	stmts := make(chan *sqlx.Stmt, 2)
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			stmt, release, err := cache.get(db, "SLOW")
+			release()
+			stmts <- stmt
+			errs <- err
+		}()
+	}
+	<-connector.preparing
+	<-connector.preparing
+
+	// Both prepares of SLOW are blocked, yet cached statements are served.
+	_, release, err = cache.get(db, "SELECT 1")
+	require.NoError(t, err)
+	release()
+
+	close(connector.release)
+	first, second := <-stmts, <-stmts
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	require.Same(t, first, second)
+	require.Len(t, cache.entries, 2)
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrNoTenant       = errors.New("no tenant in context")
+	ErrTenantMismatch = errors.New("entity belongs to another tenant")
+)
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of ctx carrying tenantID for repositories
+// created with WithTenantColumn.
+func ContextWithTenant(ctx context.Context, tenantID any) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant id stored in ctx by ContextWithTenant.
+func TenantFromContext(ctx context.Context) (any, bool) {
+	tenantID := ctx.Value(tenantContextKey{})
+	return tenantID, tenantID != nil
+}
+
+// WithTenantColumn isolates tenants sharing a table by column. Every query
+// the repository runs is restricted to the rows whose column equals the
+// tenant id of the repository's context, see WithContext and
+// ContextWithTenant, and inserted entities get the tenant id written into
+// their field; an entity already carrying another tenant's id fails with
+// ErrTenantMismatch, and upserts leave other tenants' conflicting rows
+// untouched. Without a tenant in the context every operation fails with
+// ErrNoTenant. Work across tenants needs a repository without this option.
+//
+// The repository fails every query if E has no such column.
+func WithTenantColumn(column string) Option {
+	return func(o *options) {
+		o.tenantColumn = column
+	}
+}
+
+// tenantField returns the column holding the tenant id, if any.
+func (r *entityRepository[E, ID]) tenantField() (column, bool) {
+	if r.options.tenantColumn == "" {
+		return column{}, false
+	}
+	return r.column(r.options.tenantColumn)
+}
+
+// ready fails when the repository cannot run queries: it is misconfigured,
+// the entity's read scopes are invalid, or it isolates tenants and its
+// context carries none.
+func (r *entityRepository[E, ID]) ready() error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.addGlobalScopes(r.tenantWhere()); err != nil {
+		return err
+	}
+	return r.requireTenant()
+}
+
+// requireTenant fails when the repository isolates tenants and its context
+// carries none.
+func (r *entityRepository[E, ID]) requireTenant() error {
+	if r.options.tenantColumn == "" {
+		return nil
+	}
+	if _, ok := TenantFromContext(r.ctx); !ok {
+		return ErrNoTenant
+	}
+	return nil
+}
+
+// tenantWhere starts a WHERE clause restricted to the tenant of the
+// repository's context, if it isolates tenants.
+func (r *entityRepository[E, ID]) tenantWhere() *whereBuilder {
+	w := &whereBuilder{dialect: r.options.dialect, transformers: r.options.transformers}
+	if r.options.tenantColumn == "" {
+		return w
+	}
+	if tenantID, ok := TenantFromContext(r.ctx); ok {
+		w.add(fmt.Sprintf("%s = ?", r.options.tenantColumn), tenantID)
+	}
+	return w
+}
+
+// stampTenant writes the tenant id of the repository's context into entity.
+func (r *entityRepository[E, ID]) stampTenant(entity *E) error {
+	c, ok := r.tenantField()
+	if !ok {
+		return nil
+	}
+	tenantID, ok := TenantFromContext(r.ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+
+	field := reflect.ValueOf(entity).Elem().FieldByIndex(c.Index)
+	value := reflect.ValueOf(tenantID)
+	if !value.Type().ConvertibleTo(field.Type()) {
+		return fmt.Errorf("tenant id %v of type %T does not fit column %q", tenantID, tenantID, c.Name)
+	}
+	value = value.Convert(field.Type())
+	if !field.IsZero() && field.Interface() != value.Interface() {
+		return fmt.Errorf("%w: %v", ErrTenantMismatch, field.Interface())
+	}
+	field.Set(value)
+	return nil
+}
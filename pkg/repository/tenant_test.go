@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTenantColumn(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[TenantEntity](db, WithTenantColumn("tenant_id"), WithQueryCapture())
+	_, err = repo.FindAll()
+	require.ErrorIs(t, err, ErrNoTenant)
+	require.ErrorIs(t, repo.Save(&TenantEntity{Name: "a"}), ErrNoTenant)
+
+	tenantRepo := repo.WithContext(ContextWithTenant(context.Background(), 7))
+	_, err = tenantRepo.FindAll()
+	require.Error(t, err)
+	query, args := tenantRepo.LastQuery()
+	require.Equal(t, "SELECT * FROM tenant_entities WHERE tenant_id = ?", query)
+	require.Equal(t, []any{7}, args)
+
+	require.Error(t, tenantRepo.DeleteByIDs([]int64{1}))
+	query, args = tenantRepo.LastQuery()
+	require.Equal(t, "DELETE FROM tenant_entities WHERE tenant_id = ? AND id IN (?)", query)
+	require.Equal(t, []any{7, int64(1)}, args)
+
+	entity := TenantEntity{Name: "a"}
+	require.Error(t, tenantRepo.Save(&entity))
+	require.Equal(t, int64(7), entity.TenantID)
+
+	require.ErrorIs(t, tenantRepo.Save(&TenantEntity{TenantID: 8, Name: "b"}), ErrTenantMismatch)
+
+	_, err = NewEntityRepository[SampleEntity](db, WithTenantColumn("tenant_id")).
+		WithContext(ContextWithTenant(context.Background(), 7)).FindAll()
+	require.EqualError(t, err, `tenant column "tenant_id" is not a column of repository.SampleEntity`)
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrQueryTimeout = errors.New("query timed out")
+
+// WithQueryTimeout bounds every read that loads its whole result, such as
+// FindAll, FindByID or the counts, to timeout, including retries. A read that
+// runs out of time fails with an error wrapping ErrQueryTimeout instead of
+// context.DeadlineExceeded, so handlers can tell a slow database apart from a
+// caller that went away, whose cancellation is still returned as is. Stream
+// and QueryRows hand their rows to the caller and are not bounded.
+//
+// The driver gives up on a timed-out query by closing its connection, so in a
+// transaction a timeout also ends the transaction.
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.queryTimeout = timeout
+	}
+}
+
+// withQueryTimeout runs fn with a view of the repository whose context
+// expires after the configured query timeout, if any.
+func (r *entityRepository[E, ID]) withQueryTimeout(fn func(r *entityRepository[E, ID]) error) error {
+	timeout := r.options.queryTimeout
+	if timeout <= 0 {
+		return fn(r)
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+
+	timed := *r
+	timed.ctx = ctx
+	err := fn(&timed)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && r.ctx.Err() == nil {
+		return fmt.Errorf("%w after %s", ErrQueryTimeout, timeout)
+	}
+	return err
+}
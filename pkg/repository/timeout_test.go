@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithQueryTimeout(t *testing.T) {
+	slowQuery := func(r *entityRepository[SampleEntity, int64]) error {
+		<-r.ctx.Done()
+		return r.ctx.Err()
+	}
+
+	repo := &entityRepository[SampleEntity, int64]{
+		ctx:     context.Background(),
+		options: newOptions([]Option{WithQueryTimeout(time.Millisecond)}),
+	}
+	err := repo.withQueryTimeout(slowQuery)
+	require.ErrorIs(t, err, ErrQueryTimeout)
+	require.NotErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	repo.ctx = ctx
+	require.ErrorIs(t, repo.withQueryTimeout(slowQuery), context.Canceled)
+
+	require.NoError(t, repo.withQueryTimeout(func(*entityRepository[SampleEntity, int64]) error { return nil }))
+}
@@ -0,0 +1,47 @@
+package repository
+
+import "fmt"
+
+// updatedAtColumn returns the column tracking when a row was last updated:
+// the column tagged with the updatedat option, e.g.
+// db:"modified,updatedat", or else the column named updated_at.
+func (r *entityRepository[E, ID]) updatedAtColumn() (column, bool) {
+	for _, c := range r.columns() {
+		if c.has("updatedat") {
+			return c, true
+		}
+	}
+	c, ok := r.column("updated_at")
+	return c, ok && !c.has("readonly")
+}
+
+// Touch sets the updated_at column of the row with id to NOW(6) without
+// changing anything else, e.g. to mark a row as recently used, and returns the
+// number of rows it matched. A versioned entity, one with a column tagged
+// db:"...,version", also has its version incremented. It returns ErrNotFound
+// if no visible row has the id.
+func (r *entityRepository[E, ID]) Touch(id ID) (int64, error) {
+	c, ok := r.updatedAtColumn()
+	if !ok {
+		return 0, fmt.Errorf("entity has no updated_at column")
+	}
+
+	where := r.where()
+	where.add("id = ?", id)
+	assignments := fmt.Sprintf("%s = NOW(6)", c.Name)
+	if version, ok := r.versionColumn(); ok {
+		assignments += fmt.Sprintf(", %s = %s + 1", version.Name, version.Name)
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s%s", r.table(), assignments, where)
+	affected, err := r.execAffected(query, where.args...)
+	if err != nil || affected > 0 {
+		return affected, err
+	}
+
+	// MySQL does not count a row whose value did not change, which happens
+	// when a column without fractional seconds is touched twice in a second.
+	if err := r.ExistsByID(id); err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTouch(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[ArticleEntity](db, WithQueryCapture())
+	_, err = repo.Touch(1)
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "UPDATE article_entities SET updated_at = NOW(6), version = version + 1 WHERE id = ?", query)
+	require.Equal(t, []any{int64(1)}, args)
+
+	_, err = NewEntityRepository[SampleEntity](db).Touch(1)
+	require.ErrorContains(t, err, "no updated_at column")
+}
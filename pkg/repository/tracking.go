@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// WithChangeTracking makes the repository remember the column values of every
+// entity loaded by FindByID so that Update writes only the columns changed
+// since. Columns changed concurrently by someone else are left alone unless
+// the entity changed them too.
+//
+// A snapshot of every column is kept per tracked id until the entity is
+// updated with a new snapshot or released with Untrack, so memory grows with
+// the number of distinct entities loaded. Long-lived repositories that load
+// many entities should call Untrack once they are done with one.
+func WithChangeTracking() Option {
+	return func(o *options) {
+		o.changeTracking = true
+	}
+}
+
+// changeTracker holds the last known column values of tracked entities. It is
+// shared by all views of a repository.
+type changeTracker[ID comparable] struct {
+	mu        sync.Mutex
+	snapshots map[ID]map[string]any
+}
+
+func newChangeTracker[ID comparable]() *changeTracker[ID] {
+	return &changeTracker[ID]{snapshots: make(map[ID]map[string]any)}
+}
+
+func (t *changeTracker[ID]) get(id ID) (map[string]any, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snapshot, ok := t.snapshots[id]
+	return snapshot, ok
+}
+
+func (t *changeTracker[ID]) set(id ID, snapshot map[string]any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.snapshots[id] = snapshot
+}
+
+func (t *changeTracker[ID]) remove(id ID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.snapshots, id)
+}
+
+// snapshot returns the bound value of every column of entity. Pointers are
+// dereferenced and byte slices copied so later changes to the entity do not
+// leak into the snapshot.
+func (r *entityRepository[E, ID]) snapshot(entity *E) map[string]any {
+	entityValue := reflect.ValueOf(entity).Elem()
+	values := make(map[string]any)
+	for _, c := range r.columns() {
+		if c.Index == nil {
+			// A document that fails to marshal is reported when it is saved.
+			document, _ := marshalDocument(entityValue)
+			values[c.Name] = document
+			continue
+		}
+		values[c.Name] = snapshotValue(entityValue.FieldByIndex(c.Index))
+	}
+	return values
+}
+
+func snapshotValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if b, ok := v.Interface().([]byte); ok {
+		return append([]byte(nil), b...)
+	}
+	return bindValue(v.Interface())
+}
+
+// track stores a snapshot of entities when change tracking is enabled.
+func (r *entityRepository[E, ID]) track(entities ...*E) {
+	if r.tracker == nil {
+		return
+	}
+	for _, entity := range entities {
+		r.tracker.set((*entity).GetID(), r.snapshot(entity))
+	}
+}
+
+// Update writes entity to its row. With change tracking enabled and a
+// snapshot of the entity on hand, only the columns that differ from the
+// snapshot are written and nothing is sent when none do; otherwise every
+// updatable column is written. See UpdatableEntity for which columns are.
+func (r *entityRepository[E, ID]) Update(entity *E) error {
+	return r.update(entity, nil)
+}
+
+// update writes entity back. With a non-nil version the row must still hold
+// that version in its version column, or ErrVersionConflict is returned.
+func (r *entityRepository[E, ID]) update(entity *E, version any) error {
+	if err := verifyChecks(entity); err != nil {
+		return err
+	}
+	id := (*entity).GetID()
+	current := r.snapshot(entity)
+
+	var previous map[string]any
+	if r.tracker != nil {
+		previous, _ = r.tracker.get(id)
+	}
+
+	var assignments []string
+	var args []interface{}
+	for _, c := range r.updatableColumns() {
+		if previous != nil && reflect.DeepEqual(previous[c.Name], current[c.Name]) {
+			continue
+		}
+		value, err := r.columnValue(reflect.ValueOf(entity).Elem(), c)
+		if err != nil {
+			return err
+		}
+		assignments = append(assignments, c.Name+" = ?")
+		args = append(args, value)
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	where := r.where()
+	where.add("id = ?", id)
+	if version != nil {
+		c, _ := r.versionColumn()
+		where.add(c.Name+" = ?", version)
+	}
+	query := fmt.Sprintf("UPDATE %s SET %s%s", r.table(), strings.Join(assignments, ", "), where)
+	affected, err := r.execAffected(query, append(args, where.args...)...)
+	if err != nil {
+		return err
+	}
+	if version != nil && affected == 0 {
+		return fmt.Errorf("%w: entity %v no longer has version %v", ErrVersionConflict, id, version)
+	}
+
+	if r.tracker != nil {
+		r.tracker.set(id, current)
+	}
+	return nil
+}
+
+// Untrack drops the snapshot held for id, if any.
+func (r *entityRepository[E, ID]) Untrack(id ID) {
+	if r.tracker != nil {
+		r.tracker.remove(id)
+	}
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotValue(t *testing.T) {
+	now := time.Now()
+	at := now
+	entity := struct {
+		At   *time.Time
+		Data []byte
+		None *string
+	}{At: &at, Data: []byte("abc")}
+	value := reflect.ValueOf(&entity).Elem()
+
+	atSnapshot := snapshotValue(value.Field(0))
+	data := snapshotValue(value.Field(1))
+	require.Nil(t, snapshotValue(value.Field(2)))
+
+	*entity.At = now.Add(time.Hour)
+	entity.Data[0] = 'x'
+	require.Equal(t, now.Truncate(time.Microsecond), atSnapshot)
+	require.Equal(t, []byte("abc"), data)
+}
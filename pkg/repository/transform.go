@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// ColumnTransformer converts a field to and from the bytes stored in its
+// column, e.g. to compress a large text field or store a value in a custom
+// binary format.
+type ColumnTransformer interface {
+	// Decode converts the stored bytes into a value assignable or
+	// convertible to the field's type. It is not called for NULL.
+	Decode(data []byte) (any, error)
+	// Encode converts the field's value into the bytes to store.
+	Encode(value any) ([]byte, error)
+}
+
+// WithColumnTransformer makes the repository store column through
+// transformer: Encode runs on every insert, upsert and update and Decode on
+// every entity read. Conditions comparing column for equality, e.g. Eq or
+// WhereNotIn, encode their values the same way; other operators cannot be
+// used on it. If E has no such column, every query of the repository fails.
+func WithColumnTransformer(column string, transformer ColumnTransformer) Option {
+	return func(o *options) {
+		if o.transformers == nil {
+			o.transformers = make(map[string]ColumnTransformer)
+		}
+		o.transformers[column] = transformer
+	}
+}
+
+// checkTransformers fails when a transformer is registered for a column E
+// does not map.
+func (r *entityRepository[E, ID]) checkTransformers() error {
+	validColumns := r.validColumns()
+	for column := range r.options.transformers {
+		if !validColumns[column] {
+			var emptyEntity E
+			return fmt.Errorf("transformer registered for unknown column %q of %T", column, emptyEntity)
+		}
+	}
+	return nil
+}
+
+// encodeCondition returns c with its values encoded by transformer, for a
+// condition on a transformed column. Only equality of encoded values means
+// anything, so other operators and collations are rejected.
+func encodeCondition(transformer ColumnTransformer, c Condition) (Condition, error) {
+	operator := strings.ToUpper(strings.TrimSpace(c.Operator))
+	switch {
+	case operator != "=" && operator != "!=" && operator != "<>" && operator != "NOT IN":
+		return c, fmt.Errorf("operator %q cannot be used on transformed column %q", c.Operator, c.Column)
+	case c.Collation != "":
+		return c, fmt.Errorf("collation cannot be used on transformed column %q", c.Column)
+	case c.Value == nil:
+		return c, nil
+	}
+	encode := func(value any) (any, error) {
+		encoded, err := transformer.Encode(value)
+		if err != nil {
+			return nil, fmt.Errorf("encode column %q: %w", c.Column, err)
+		}
+		return encoded, nil
+	}
+	if operator != "NOT IN" {
+		encoded, err := encode(c.Value)
+		c.Value = encoded
+		return c, err
+	}
+	list := reflect.ValueOf(c.Value)
+	if list.Kind() != reflect.Slice {
+		return c, fmt.Errorf("NOT IN needs a list of values, got %T", c.Value)
+	}
+	values := make([]any, list.Len())
+	for i := range values {
+		encoded, err := encode(list.Index(i).Interface())
+		if err != nil {
+			return c, err
+		}
+		values[i] = encoded
+	}
+	c.Value = values
+	return c, nil
+}
+
+// columnValue returns the query argument for column c of entityValue, encoded
+// by the column's transformer if it has one.
+func (r *entityRepository[E, ID]) columnValue(entityValue reflect.Value, c column) (any, error) {
+	if c.Index == nil {
+		return marshalDocument(entityValue)
+	}
+	value := entityValue.FieldByIndex(c.Index).Interface()
+	transformer, ok := r.options.transformers[c.Name]
+	if !ok {
+		return bindValue(value), nil
+	}
+	encoded, err := transformer.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("encode column %q: %w", c.Name, err)
+	}
+	return encoded, nil
+}
+
+// scanEntity scans the current row into entity, decoding transformed columns
+// and the document of a DocumentEntity.
+func (r *entityRepository[E, ID]) scanEntity(rows *sqlx.Rows, entity *E) error {
+	if !r.scansManually() {
+		return rows.StructScan(entity)
+	}
+	documentColumn, _ := r.documentColumn()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	entityValue := reflect.ValueOf(entity).Elem()
+	fields := r.DB.Mapper.TraversalsByName(entityValue.Type(), columns)
+
+	targets := make([]any, len(columns))
+	encoded := make(map[int]*[]byte)
+	var document *[]byte
+	for i, name := range columns {
+		switch {
+		case name == documentColumn.Name:
+			document = new([]byte)
+			targets[i] = document
+		case len(fields[i]) == 0:
+			return fmt.Errorf("missing destination name %s in %T", name, entity)
+		case r.options.transformers[name] != nil:
+			encoded[i] = new([]byte)
+			targets[i] = encoded[i]
+		default:
+			targets[i] = reflectx.FieldByIndexes(entityValue, fields[i]).Addr().Interface()
+		}
+	}
+	if err := rows.Scan(targets...); err != nil {
+		return err
+	}
+
+	for i, data := range encoded {
+		if *data == nil {
+			continue
+		}
+		decoded, err := r.options.transformers[columns[i]].Decode(*data)
+		if err != nil {
+			return fmt.Errorf("decode column %q: %w", columns[i], err)
+		}
+		if decoded == nil {
+			continue
+		}
+		field := reflectx.FieldByIndexes(entityValue, fields[i])
+		value := reflect.ValueOf(decoded)
+		switch {
+		case value.Type().AssignableTo(field.Type()):
+			field.Set(value)
+		case value.Type().ConvertibleTo(field.Type()):
+			field.Set(value.Convert(field.Type()))
+		default:
+			return fmt.Errorf("decode column %q: cannot assign %T to field of type %s", columns[i], decoded, field.Type())
+		}
+	}
+
+	if document != nil && *document != nil {
+		if err := any(entity).(DocumentEntity).UnmarshalDocument(*document); err != nil {
+			return fmt.Errorf("unmarshal document: %w", err)
+		}
+	}
+	return nil
+}
+
+// selectEntities runs query and scans every row into a new entity.
+func (r *entityRepository[E, ID]) selectEntities(dest *[]*E, query string, args ...any) error {
+	rows, err := r.queryRows(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entity := new(E)
+		if err := r.scanEntity(rows, entity); err != nil {
+			return err
+		}
+		*dest = append(*dest, entity)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithColumnTransformer_UnknownColumn(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	_, err = NewEntityRepository[CompressedEntity](db, WithColumnTransformer("body", gzipTransformer{})).FindAll()
+	require.EqualError(t, err, "sql: database is closed")
+
+	_, err = NewEntityRepository[CompressedEntity](db, WithColumnTransformer("unknown", gzipTransformer{})).FindAll()
+	require.EqualError(t, err, `transformer registered for unknown column "unknown" of repository.CompressedEntity`)
+}
+
+func TestScanEntity_UnmappedColumn(t *testing.T) {
+	db, err := sql.Open("sqlrepo_rows", "1")
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = NewEntityRepository[NamedDocumentEntity](db).FindAll()
+	require.EqualError(t, err, "missing destination name name in *repository.NamedDocumentEntity")
+}
+
+func TestWithColumnTransformer_Conditions(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+	repo := NewEntityRepository[CompressedEntity](db, WithColumnTransformer("body", gzipTransformer{}), WithQueryCapture())
+
+	x, err := gzipTransformer{}.Encode("x")
+	require.NoError(t, err)
+	y, err := gzipTransformer{}.Encode("y")
+	require.NoError(t, err)
+
+	_, _ = repo.FindBySpec(&QuerySpec{Where: []Condition{Eq("body", "x"), WhereNotIn("body", "x", "y")}})
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM compressed_entities WHERE body = ? AND body NOT IN (?,?)", query)
+	require.Equal(t, []any{x, x, y}, args)
+
+	_, _ = repo.FindBySpec(&QuerySpec{Where: []Condition{Eq("body", nil)}})
+	query, _ = repo.LastQuery()
+	require.Equal(t, "SELECT * FROM compressed_entities WHERE body IS NULL", query)
+
+	_, err = repo.FindBySpec(&QuerySpec{Where: []Condition{Where("body", "LIKE", "x%")}})
+	require.EqualError(t, err, `operator "LIKE" cannot be used on transformed column "body"`)
+	_, err = repo.FindBySpec(&QuerySpec{Where: []Condition{Eq("body", "x").Collate("utf8mb4_bin")}})
+	require.EqualError(t, err, `collation cannot be used on transformed column "body"`)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FindByTuples returns the entities whose columns equal all values of any of
+// tuples, e.g. FindByTuples([]string{"region", "tier"}, [][]any{{"EU", 1},
+// {"US", 2}}) matches (region, tier) IN (("EU", 1), ("US", 2)). Every tuple
+// must have one value per column. No tuples match no rows, and long lists
+// are queried in chunks.
+func (r *entityRepository[E, ID]) FindByTuples(columns []string, tuples [][]any) ([]*E, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no columns to match")
+	}
+	validColumns := r.validColumns()
+	for _, column := range columns {
+		if !validColumns[column] {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+	}
+	for i, tuple := range tuples {
+		if len(tuple) != len(columns) {
+			return nil, fmt.Errorf("tuple %d has %d values, want %d", i, len(tuple), len(columns))
+		}
+	}
+
+	tableName := r.table()
+	tuplePlaceholder := "(" + placeholders(len(columns)) + ")"
+	chunkSize := max(maxInListSize/len(columns), 1)
+
+	entities := []*E{}
+	for _, tupleChunk := range chunk(tuples, chunkSize) {
+		var args []any
+		for _, tuple := range tupleChunk {
+			for _, value := range tuple {
+				args = append(args, bindValue(value))
+			}
+		}
+		list := strings.TrimSuffix(strings.Repeat(tuplePlaceholder+",", len(tupleChunk)), ",")
+
+		where := r.where()
+		where.add(fmt.Sprintf("(%s) IN (%s)", strings.Join(columns, ", "), list), args...)
+
+		var chunkEntities []*E
+		query := fmt.Sprintf("SELECT * FROM %s%s", tableName, where)
+		if err := r.selectAll(&chunkEntities, query, where.args...); err != nil {
+			return nil, err
+		}
+		entities = append(entities, chunkEntities...)
+	}
+	return entities, nil
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindByTuples(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[OrderEntity](db, WithQueryCapture())
+	_, err = repo.FindByTuples([]string{"customer", "amount"}, [][]any{{"alice", 1}, {"bob", 2}})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "SELECT * FROM order_entities WHERE (customer, amount) IN ((?,?),(?,?))", query)
+	require.Equal(t, []any{"alice", 1, "bob", 2}, args)
+
+	entities, err := repo.FindByTuples([]string{"customer", "amount"}, nil)
+	require.NoError(t, err)
+	require.Empty(t, entities)
+
+	_, err = repo.FindByTuples([]string{"customer", "amount"}, [][]any{{"alice"}})
+	require.ErrorContains(t, err, "tuple 0 has 1 values, want 2")
+	_, err = repo.FindByTuples([]string{"unknown"}, [][]any{{1}})
+	require.ErrorContains(t, err, `unknown column "unknown"`)
+}
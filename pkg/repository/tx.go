@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrNestedSnapshot = errors.New("read snapshot cannot be opened inside a transaction")
+
+// RunInTx runs fn inside a transaction, committing when fn returns nil and
+// rolling back otherwise. The repository handed to fn is bound to the
+// transaction and must not be used after fn returns. Calling RunInTx on a
+// transaction-bound repository joins the existing transaction.
+func (r *entityRepository[E, ID]) RunInTx(fn func(tx TxRepository[E, ID]) error) error {
+	return r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		return fn(txRepo)
+	})
+}
+
+func (r *entityRepository[E, ID]) inTx(fn func(txRepo *entityRepository[E, ID]) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	return r.beginTx(nil, fn)
+}
+
+// ReadSnapshot runs fn with a repository bound to a read-only REPEATABLE READ
+// transaction, so every read fn makes sees the same consistent snapshot of the
+// database, taken at its first read. Writes through the repository fail. The
+// transaction ends when fn returns.
+func (r *entityRepository[E, ID]) ReadSnapshot(fn func(snapshot Repository[E, ID]) error) error {
+	if r.tx != nil {
+		return ErrNestedSnapshot
+	}
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	return r.beginTx(opts, func(txRepo *entityRepository[E, ID]) error {
+		return fn(txRepo)
+	})
+}
+
+func (r *entityRepository[E, ID]) beginTx(opts *sql.TxOptions, fn func(txRepo *entityRepository[E, ID]) error) error {
+	return runTx(r.ctx, r.DB, opts, func(tx *sqlx.Tx) error {
+		return fn(r.withTx(tx))
+	})
+}
+
+// runTx runs fn in a new transaction on db, committing when fn returns nil and
+// rolling back when it fails or panics.
+func runTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// withTx returns a copy of the repository bound to tx. The copy scans with
+// the repository's mapper even when tx was opened elsewhere, e.g. by
+// RunUnitOfWork, without changing the mapper of tx itself, which other
+// repositories may share.
+func (r *entityRepository[E, ID]) withTx(tx *sqlx.Tx) *entityRepository[E, ID] {
+	mapped := *tx
+	mapped.Mapper = r.DB.Mapper
+	txRepo := *r
+	txRepo.tx = &mapped
+	return &txRepo
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// UnitOfWork is a transaction shared by repositories of different entity
+// types. Obtain repositories bound to it with Repo.
+type UnitOfWork struct {
+	db  *sql.DB
+	tx  *sqlx.Tx
+	ctx context.Context
+}
+
+// RunUnitOfWork runs fn inside a single transaction on db, committing when fn
+// returns nil and rolling back otherwise, so every write made through the
+// unit's repositories succeeds or fails together. The unit of work and its
+// repositories must not be used after fn returns.
+func RunUnitOfWork(ctx context.Context, db *sql.DB, fn func(uow *UnitOfWork) error) error {
+	return runTx(ctx, sqlx.NewDb(db, bindDriver), nil, func(tx *sqlx.Tx) error {
+		return fn(&UnitOfWork{db: db, tx: tx, ctx: ctx})
+	})
+}
+
+// Repo returns a repository for E bound to the unit of work's transaction,
+// configured by opts as NewEntityRepository would be.
+func Repo[E Entity[ID], ID comparable](uow *UnitOfWork, opts ...Option) TxRepository[E, ID] {
+	r := NewEntityRepository[E, ID](uow.db, opts...).(*entityRepository[E, ID])
+	r.ctx = uow.ctx
+	return r.withTx(uow.tx)
+}
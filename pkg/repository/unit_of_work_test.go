@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTx_Mapper(t *testing.T) {
+	r := NewEntityRepository[UntaggedEntity](nil).(*entityRepository[UntaggedEntity, int64])
+	shared := &sqlx.Tx{}
+
+	txRepo := r.withTx(shared)
+	require.Same(t, r.DB.Mapper, txRepo.tx.Mapper)
+	require.Nil(t, shared.Mapper)
+}
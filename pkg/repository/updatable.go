@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"slices"
+)
+
+// UpdatableEntity is implemented by entities that whitelist the columns
+// updates may write. Columns missing from UpdatableColumns keep their stored
+// value on Update and on the update half of an upsert, however the entity's
+// fields are set. A column can also be protected individually with the
+// immutable tag option, e.g. db:"role,immutable".
+type UpdatableEntity interface {
+	UpdatableColumns() []string
+}
+
+// updatableColumns returns the columns an update of E may write: every column
+// except the id, the tenant column, immutable and readonly columns and, when E
+// whitelists its updatable columns, any column not in the whitelist.
+func (r *entityRepository[E, ID]) updatableColumns() []column {
+	var whitelist []string
+	updatable, restricted := any(new(E)).(UpdatableEntity)
+	if restricted {
+		whitelist = updatable.UpdatableColumns()
+	}
+
+	var columns []column
+	for _, c := range r.columns() {
+		if c.Name == "id" || c.Name == r.options.tenantColumn || c.has("immutable") || c.has("readonly") {
+			continue
+		}
+		if restricted && !slices.Contains(whitelist, c.Name) {
+			continue
+		}
+		columns = append(columns, c)
+	}
+	return columns
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatableColumns(t *testing.T) {
+	repo := &entityRepository[AccountEntity, int64]{options: newOptions(nil)}
+	var names []string
+	for _, c := range repo.updatableColumns() {
+		names = append(names, c.Name)
+	}
+	require.Equal(t, []string{"name"}, names)
+
+	sampleRepo := &entityRepository[SampleEntity, int64]{options: newOptions(nil)}
+	require.Len(t, sampleRepo.updatableColumns(), 1)
+}
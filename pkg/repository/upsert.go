@@ -0,0 +1,302 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BulkResult summarizes a bulk write. IDs holds the id of every entity in the
+// order they were passed.
+type BulkResult[ID comparable] struct {
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+	Skipped  int64 `json:"skipped"`
+	IDs      []ID  `json:"ids"`
+}
+
+// UpsertAll inserts entities, updating every updatable column of rows that
+// conflict on the primary key or a unique key; see UpdatableEntity. Entities
+// with a zero auto-incremented id are inserted with a newly assigned id, which
+// is not written back to the entity.
+func (r *entityRepository[E, ID]) UpsertAll(entities []*E) error {
+	_, err := r.upsertAll(entities, nil)
+	return err
+}
+
+// MergeRule decides which value a column keeps when an upsert conflicts with
+// an existing row.
+type MergeRule int
+
+const (
+	// IncomingWins stores the incoming value. It is the rule of columns
+	// missing from a MergeStrategy.
+	IncomingWins MergeRule = iota
+	// GreatestWins keeps the greater of the stored and the incoming value,
+	// e.g. for version counters, with
+	// GREATEST(COALESCE(col, VALUES(col)), COALESCE(VALUES(col), col)) so that
+	// a NULL on either side does not win.
+	GreatestWins
+	// LeastWins keeps the lesser of the two values, e.g. for first-seen
+	// timestamps, with the LEAST counterpart of GreatestWins.
+	LeastWins
+	// CoalesceNonNull stores the incoming value unless it is NULL, with
+	// COALESCE(VALUES(col), col), so NULLs never overwrite known values.
+	CoalesceNonNull
+)
+
+// MergeStrategy maps updatable columns to the rule resolving their conflicts.
+type MergeStrategy map[string]MergeRule
+
+// Upsert is UpsertAll with the value each conflicting column keeps decided by
+// strategy, e.g.
+//
+//	repo.Upsert(entities, MergeStrategy{"version": GreatestWins, "name": CoalesceNonNull})
+//
+// Every column of strategy must be one upserts update; see UpdatableEntity.
+func (r *entityRepository[E, ID]) Upsert(entities []*E, strategy MergeStrategy) error {
+	_, err := r.upsertAll(entities, strategy)
+	return err
+}
+
+func (r *entityRepository[E, ID]) upsertAll(entities []*E, strategy MergeStrategy) (sql.Result, error) {
+	if len(entities) == 0 {
+		return nil, nil
+	}
+
+	updates, err := r.upsertAssignments(strategy)
+	if err != nil {
+		return nil, err
+	}
+	insert, err := r.buildInsert(entities, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(updates) == 0 {
+		updates = append(updates, "id = id")
+	}
+
+	query := insert.query + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	result, err := r.exec(query, insert.args...)
+	if err != nil {
+		return nil, err
+	}
+	r.rememberIDs(entities)
+	return result, nil
+}
+
+// upsertAssignments returns the ON DUPLICATE KEY UPDATE assignments of an
+// upsert, merging each column according to strategy. Each one only takes
+// effect when the conflicting row belongs to the repository's tenant and, with
+// WithChangedOnlyUpsert, when a compared column changed.
+func (r *entityRepository[E, ID]) upsertAssignments(strategy MergeStrategy) ([]string, error) {
+	columns := r.updatableColumns()
+	for name, rule := range strategy {
+		if rule < IncomingWins || rule > CoalesceNonNull {
+			return nil, fmt.Errorf("unsupported merge rule %d for column %q", rule, name)
+		}
+		if !slices.ContainsFunc(columns, func(c column) bool { return c.Name == name }) {
+			return nil, fmt.Errorf("column %q is not updated by upserts", name)
+		}
+	}
+
+	var guards []string
+	if tenant := r.options.tenantColumn; tenant != "" {
+		guards = append(guards, fmt.Sprintf("%s = VALUES(%s)", tenant, tenant))
+	}
+	if r.options.changedOnlyUpsert {
+		var ignored, compared []column
+		var unchanged []string
+		for _, c := range columns {
+			if slices.Contains(r.options.changedOnlyIgnore, c.Name) {
+				ignored = append(ignored, c)
+				continue
+			}
+			compared = append(compared, c)
+			unchanged = append(unchanged, fmt.Sprintf("%s <=> VALUES(%s)", c.Name, c.Name))
+		}
+		if len(unchanged) > 0 {
+			guards = append(guards, fmt.Sprintf("NOT (%s)", strings.Join(unchanged, " AND ")))
+		}
+		// MySQL assigns left to right, so the ignored columns go first,
+		// while the compared columns still hold their stored values.
+		columns = append(ignored, compared...)
+	}
+
+	updates := make([]string, len(columns))
+	for i, c := range columns {
+		value := mergeExpr(c.Name, strategy[c.Name])
+		if len(guards) == 0 {
+			updates[i] = fmt.Sprintf("%s = %s", c.Name, value)
+			continue
+		}
+		updates[i] = fmt.Sprintf("%s = IF(%s, %s, %s)", c.Name, strings.Join(guards, " AND "), value, c.Name)
+	}
+	return updates, nil
+}
+
+// mergeExpr returns the value rule assigns to column on a conflict.
+func mergeExpr(column string, rule MergeRule) string {
+	switch rule {
+	case GreatestWins:
+		return fmt.Sprintf("GREATEST(COALESCE(%s, VALUES(%s)), COALESCE(VALUES(%s), %s))", column, column, column, column)
+	case LeastWins:
+		return fmt.Sprintf("LEAST(COALESCE(%s, VALUES(%s)), COALESCE(VALUES(%s), %s))", column, column, column, column)
+	case CoalesceNonNull:
+		return fmt.Sprintf("COALESCE(VALUES(%s), %s)", column, column)
+	default:
+		return fmt.Sprintf("VALUES(%s)", column)
+	}
+}
+
+// SaveAllSummary behaves like SaveAll and reports every entity as inserted.
+func (r *entityRepository[E, ID]) SaveAllSummary(entities []*E) (*BulkResult[ID], error) {
+	if len(entities) == 0 {
+		return &BulkResult[ID]{IDs: []ID{}}, nil
+	}
+
+	res, err := r.insertAll(entities)
+	if err != nil {
+		return nil, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	return &BulkResult[ID]{Inserted: inserted, IDs: entityIDs[E, ID](entities)}, nil
+}
+
+// UpsertAllSummary behaves like UpsertAll and reports how many rows were
+// inserted, updated, or skipped because they already held the same values.
+// Entities with a zero auto-incremented id cannot conflict on the primary key
+// and are inserted as SaveAll would, with their new ids written back and
+// reported in IDs; one conflicting on another unique key fails the call.
+//
+// MySQL reports one affected row per insert, two per update and none for an
+// unchanged row, so the affected row count alone is ambiguous. The rows whose
+// id is among the upserted ones are therefore locked and counted first, within
+// the same transaction and regardless of the repository's scopes, since
+// ON DUPLICATE KEY conflicts with soft-deleted rows and other tenants' rows
+// too: the first entity of every other id is an insert, and the updates are
+// half of the remaining affected rows. Entities conflicting with another
+// tenant's row leave it untouched and count as skipped. An id given more than
+// once is inserted at most once, and its later entities count as updated or
+// skipped. Conflicts on unique keys other than the primary key are counted as
+// inserts.
+func (r *entityRepository[E, ID]) UpsertAllSummary(entities []*E) (*BulkResult[ID], error) {
+	if len(entities) == 0 {
+		return &BulkResult[ID]{IDs: []ID{}}, nil
+	}
+
+	explicit, generated := entities, []*E(nil)
+	if c, ok := r.column("id"); ok && c.has("autoincrement") {
+		explicit, generated = r.partitionByID(entities)
+	}
+
+	seen := make(map[ID]bool)
+	var existingArgs []interface{}
+	for _, entity := range explicit {
+		if id := (*entity).GetID(); !seen[id] {
+			seen[id] = true
+			existingArgs = append(existingArgs, id)
+		}
+	}
+
+	result := &BulkResult[ID]{}
+	err := r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		if len(explicit) > 0 {
+			existing, foreign, err := txRepo.conflictingIDs(existingArgs)
+			if err != nil {
+				return err
+			}
+
+			res, err := txRepo.upsertAll(explicit, nil)
+			if err != nil {
+				return err
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			result.Inserted = int64(len(seen)-len(foreign)) - existing
+			result.Updated = (affected - result.Inserted) / 2
+			result.Skipped = int64(len(explicit)) - result.Inserted - result.Updated
+		}
+
+		if len(generated) > 0 {
+			res, err := txRepo.insertRows(generated, false)
+			if err != nil {
+				return err
+			}
+			inserted, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			result.Inserted += inserted
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.IDs = entityIDs[E, ID](entities)
+	return result, nil
+}
+
+// conflictingIDs locks the rows of the table whose id is among ids, whatever
+// the repository's scopes, and returns how many of them an upsert would
+// update and the ids of those belonging to another tenant, which it leaves
+// untouched.
+func (r *entityRepository[E, ID]) conflictingIDs(ids []any) (existing int64, foreign map[ID]bool, err error) {
+	own, args := "TRUE", []any(nil)
+	if tenant := r.options.tenantColumn; tenant != "" {
+		tenantID, _ := TenantFromContext(r.ctx)
+		own, args = fmt.Sprintf("COALESCE(%s = ?, FALSE)", tenant), []any{tenantID}
+	}
+	var emptyEntity E
+	query := fmt.Sprintf("SELECT id, %s AS own FROM %s WHERE id IN (%s) FOR UPDATE",
+		own, emptyEntity.GetTableName(), placeholders(len(ids)))
+
+	var rows []struct {
+		ID  ID   `db:"id"`
+		Own bool `db:"own"`
+	}
+	if err := r.selectAll(&rows, query, append(args, ids...)...); err != nil {
+		return 0, nil, err
+	}
+	foreign = make(map[ID]bool)
+	for _, row := range rows {
+		if row.Own {
+			existing++
+		} else {
+			foreign[row.ID] = true
+		}
+	}
+	return existing, foreign, nil
+}
+
+// UpsertWithCounts behaves like UpsertAll and returns how many rows were
+// inserted and how many were updated; rows that already held the same values
+// count as neither. See UpsertAllSummary for how the counts are derived from
+// MySQL's affected rows, which count 1 per inserted row, 2 per updated row and
+// 0 per unchanged row. The arithmetic assumes the default connection flags: a
+// DSN with clientFoundRows=true makes MySQL count unchanged rows as 1, so they
+// would be reported as updated.
+func (r *entityRepository[E, ID]) UpsertWithCounts(entities []*E) (inserted, updated int64, err error) {
+	result, err := r.UpsertAllSummary(entities)
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.Inserted, result.Updated, nil
+}
+
+func entityIDs[E Entity[ID], ID comparable](entities []*E) []ID {
+	ids := make([]ID, len(entities))
+	for i, entity := range entities {
+		ids[i] = (*entity).GetID()
+	}
+	return ids
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertAssignments_ChangedOnly(t *testing.T) {
+	repo := &entityRepository[OrderEntity, int64]{options: newOptions(nil)}
+	updates, err := repo.upsertAssignments(nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"customer = VALUES(customer)", "amount = VALUES(amount)"}, updates)
+
+	repo = &entityRepository[OrderEntity, int64]{options: newOptions([]Option{WithChangedOnlyUpsert("amount")})}
+	updates, err = repo.upsertAssignments(nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"amount = IF(NOT (customer <=> VALUES(customer)), VALUES(amount), amount)",
+		"customer = IF(NOT (customer <=> VALUES(customer)), VALUES(customer), customer)",
+	}, updates)
+}
+
+func TestUpsertAssignments_MergeStrategy(t *testing.T) {
+	repo := &entityRepository[OrderEntity, int64]{options: newOptions(nil)}
+	updates, err := repo.upsertAssignments(MergeStrategy{"amount": GreatestWins, "customer": CoalesceNonNull})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"customer = COALESCE(VALUES(customer), customer)",
+		"amount = GREATEST(COALESCE(amount, VALUES(amount)), COALESCE(VALUES(amount), amount))",
+	}, updates)
+
+	_, err = repo.upsertAssignments(MergeStrategy{"id": GreatestWins})
+	require.ErrorContains(t, err, `column "id" is not updated by upserts`)
+	_, err = repo.upsertAssignments(MergeStrategy{"amount": MergeRule(42)})
+	require.ErrorContains(t, err, "unsupported merge rule")
+}
+
+func TestUpsertAllSummary_Conflicts(t *testing.T) {
+	// Row 1 is soft-deleted, which the upsert still updates.
+	connector := &recordingConnector{
+		columns:  []string{"id", "own"},
+		rows:     [][]driver.Value{{int64(1), true}},
+		affected: 3,
+	}
+	deleted := NewEntityRepository[SoftDeleteEntity](sql.OpenDB(connector))
+	result, err := deleted.UpsertAllSummary([]*SoftDeleteEntity{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT id, TRUE AS own FROM soft_delete_entities WHERE id IN (?,?) FOR UPDATE", connector.executed[0])
+	require.Equal(t, int64(1), result.Inserted)
+	require.Equal(t, int64(1), result.Updated)
+	require.Equal(t, int64(0), result.Skipped)
+
+	// Row 1 belongs to another tenant and is left untouched.
+	connector = &recordingConnector{
+		columns:  []string{"id", "own"},
+		rows:     [][]driver.Value{{int64(1), false}},
+		affected: 1,
+	}
+	tenants := NewEntityRepository[TenantEntity](sql.OpenDB(connector), WithTenantColumn("tenant_id")).
+		WithContext(ContextWithTenant(context.Background(), int64(7)))
+	result, err = tenants.UpsertAllSummary([]*TenantEntity{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}})
+	require.NoError(t, err)
+	require.Equal(t, "SELECT id, COALESCE(tenant_id = ?, FALSE) AS own FROM tenant_entities WHERE id IN (?,?) FOR UPDATE", connector.executed[0])
+	require.Equal(t, []driver.Value{int64(7), int64(1), int64(2)}, connector.args[:3])
+	require.Equal(t, int64(1), result.Inserted)
+	require.Equal(t, int64(0), result.Updated)
+	require.Equal(t, int64(1), result.Skipped)
+}
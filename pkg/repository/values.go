@@ -0,0 +1,42 @@
+package repository
+
+// Values converts the result of any finder returning []*E into a slice of
+// values, passing err through, e.g.
+//
+//	users, err := repository.Values(repo.FindAll())
+//
+// The entities are copied, so changes to the returned values do not affect the
+// pointers and vice versa.
+func Values[E any](entities []*E, err error) ([]E, error) {
+	if err != nil {
+		return nil, err
+	}
+	values := make([]E, len(entities))
+	for i, entity := range entities {
+		values[i] = *entity
+	}
+	return values, nil
+}
+
+// PaginatedValues is a PaginatedResult holding its results by value.
+type PaginatedValues[E any] struct {
+	Pagination Pagination `json:"pagination"`
+	TotalCount int        `json:"total_count"`
+	Results    []E        `json:"results"`
+}
+
+// PageValues converts the result of FindAllPaginated into PaginatedValues,
+// passing err through, e.g.
+//
+//	page, err := repository.PageValues(repo.FindAllPaginated(pagination))
+func PageValues[E any](page *PaginatedResult[E], err error) (*PaginatedValues[E], error) {
+	if err != nil {
+		return nil, err
+	}
+	results, _ := Values(page.Results, nil)
+	return &PaginatedValues[E]{
+		Pagination: page.Pagination,
+		TotalCount: page.TotalCount,
+		Results:    results,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValues(t *testing.T) {
+	entities := []*SampleEntity{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}}
+	values, err := Values(entities, nil)
+	require.NoError(t, err)
+	require.Equal(t, []SampleEntity{{Id: 1, Name: "a"}, {Id: 2, Name: "b"}}, values)
+
+	values[0].Name = "changed"
+	require.Equal(t, "a", entities[0].Name)
+
+	_, err = Values([]*SampleEntity(nil), errors.New("boom"))
+	require.EqualError(t, err, "boom")
+}
+
+func TestPageValues(t *testing.T) {
+	page := &PaginatedResult[SampleEntity]{
+		Pagination: Pagination{Limit: 2, Offset: 4},
+		TotalCount: 7,
+		Results:    []*SampleEntity{{Id: 5, Name: "e"}},
+	}
+	values, err := PageValues(page, nil)
+	require.NoError(t, err)
+	require.Equal(t, &PaginatedValues[SampleEntity]{
+		Pagination: Pagination{Limit: 2, Offset: 4},
+		TotalCount: 7,
+		Results:    []SampleEntity{{Id: 5, Name: "e"}},
+	}, values)
+}
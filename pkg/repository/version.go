@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var ErrVersionConflict = errors.New("entity version changed")
+
+// versionColumn returns the column tagged with the version option, e.g.
+// db:"version,version": an integer column counting the changes of a row.
+// Touch increments it along with updated_at, and Edit checks and increments
+// it.
+func (r *entityRepository[E, ID]) versionColumn() (column, bool) {
+	for _, c := range r.columns() {
+		if c.has("version") {
+			return c, true
+		}
+	}
+	return column{}, false
+}
+
+// bumpVersion increments field, the version field of an entity.
+func bumpVersion(field reflect.Value) error {
+	switch {
+	case field.CanInt():
+		field.SetInt(field.Int() + 1)
+	case field.CanUint():
+		field.SetUint(field.Uint() + 1)
+	default:
+		return fmt.Errorf("version column of type %s is not an integer", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrWindowFunctionsUnsupported = errors.New("server does not support window functions")
+
+// windowFunctionCheck caches whether the server supports window functions. It
+// is shared by all views of a repository.
+type windowFunctionCheck struct {
+	mu      sync.Mutex
+	checked bool
+	err     error
+}
+
+// checkWindowFunctions returns ErrWindowFunctionsUnsupported when the server
+// predates window functions, which arrived in MySQL 8.0 and MariaDB 10.2. The
+// server version is read once per repository; other dialects than
+// MySQLDialect are assumed to support them.
+func (r *entityRepository[E, ID]) checkWindowFunctions() error {
+	if _, ok := r.options.dialect.(MySQLDialect); !ok {
+		return nil
+	}
+	r.windowFunctions.mu.Lock()
+	defer r.windowFunctions.mu.Unlock()
+	if r.windowFunctions.checked {
+		return r.windowFunctions.err
+	}
+
+	var version string
+	if err := r.getOne(&version, "SELECT VERSION()"); err != nil {
+		return err
+	}
+
+	r.windowFunctions.checked = true
+	if !supportsWindowFunctions(version) {
+		r.windowFunctions.err = fmt.Errorf("%w: server version is %s", ErrWindowFunctionsUnsupported, version)
+	}
+	return r.windowFunctions.err
+}
+
+// supportsWindowFunctions reports whether a server reporting version, e.g.
+// 8.0.36 or 10.11.6-MariaDB, supports window functions.
+func supportsWindowFunctions(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	if strings.Contains(strings.ToLower(version), "mariadb") {
+		return major > 10 || major == 10 && minor >= 2
+	}
+	return major >= 8
+}
+
+// FindRanked returns the entities matching conditions in the given order,
+// each with its 1-based position stored in rankColumn, which must be mapped to
+// an integer field tagged readonly, e.g. db:"rank,readonly". Ties in the order
+// column are ranked by id. The position is computed with ROW_NUMBER() over the
+// matching rows only, so conditions narrow the ranking rather than filter a
+// global one.
+func (r *entityRepository[E, ID]) FindRanked(rankColumn string, order OrderClause, conditions map[string]any) ([]*E, error) {
+	rank, ok := r.column(rankColumn)
+	if !ok || !rank.has("readonly") {
+		return nil, fmt.Errorf("rank column %q is not a readonly column", rankColumn)
+	}
+	orderClause, err := orderBy(r.options.dialect, r.validColumns(), []OrderClause{order, {Column: "id"}})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkWindowFunctions(); err != nil {
+		return nil, err
+	}
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+
+	alias := r.quoteIdentifier(rankColumn)
+	query := fmt.Sprintf("SELECT *, ROW_NUMBER() OVER (%s) AS %s FROM %s%s ORDER BY %s",
+		strings.TrimPrefix(orderClause, " "), alias, r.table(), where, alias)
+	var entities []*E
+	if err := r.selectAll(&entities, query, where.args...); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// WindowExpr is a window function computed over the rows of a read and
+// scanned into the readonly column As, e.g. a running total of amount per
+// account in booking order:
+//
+//	WindowExpr{As: "running_total", Function: "SUM", Column: "amount",
+//		PartitionBy: []string{"account"}, OrderBy: []OrderClause{{Column: "booked_on"}, {Column: "id"}}}
+//
+// Function is one of SUM, AVG, MIN, MAX and COUNT, applied to Column, or one
+// of ROW_NUMBER, RANK and DENSE_RANK, which take no column. COUNT without a
+// column counts rows. With OrderBy, aggregates use SQL's default frame: every
+// row up to the current one and its peers, the rows with the same order
+// values, so peers share a running total; order by id last to total row by
+// row.
+type WindowExpr struct {
+	As          string
+	Function    string
+	Column      string
+	PartitionBy []string
+	OrderBy     []OrderClause
+}
+
+// windowAggregates lists the window functions that take a column, and
+// windowRankings those that take none.
+var (
+	windowAggregates = map[string]bool{"SUM": true, "AVG": true, "MIN": true, "MAX": true, "COUNT": true}
+	windowRankings   = map[string]bool{"ROW_NUMBER": true, "RANK": true, "DENSE_RANK": true}
+)
+
+// windowExpr returns the SQL of w, selected under its alias.
+func (r *entityRepository[E, ID]) windowExpr(validColumns map[string]bool, w WindowExpr) (string, error) {
+	target, ok := r.column(w.As)
+	if !ok || !target.has("readonly") {
+		return "", fmt.Errorf("window column %q is not a readonly column", w.As)
+	}
+	function := strings.ToUpper(strings.TrimSpace(w.Function))
+	var call string
+	switch {
+	case windowRankings[function]:
+		if w.Column != "" {
+			return "", fmt.Errorf("window function %s takes no column", function)
+		}
+		if len(w.OrderBy) == 0 {
+			return "", fmt.Errorf("window function %s needs an order", function)
+		}
+		call = function + "()"
+	case function == "COUNT" && w.Column == "":
+		call = "COUNT(*)"
+	case windowAggregates[function]:
+		if !validColumns[w.Column] {
+			return "", fmt.Errorf("unknown column %q", w.Column)
+		}
+		call = fmt.Sprintf("%s(%s)", function, w.Column)
+	default:
+		return "", fmt.Errorf("unsupported window function %q", w.Function)
+	}
+
+	var window []string
+	if len(w.PartitionBy) > 0 {
+		for _, column := range w.PartitionBy {
+			if !validColumns[column] {
+				return "", fmt.Errorf("unknown column %q", column)
+			}
+		}
+		window = append(window, "PARTITION BY "+strings.Join(w.PartitionBy, ", "))
+	}
+	order, err := orderBy(r.options.dialect, validColumns, w.OrderBy)
+	if err != nil {
+		return "", err
+	}
+	if order != "" {
+		window = append(window, strings.TrimPrefix(order, " "))
+	}
+	return fmt.Sprintf("%s OVER (%s) AS %s", call, strings.Join(window, " "), r.quoteIdentifier(w.As)), nil
+}
+
+// FindWithWindows returns the entities matching conditions in the given
+// order, each with the values of windows stored in their readonly columns.
+// Like FindRanked, which it generalizes, the windows range over the matching
+// rows only: a running total restarts at the first row the conditions let
+// through.
+func (r *entityRepository[E, ID]) FindWithWindows(windows []WindowExpr, conditions map[string]any, order ...OrderClause) ([]*E, error) {
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no window expressions given")
+	}
+	validColumns := r.validColumns()
+	selectList := "*"
+	for _, w := range windows {
+		expr, err := r.windowExpr(validColumns, w)
+		if err != nil {
+			return nil, err
+		}
+		selectList += ", " + expr
+	}
+	orderClause, err := orderBy(r.options.dialect, validColumns, order)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.checkWindowFunctions(); err != nil {
+		return nil, err
+	}
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s%s%s", selectList, r.table(), where, orderClause)
+	var entities []*E
+	if err := r.selectAll(&entities, query, where.args...); err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
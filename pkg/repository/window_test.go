@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupportsWindowFunctions(t *testing.T) {
+	require.True(t, supportsWindowFunctions("8.0.36"))
+	require.True(t, supportsWindowFunctions("8.4.0-log"))
+	require.True(t, supportsWindowFunctions("10.11.6-MariaDB-1:10.11.6+maria~ubu2204"))
+	require.False(t, supportsWindowFunctions("5.7.44"))
+	require.False(t, supportsWindowFunctions("10.1.48-MariaDB"))
+	require.False(t, supportsWindowFunctions("unknown"))
+}
+
+func TestFindRanked(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[PlayerEntity](db, WithDialect(StandardDialect{}), WithQueryCapture())
+	_, err = repo.FindRanked("rank", OrderClause{Column: "score", Desc: true}, map[string]any{"name": "a"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, `SELECT *, ROW_NUMBER() OVER (ORDER BY score DESC, id) AS "rank" FROM player_entities WHERE name = ? ORDER BY "rank"`, query)
+	require.Equal(t, []any{"a"}, args)
+
+	_, err = repo.FindRanked("name", OrderClause{Column: "score"}, nil)
+	require.ErrorContains(t, err, "not a readonly column")
+	_, err = repo.FindRanked("rank", OrderClause{Column: "rank"}, nil)
+	require.ErrorContains(t, err, `unknown column "rank"`)
+}
+
+func TestReadonlyColumns(t *testing.T) {
+	repo := &entityRepository[PlayerEntity, int64]{options: newOptions(nil)}
+	require.False(t, repo.validColumns()["rank"])
+	for _, c := range repo.updatableColumns() {
+		require.NotEqual(t, "rank", c.Name)
+	}
+}
+
+func TestFindWithWindows(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[OrderTotalsEntity](db, WithDialect(StandardDialect{}), WithQueryCapture())
+	_, err = repo.FindWithWindows([]WindowExpr{
+		{As: "running_total", Function: "sum", Column: "amount", PartitionBy: []string{"customer"}, OrderBy: []OrderClause{{Column: "id"}}},
+		{As: "customer_total", Function: "COUNT"},
+	}, map[string]any{"customer": "a"}, OrderClause{Column: "id"})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, `SELECT *, SUM(amount) OVER (PARTITION BY customer ORDER BY id) AS "running_total", COUNT(*) OVER () AS "customer_total" `+
+		`FROM order_entities WHERE customer = ? ORDER BY id`, query)
+	require.Equal(t, []any{"a"}, args)
+
+	for _, w := range []WindowExpr{
+		{As: "amount", Function: "SUM", Column: "amount"},
+		{As: "running_total", Function: "SUM", Column: "running_total"},
+		{As: "running_total", Function: "SUM", Column: "amount", PartitionBy: []string{"amount; --"}},
+		{As: "running_total", Function: "SUM", Column: "amount", OrderBy: []OrderClause{{Column: "x"}}},
+		{As: "running_total", Function: "LAG", Column: "amount"},
+		{As: "running_total", Function: "RANK", Column: "amount", OrderBy: []OrderClause{{Column: "id"}}},
+		{As: "running_total", Function: "ROW_NUMBER"},
+	} {
+		_, err = repo.FindWithWindows([]WindowExpr{w}, nil)
+		require.Error(t, err, "%+v", w)
+	}
+	_, err = repo.FindWithWindows(nil, nil)
+	require.Error(t, err)
+}
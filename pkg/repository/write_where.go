@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DeleteBy deletes, or soft-deletes, the rows matching conditions and returns
+// how many were deleted. With a positive limit at most limit rows are deleted,
+// lowest ids first, so large cleanups can run as a loop of short statements
+// until DeleteBy returns 0. Conditions are required; use DeleteAll to delete
+// every row.
+func (r *entityRepository[E, ID]) DeleteBy(conditions map[string]any, limit int) (int64, error) {
+	if len(conditions) == 0 {
+		return 0, fmt.Errorf("refusing to delete without conditions")
+	}
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return 0, err
+	}
+
+	return r.deleteWhere(false, where, limit)
+}
+
+// UpdateWhere sets the columns of values on the rows matching conditions and
+// returns how many rows changed. Every column of values must be one Update
+// may write; see UpdatableEntity. A positive limit works as for DeleteBy.
+func (r *entityRepository[E, ID]) UpdateWhere(conditions, values map[string]any, limit int) (int64, error) {
+	if len(conditions) == 0 {
+		return 0, fmt.Errorf("refusing to update without conditions")
+	}
+	assignments, args, err := r.assignments(values)
+	if err != nil {
+		return 0, err
+	}
+
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return 0, err
+	}
+	clause, whereArgs := r.limitWrite(where, limit)
+	query := fmt.Sprintf("UPDATE %s SET %s%s", r.table(), assignments, clause)
+	return r.execAffected(query, append(args, whereArgs...)...)
+}
+
+// UpdateWhereReturning sets the columns of values on the rows matching
+// conditions, like UpdateWhere without a limit, and returns the ids of the
+// rows it matched, e.g. to invalidate caches or publish events for them. The
+// ids come from RETURNING id with a Returner dialect; otherwise the matching
+// rows are selected and locked first, in the same transaction as the update.
+func (r *entityRepository[E, ID]) UpdateWhereReturning(conditions, values map[string]any) ([]ID, error) {
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("refusing to update without conditions")
+	}
+	assignments, args, err := r.assignments(values)
+	if err != nil {
+		return nil, err
+	}
+	where := r.where()
+	if err := r.addConditions(where, conditions); err != nil {
+		return nil, err
+	}
+
+	if returner, ok := r.options.dialect.(Returner); ok {
+		query := fmt.Sprintf("UPDATE %s SET %s%s%s", r.table(), assignments, where, returner.Returning("id"))
+		ids := []ID{}
+		if err := r.execReturning(&ids, query, append(args, where.args...)...); err != nil {
+			return nil, err
+		}
+		return ids, nil
+	}
+
+	ids := []ID{}
+	err = r.inTx(func(txRepo *entityRepository[E, ID]) error {
+		query := fmt.Sprintf("SELECT id FROM %s%s FOR UPDATE", r.table(), where)
+		if err := txRepo.selectAll(&ids, query, where.args...); err != nil {
+			return err
+		}
+		for _, idChunk := range chunk(ids, maxInListSize) {
+			idArgs := make([]any, len(idChunk))
+			for i, id := range idChunk {
+				idArgs[i] = id
+			}
+			query := fmt.Sprintf("UPDATE %s SET %s WHERE id IN (%s)", r.table(), assignments, placeholders(len(idChunk)))
+			if _, err := txRepo.exec(query, append(append([]any(nil), args...), idArgs...)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// assignments returns the SET list assigning values and its arguments. Every
+// column of values must be one Update may write; see UpdatableEntity.
+func (r *entityRepository[E, ID]) assignments(values map[string]any) (string, []any, error) {
+	if len(values) == 0 {
+		return "", nil, fmt.Errorf("no values to update")
+	}
+
+	updatable := make(map[string]bool)
+	for _, c := range r.updatableColumns() {
+		updatable[c.Name] = true
+	}
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		if !updatable[column] {
+			return "", nil, fmt.Errorf("column %q cannot be updated", column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	assignments := make([]string, len(columns))
+	var args []any
+	for i, column := range columns {
+		value := bindValue(values[column])
+		if transformer, ok := r.options.transformers[column]; ok {
+			encoded, err := transformer.Encode(values[column])
+			if err != nil {
+				return "", nil, fmt.Errorf("encode column %q: %w", column, err)
+			}
+			value = encoded
+		}
+		assignments[i] = column + " = ?"
+		args = append(args, value)
+	}
+	return strings.Join(assignments, ", "), args, nil
+}
+
+// execAffected executes query and returns the number of affected rows.
+func (r *entityRepository[E, ID]) execAffected(query string, args ...any) (int64, error) {
+	res, err := r.exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteBy_Limit(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	cases := map[string]struct {
+		dialect Dialect
+		query   string
+		args    []any
+	}{
+		"mysql": {
+			dialect: MySQLDialect{},
+			query:   "DELETE FROM order_entities WHERE customer = ? ORDER BY id LIMIT ?",
+			args:    []any{"alice", 100},
+		},
+		"standard": {
+			dialect: StandardDialect{},
+			query:   "DELETE FROM order_entities WHERE id IN (SELECT id FROM order_entities WHERE customer = ? ORDER BY id FETCH FIRST ? ROWS ONLY)",
+			args:    []any{"alice", 100},
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := NewEntityRepository[OrderEntity](db, WithDialect(c.dialect), WithQueryCapture())
+			_, err := repo.DeleteBy(map[string]any{"customer": "alice"}, 100)
+			require.Error(t, err)
+			query, args := repo.LastQuery()
+			require.Equal(t, c.query, query)
+			require.Equal(t, c.args, args)
+		})
+	}
+}
+
+func TestUpdateWhere(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	repo := NewEntityRepository[AccountEntity](db, WithQueryCapture())
+	_, err = repo.UpdateWhere(map[string]any{"email": "a@example.com"}, map[string]any{"name": "alice"}, 0)
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "UPDATE account_entities SET name = ? WHERE email = ?", query)
+	require.Equal(t, []any{"alice", "a@example.com"}, args)
+
+	_, err = repo.UpdateWhere(map[string]any{"email": "a@example.com"}, map[string]any{"role": "admin"}, 0)
+	require.ErrorContains(t, err, `column "role" cannot be updated`)
+	_, err = repo.UpdateWhere(nil, map[string]any{"name": "alice"}, 0)
+	require.ErrorContains(t, err, "without conditions")
+}
+
+func TestUpdateWhereReturning_Returner(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[OrderEntity](db, WithDialect(StandardDialect{}), WithQueryCapture())
+	_, err = repo.UpdateWhereReturning(map[string]any{"customer": "alice"}, map[string]any{"amount": 0})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "UPDATE order_entities SET amount = ? WHERE customer = ? RETURNING id", query)
+	require.Equal(t, []any{0, "alice"}, args)
+
+	_, err = repo.UpdateWhereReturning(map[string]any{"customer": "alice"}, map[string]any{"id": 1})
+	require.ErrorContains(t, err, "cannot be updated")
+	_, err = repo.UpdateWhereReturning(nil, map[string]any{"amount": 0})
+	require.Error(t, err)
+}
+
+func TestDeleteByIDsReturning_Returner(t *testing.T) {
+	db, err := sql.Open("mysql", "user:password@tcp(localhost:3306)/db")
+	require.NoError(t, err)
+	db.Close()
+
+	repo := NewEntityRepository[OrderEntity](db, WithDialect(StandardDialect{}), WithQueryCapture())
+	_, err = repo.DeleteByIDsReturning([]int64{1, 2})
+	require.Error(t, err)
+	query, args := repo.LastQuery()
+	require.Equal(t, "DELETE FROM order_entities WHERE id IN (?,?) RETURNING *", query)
+	require.Equal(t, []any{int64(1), int64(2)}, args)
+
+	deleted, err := repo.DeleteByIDsReturning(nil)
+	require.NoError(t, err)
+	require.Empty(t, deleted)
+}
+
+func TestDeleteByIDsReturning_Chunks(t *testing.T) {
+	connector := &recordingConnector{}
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	ids := make([]int64, maxInListSize+1)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	repo := NewEntityRepository[SampleEntity](db, WithDialect(StandardDialect{}))
+	_, err := repo.DeleteByIDsReturning(ids)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		fmt.Sprintf("DELETE FROM sample_entities WHERE id IN (%s) RETURNING *", placeholders(maxInListSize)),
+		"DELETE FROM sample_entities WHERE id IN (?) RETURNING *",
+	}, connector.executed)
+	require.Len(t, connector.args, len(ids))
+}